@@ -0,0 +1,667 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: api/marketdata/v1/marketdata.proto
+
+package marketdatav1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Adjustment int32
+
+const (
+	// Raw traded prices and volumes.
+	Adjustment_ADJUSTMENT_NONE Adjustment = 0
+	// Prices divided and volumes multiplied by later split ratios.
+	Adjustment_ADJUSTMENT_SPLITS Adjustment = 1
+	// Splits, plus prices scaled down by later cash dividends.
+	Adjustment_ADJUSTMENT_SPLITS_AND_DIVIDENDS Adjustment = 2
+)
+
+// Enum value maps for Adjustment.
+var (
+	Adjustment_name = map[int32]string{
+		0: "ADJUSTMENT_NONE",
+		1: "ADJUSTMENT_SPLITS",
+		2: "ADJUSTMENT_SPLITS_AND_DIVIDENDS",
+	}
+	Adjustment_value = map[string]int32{
+		"ADJUSTMENT_NONE":                 0,
+		"ADJUSTMENT_SPLITS":               1,
+		"ADJUSTMENT_SPLITS_AND_DIVIDENDS": 2,
+	}
+)
+
+func (x Adjustment) Enum() *Adjustment {
+	p := new(Adjustment)
+	*p = x
+	return p
+}
+
+func (x Adjustment) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Adjustment) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_marketdata_v1_marketdata_proto_enumTypes[0].Descriptor()
+}
+
+func (Adjustment) Type() protoreflect.EnumType {
+	return &file_api_marketdata_v1_marketdata_proto_enumTypes[0]
+}
+
+func (x Adjustment) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Adjustment.Descriptor instead.
+func (Adjustment) EnumDescriptor() ([]byte, []int) {
+	return file_api_marketdata_v1_marketdata_proto_rawDescGZIP(), []int{0}
+}
+
+type Tick struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Symbol   string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Ts       *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=ts,proto3" json:"ts,omitempty"`
+	Price    float64                `protobuf:"fixed64,3,opt,name=price,proto3" json:"price,omitempty"`
+	Size     float64                `protobuf:"fixed64,4,opt,name=size,proto3" json:"size,omitempty"`
+	Exchange string                 `protobuf:"bytes,5,opt,name=exchange,proto3" json:"exchange,omitempty"`
+	SrcId    string                 `protobuf:"bytes,6,opt,name=src_id,json=srcId,proto3" json:"src_id,omitempty"`
+	// live is false for ticks replayed from storage.
+	Live          bool `protobuf:"varint,7,opt,name=live,proto3" json:"live,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tick) Reset() {
+	*x = Tick{}
+	mi := &file_api_marketdata_v1_marketdata_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tick) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tick) ProtoMessage() {}
+
+func (x *Tick) ProtoReflect() protoreflect.Message {
+	mi := &file_api_marketdata_v1_marketdata_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tick.ProtoReflect.Descriptor instead.
+func (*Tick) Descriptor() ([]byte, []int) {
+	return file_api_marketdata_v1_marketdata_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Tick) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Tick) GetTs() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Ts
+	}
+	return nil
+}
+
+func (x *Tick) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Tick) GetSize() float64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Tick) GetExchange() string {
+	if x != nil {
+		return x.Exchange
+	}
+	return ""
+}
+
+func (x *Tick) GetSrcId() string {
+	if x != nil {
+		return x.SrcId
+	}
+	return ""
+}
+
+func (x *Tick) GetLive() bool {
+	if x != nil {
+		return x.Live
+	}
+	return false
+}
+
+type StreamTicksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbols       []string               `protobuf:"bytes,1,rep,name=symbols,proto3" json:"symbols,omitempty"`
+	From          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamTicksRequest) Reset() {
+	*x = StreamTicksRequest{}
+	mi := &file_api_marketdata_v1_marketdata_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamTicksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamTicksRequest) ProtoMessage() {}
+
+func (x *StreamTicksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_marketdata_v1_marketdata_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamTicksRequest.ProtoReflect.Descriptor instead.
+func (*StreamTicksRequest) Descriptor() ([]byte, []int) {
+	return file_api_marketdata_v1_marketdata_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StreamTicksRequest) GetSymbols() []string {
+	if x != nil {
+		return x.Symbols
+	}
+	return nil
+}
+
+func (x *StreamTicksRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+type GetLatestRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbols       []string               `protobuf:"bytes,1,rep,name=symbols,proto3" json:"symbols,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLatestRequest) Reset() {
+	*x = GetLatestRequest{}
+	mi := &file_api_marketdata_v1_marketdata_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLatestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLatestRequest) ProtoMessage() {}
+
+func (x *GetLatestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_marketdata_v1_marketdata_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLatestRequest.ProtoReflect.Descriptor instead.
+func (*GetLatestRequest) Descriptor() ([]byte, []int) {
+	return file_api_marketdata_v1_marketdata_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetLatestRequest) GetSymbols() []string {
+	if x != nil {
+		return x.Symbols
+	}
+	return nil
+}
+
+type GetLatestResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Symbols without any tick are omitted.
+	Ticks         []*Tick `protobuf:"bytes,1,rep,name=ticks,proto3" json:"ticks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLatestResponse) Reset() {
+	*x = GetLatestResponse{}
+	mi := &file_api_marketdata_v1_marketdata_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLatestResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLatestResponse) ProtoMessage() {}
+
+func (x *GetLatestResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_marketdata_v1_marketdata_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLatestResponse.ProtoReflect.Descriptor instead.
+func (*GetLatestResponse) Descriptor() ([]byte, []int) {
+	return file_api_marketdata_v1_marketdata_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetLatestResponse) GetTicks() []*Tick {
+	if x != nil {
+		return x.Ticks
+	}
+	return nil
+}
+
+type GetBarsRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Symbol   string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Interval *durationpb.Duration   `protobuf:"bytes,2,opt,name=interval,proto3" json:"interval,omitempty"`
+	From     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	// Defaults to now.
+	To *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=to,proto3" json:"to,omitempty"`
+	// Defaults to, and is capped by, the server's grpc.max_bars.
+	Limit uint32 `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	// Adjusts bars before corporate actions to be comparable with today's
+	// prices. Stored ticks are never changed.
+	Adjustment    Adjustment `protobuf:"varint,6,opt,name=adjustment,proto3,enum=streamforge.marketdata.v1.Adjustment" json:"adjustment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBarsRequest) Reset() {
+	*x = GetBarsRequest{}
+	mi := &file_api_marketdata_v1_marketdata_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBarsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBarsRequest) ProtoMessage() {}
+
+func (x *GetBarsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_marketdata_v1_marketdata_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBarsRequest.ProtoReflect.Descriptor instead.
+func (*GetBarsRequest) Descriptor() ([]byte, []int) {
+	return file_api_marketdata_v1_marketdata_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetBarsRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *GetBarsRequest) GetInterval() *durationpb.Duration {
+	if x != nil {
+		return x.Interval
+	}
+	return nil
+}
+
+func (x *GetBarsRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *GetBarsRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *GetBarsRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *GetBarsRequest) GetAdjustment() Adjustment {
+	if x != nil {
+		return x.Adjustment
+	}
+	return Adjustment_ADJUSTMENT_NONE
+}
+
+type Bar struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	Open          float64                `protobuf:"fixed64,2,opt,name=open,proto3" json:"open,omitempty"`
+	High          float64                `protobuf:"fixed64,3,opt,name=high,proto3" json:"high,omitempty"`
+	Low           float64                `protobuf:"fixed64,4,opt,name=low,proto3" json:"low,omitempty"`
+	Close         float64                `protobuf:"fixed64,5,opt,name=close,proto3" json:"close,omitempty"`
+	Volume        float64                `protobuf:"fixed64,6,opt,name=volume,proto3" json:"volume,omitempty"`
+	Count         uint64                 `protobuf:"varint,7,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Bar) Reset() {
+	*x = Bar{}
+	mi := &file_api_marketdata_v1_marketdata_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Bar) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Bar) ProtoMessage() {}
+
+func (x *Bar) ProtoReflect() protoreflect.Message {
+	mi := &file_api_marketdata_v1_marketdata_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Bar.ProtoReflect.Descriptor instead.
+func (*Bar) Descriptor() ([]byte, []int) {
+	return file_api_marketdata_v1_marketdata_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Bar) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *Bar) GetOpen() float64 {
+	if x != nil {
+		return x.Open
+	}
+	return 0
+}
+
+func (x *Bar) GetHigh() float64 {
+	if x != nil {
+		return x.High
+	}
+	return 0
+}
+
+func (x *Bar) GetLow() float64 {
+	if x != nil {
+		return x.Low
+	}
+	return 0
+}
+
+func (x *Bar) GetClose() float64 {
+	if x != nil {
+		return x.Close
+	}
+	return 0
+}
+
+func (x *Bar) GetVolume() float64 {
+	if x != nil {
+		return x.Volume
+	}
+	return 0
+}
+
+func (x *Bar) GetCount() uint64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type GetBarsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Bars          []*Bar                 `protobuf:"bytes,2,rep,name=bars,proto3" json:"bars,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBarsResponse) Reset() {
+	*x = GetBarsResponse{}
+	mi := &file_api_marketdata_v1_marketdata_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBarsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBarsResponse) ProtoMessage() {}
+
+func (x *GetBarsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_marketdata_v1_marketdata_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBarsResponse.ProtoReflect.Descriptor instead.
+func (*GetBarsResponse) Descriptor() ([]byte, []int) {
+	return file_api_marketdata_v1_marketdata_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetBarsResponse) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *GetBarsResponse) GetBars() []*Bar {
+	if x != nil {
+		return x.Bars
+	}
+	return nil
+}
+
+var File_api_marketdata_v1_marketdata_proto protoreflect.FileDescriptor
+
+const file_api_marketdata_v1_marketdata_proto_rawDesc = "" +
+	"\n" +
+	"\"api/marketdata/v1/marketdata.proto\x12\x19streamforge.marketdata.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xbb\x01\n" +
+	"\x04Tick\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12*\n" +
+	"\x02ts\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02ts\x12\x14\n" +
+	"\x05price\x18\x03 \x01(\x01R\x05price\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x01R\x04size\x12\x1a\n" +
+	"\bexchange\x18\x05 \x01(\tR\bexchange\x12\x15\n" +
+	"\x06src_id\x18\x06 \x01(\tR\x05srcId\x12\x12\n" +
+	"\x04live\x18\a \x01(\bR\x04live\"^\n" +
+	"\x12StreamTicksRequest\x12\x18\n" +
+	"\asymbols\x18\x01 \x03(\tR\asymbols\x12.\n" +
+	"\x04from\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\",\n" +
+	"\x10GetLatestRequest\x12\x18\n" +
+	"\asymbols\x18\x01 \x03(\tR\asymbols\"J\n" +
+	"\x11GetLatestResponse\x125\n" +
+	"\x05ticks\x18\x01 \x03(\v2\x1f.streamforge.marketdata.v1.TickR\x05ticks\"\x98\x02\n" +
+	"\x0eGetBarsRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x125\n" +
+	"\binterval\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\binterval\x12.\n" +
+	"\x04from\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\rR\x05limit\x12E\n" +
+	"\n" +
+	"adjustment\x18\x06 \x01(\x0e2%.streamforge.marketdata.v1.AdjustmentR\n" +
+	"adjustment\"\xb5\x01\n" +
+	"\x03Bar\x120\n" +
+	"\x05start\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05start\x12\x12\n" +
+	"\x04open\x18\x02 \x01(\x01R\x04open\x12\x12\n" +
+	"\x04high\x18\x03 \x01(\x01R\x04high\x12\x10\n" +
+	"\x03low\x18\x04 \x01(\x01R\x03low\x12\x14\n" +
+	"\x05close\x18\x05 \x01(\x01R\x05close\x12\x16\n" +
+	"\x06volume\x18\x06 \x01(\x01R\x06volume\x12\x14\n" +
+	"\x05count\x18\a \x01(\x04R\x05count\"]\n" +
+	"\x0fGetBarsResponse\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x122\n" +
+	"\x04bars\x18\x02 \x03(\v2\x1e.streamforge.marketdata.v1.BarR\x04bars*]\n" +
+	"\n" +
+	"Adjustment\x12\x13\n" +
+	"\x0fADJUSTMENT_NONE\x10\x00\x12\x15\n" +
+	"\x11ADJUSTMENT_SPLITS\x10\x01\x12#\n" +
+	"\x1fADJUSTMENT_SPLITS_AND_DIVIDENDS\x10\x022\xb7\x02\n" +
+	"\n" +
+	"MarketData\x12_\n" +
+	"\vStreamTicks\x12-.streamforge.marketdata.v1.StreamTicksRequest\x1a\x1f.streamforge.marketdata.v1.Tick0\x01\x12f\n" +
+	"\tGetLatest\x12+.streamforge.marketdata.v1.GetLatestRequest\x1a,.streamforge.marketdata.v1.GetLatestResponse\x12`\n" +
+	"\aGetBars\x12).streamforge.marketdata.v1.GetBarsRequest\x1a*.streamforge.marketdata.v1.GetBarsResponseBBZ@github.com/jonandereg/streamforge/api/marketdata/v1;marketdatav1b\x06proto3"
+
+var (
+	file_api_marketdata_v1_marketdata_proto_rawDescOnce sync.Once
+	file_api_marketdata_v1_marketdata_proto_rawDescData []byte
+)
+
+func file_api_marketdata_v1_marketdata_proto_rawDescGZIP() []byte {
+	file_api_marketdata_v1_marketdata_proto_rawDescOnce.Do(func() {
+		file_api_marketdata_v1_marketdata_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_marketdata_v1_marketdata_proto_rawDesc), len(file_api_marketdata_v1_marketdata_proto_rawDesc)))
+	})
+	return file_api_marketdata_v1_marketdata_proto_rawDescData
+}
+
+var file_api_marketdata_v1_marketdata_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_api_marketdata_v1_marketdata_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_api_marketdata_v1_marketdata_proto_goTypes = []any{
+	(Adjustment)(0),               // 0: streamforge.marketdata.v1.Adjustment
+	(*Tick)(nil),                  // 1: streamforge.marketdata.v1.Tick
+	(*StreamTicksRequest)(nil),    // 2: streamforge.marketdata.v1.StreamTicksRequest
+	(*GetLatestRequest)(nil),      // 3: streamforge.marketdata.v1.GetLatestRequest
+	(*GetLatestResponse)(nil),     // 4: streamforge.marketdata.v1.GetLatestResponse
+	(*GetBarsRequest)(nil),        // 5: streamforge.marketdata.v1.GetBarsRequest
+	(*Bar)(nil),                   // 6: streamforge.marketdata.v1.Bar
+	(*GetBarsResponse)(nil),       // 7: streamforge.marketdata.v1.GetBarsResponse
+	(*timestamppb.Timestamp)(nil), // 8: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),   // 9: google.protobuf.Duration
+}
+var file_api_marketdata_v1_marketdata_proto_depIdxs = []int32{
+	8,  // 0: streamforge.marketdata.v1.Tick.ts:type_name -> google.protobuf.Timestamp
+	8,  // 1: streamforge.marketdata.v1.StreamTicksRequest.from:type_name -> google.protobuf.Timestamp
+	1,  // 2: streamforge.marketdata.v1.GetLatestResponse.ticks:type_name -> streamforge.marketdata.v1.Tick
+	9,  // 3: streamforge.marketdata.v1.GetBarsRequest.interval:type_name -> google.protobuf.Duration
+	8,  // 4: streamforge.marketdata.v1.GetBarsRequest.from:type_name -> google.protobuf.Timestamp
+	8,  // 5: streamforge.marketdata.v1.GetBarsRequest.to:type_name -> google.protobuf.Timestamp
+	0,  // 6: streamforge.marketdata.v1.GetBarsRequest.adjustment:type_name -> streamforge.marketdata.v1.Adjustment
+	8,  // 7: streamforge.marketdata.v1.Bar.start:type_name -> google.protobuf.Timestamp
+	6,  // 8: streamforge.marketdata.v1.GetBarsResponse.bars:type_name -> streamforge.marketdata.v1.Bar
+	2,  // 9: streamforge.marketdata.v1.MarketData.StreamTicks:input_type -> streamforge.marketdata.v1.StreamTicksRequest
+	3,  // 10: streamforge.marketdata.v1.MarketData.GetLatest:input_type -> streamforge.marketdata.v1.GetLatestRequest
+	5,  // 11: streamforge.marketdata.v1.MarketData.GetBars:input_type -> streamforge.marketdata.v1.GetBarsRequest
+	1,  // 12: streamforge.marketdata.v1.MarketData.StreamTicks:output_type -> streamforge.marketdata.v1.Tick
+	4,  // 13: streamforge.marketdata.v1.MarketData.GetLatest:output_type -> streamforge.marketdata.v1.GetLatestResponse
+	7,  // 14: streamforge.marketdata.v1.MarketData.GetBars:output_type -> streamforge.marketdata.v1.GetBarsResponse
+	12, // [12:15] is the sub-list for method output_type
+	9,  // [9:12] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_api_marketdata_v1_marketdata_proto_init() }
+func file_api_marketdata_v1_marketdata_proto_init() {
+	if File_api_marketdata_v1_marketdata_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_marketdata_v1_marketdata_proto_rawDesc), len(file_api_marketdata_v1_marketdata_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_marketdata_v1_marketdata_proto_goTypes,
+		DependencyIndexes: file_api_marketdata_v1_marketdata_proto_depIdxs,
+		EnumInfos:         file_api_marketdata_v1_marketdata_proto_enumTypes,
+		MessageInfos:      file_api_marketdata_v1_marketdata_proto_msgTypes,
+	}.Build()
+	File_api_marketdata_v1_marketdata_proto = out.File
+	file_api_marketdata_v1_marketdata_proto_goTypes = nil
+	file_api_marketdata_v1_marketdata_proto_depIdxs = nil
+}
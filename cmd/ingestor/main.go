@@ -4,6 +4,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
@@ -11,31 +12,31 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/jonandereg/streamforge/internal/admin"
 	"github.com/jonandereg/streamforge/internal/broker"
+	"github.com/jonandereg/streamforge/internal/config"
 	"github.com/jonandereg/streamforge/internal/ingestor"
 	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
 	"github.com/jonandereg/streamforge/internal/obs"
+	"github.com/jonandereg/streamforge/internal/supervisor"
 	"go.uber.org/zap"
 )
 
 func main() {
+	var flags config.Flags
+	flags.Register(flag.CommandLine)
+	flag.Parse()
+	opts := flags.Options(config.ServiceIngestor)
+	cfg, err := config.Load(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config error:\n%v\n", err)
+		os.Exit(1)
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	metricsPort := 2112
-	// todo: change for envVars
-	obsCfg := obs.Config{
-		ServiceName:    "streamforge-ingestor",
-		ServiceVersion: "0.1.0",
-		Env:            "dev",
-		LogLevel:       "debug",
-		LogJSON:        false,
-		OTLPEndpoint:   "localhost:4318",
-		EnablePprof:    true,
-		MetricsPath:    "/metrics",
-		HealthPath:     "/healthz",
-		ReadyPath:      "/readyz",
-	}
+	obsCfg := cfg.ObsConfig(config.ServiceIngestor)
 
 	o, shutdown, err := obs.Init(ctx, obsCfg)
 	if err != nil {
@@ -52,17 +53,39 @@ func main() {
 	sfmetrics.Register(o.PromRegistry)
 	sfmetrics.Prime()
 	obs.MustRegister(o.PromRegistry, broker.BrokerConnectTotal, broker.BrokerCloseTotal)
+	sfmetrics.RegisterSupervisor(o.PromRegistry)
+	sfmetrics.RegisterConfig(o.PromRegistry)
+	sfmetrics.RegisterAdmin(o.PromRegistry)
+	sfmetrics.RegisterQuality(o.PromRegistry)
+	sfmetrics.RegisterDedup(o.PromRegistry)
+	sup := supervisor.New(o.Logger, 200*time.Millisecond, 30*time.Second)
+
+	rl := config.NewReloader(cfg, opts, o.Logger)
+	rl.OnChange(func(c config.AppConfig) {
+		if err := o.Reconfigure(c.ObsConfig(config.ServiceIngestor)); err != nil {
+			o.Logger.Error("apply reloaded obs config", zap.Error(err))
+		}
+	})
+	sup.Go(ctx, "config-reloader", func(ctx context.Context) error {
+		return rl.Run(ctx, 0)
+	})
 
 	mux := http.NewServeMux()
 	m := o.HTTPMetrics
 	mux.Handle(obsCfg.MetricsPath, o.MetricsHandler)
 	mux.Handle(obsCfg.HealthPath, m.Wrap(obsCfg.HealthPath, o.HealthHandler))
-	mux.Handle(obsCfg.ReadyPath, m.Wrap(obsCfg.ReadyPath, o.ReadyHandler.Handler()))
+	mux.Handle(obsCfg.ReadyPath, m.Wrap(obsCfg.ReadyPath, o.Health.ReadyHandler()))
+	adm, err := admin.NewFromConfig(cfg, o.Logger)
+	if err != nil {
+		o.Logger.Fatal("admin config failed", zap.Error(err))
+	}
+	adm.HandleLogLevel(o.LogLevel)
+	adm.HandleSupervisor(sup)
+	mux.Handle(admin.Prefix, m.Wrap(admin.Prefix, adm))
 	obs.RegisterPprof(mux)
 
-	addr := fmt.Sprintf(":%d", metricsPort)
 	srv := &http.Server{
-		Addr:              addr,
+		Addr:              cfg.Ingestor.HTTPAddr,
 		Handler:           mux,
 		ReadHeaderTimeout: 30 * time.Second,
 	}
@@ -84,7 +107,7 @@ func main() {
 		}
 	}()
 
-	if err := ingestor.Run(ctx, o); err != nil {
+	if err := ingestor.Run(ctx, o, sup, cfg, rl, adm); err != nil {
 		o.Logger.Fatal("ingestor start failed", zap.Error(err))
 	}
 	select {
@@ -40,9 +40,10 @@ func main() {
 
 	mux.Handle(cfg.MetricsPath, o.MetricsHandler)
 	mux.Handle(cfg.HealthPath, m.Wrap(cfg.HealthPath, o.HealthHandler))
-	mux.Handle(cfg.ReadyPath, m.Wrap(cfg.ReadyPath, o.ReadyHandler.Handler()))
+	mux.Handle(cfg.ReadyPath, m.Wrap(cfg.ReadyPath, o.Health.ReadyHandler()))
 
 	o.ReadyHandler.SetReady()
+	go func() { _ = o.Health.Run(ctx) }()
 
 	obs.RegisterPprof(mux)
 
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jonandereg/streamforge/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+const configUsage = `usage: streamforge config print [-config file] [-set path=value ...] [-service name]
+
+  print   show the effective configuration (defaults, file, environment and
+          -set overrides merged) as YAML, with secrets redacted, then report
+          validation problems for -service (ingestor, ticks-processor or all)
+`
+
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprint(os.Stderr, configUsage)
+		if len(args) == 0 {
+			return errors.New("missing config subcommand")
+		}
+		return fmt.Errorf("unknown config subcommand %q", args[0])
+	}
+
+	var flags config.Flags
+	var service string
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	flags.Register(fs)
+	fs.StringVar(&service, "service", "", "validate for one service: ingestor or ticks-processor (default all)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	opts := flags.Options(service)
+	opts.SkipValidation = true
+	cfg, err := config.Load(opts)
+	if err != nil {
+		return err
+	}
+
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg.Redacted()); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(service); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return nil
+}
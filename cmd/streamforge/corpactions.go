@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/corpactions"
+	"github.com/jonandereg/streamforge/internal/store"
+)
+
+const corpactionsUsage = `usage: streamforge corpactions <load|list> [flags]
+
+  load -file actions.csv [-dry-run]   upsert splits and dividends into the corporate_actions table
+  list -symbol AAPL [-since DATE]     print the stored actions of a symbol
+
+The CSV header is symbol,ex_date,kind,value: kind is split (value: new shares
+per old share) or dividend (value: cash per share); see
+configs/corporate_actions.example.csv. The database is database.url from the
+regular config sources.
+`
+
+func runCorpactions(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, corpactionsUsage)
+		return errors.New("missing corpactions subcommand")
+	}
+	switch args[0] {
+	case "load":
+		return corpactionsLoad(args[1:])
+	case "list":
+		return corpactionsList(args[1:])
+	default:
+		fmt.Fprint(os.Stderr, corpactionsUsage)
+		return fmt.Errorf("unknown corpactions subcommand %q", args[0])
+	}
+}
+
+// openStore connects to database.url from the regular config sources.
+func openStore(ctx context.Context) (*store.TickStore, error) {
+	cfg, err := config.Load(config.Options{SkipValidation: true})
+	if err != nil {
+		return nil, err
+	}
+	r, err := cfg.SecretsResolver()
+	if err != nil {
+		return nil, err
+	}
+	return store.New(ctx, cfg.Database, r)
+}
+
+func corpactionsLoad(args []string) error {
+	var file string
+	var dryRun bool
+	fs := flag.NewFlagSet("corpactions load", flag.ContinueOnError)
+	fs.StringVar(&file, "file", "", "CSV file of actions")
+	fs.BoolVar(&dryRun, "dry-run", false, "validate and print the actions without storing them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if file == "" {
+		return errors.New("-file is required")
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	actions, err := corpactions.ParseCSV(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	if dryRun {
+		return printActions(actions)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	ts, err := openStore(ctx)
+	if err != nil {
+		return err
+	}
+	defer ts.Close()
+	if err := ts.UpsertCorporateActions(ctx, actions); err != nil {
+		return err
+	}
+	fmt.Printf("stored %d corporate actions\n", len(actions))
+	return nil
+}
+
+func corpactionsList(args []string) error {
+	var symbol, since string
+	fs := flag.NewFlagSet("corpactions list", flag.ContinueOnError)
+	fs.StringVar(&symbol, "symbol", "", "symbol to list")
+	fs.StringVar(&since, "since", "1970-01-01", "first ex-date to list (YYYY-MM-DD)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if symbol == "" {
+		return errors.New("-symbol is required")
+	}
+	from, err := time.Parse(time.DateOnly, since)
+	if err != nil {
+		return fmt.Errorf("-since: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	ts, err := openStore(ctx)
+	if err != nil {
+		return err
+	}
+	defer ts.Close()
+	actions, err := ts.CorporateActions(ctx, strings.ToUpper(symbol), from)
+	if err != nil {
+		return err
+	}
+	return printActions(actions)
+}
+
+func printActions(actions []corpactions.Action) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "SYMBOL\tEX_DATE\tKIND\tVALUE\n")
+	for _, a := range actions {
+		v := a.Ratio
+		if a.Kind == corpactions.KindDividend {
+			v = a.Amount
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%g\n", a.Symbol, a.ExDate.Format(time.DateOnly), a.Kind, v)
+	}
+	return tw.Flush()
+}
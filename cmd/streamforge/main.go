@@ -1,4 +1,6 @@
 // Command streamforge is the entrypoint for the StreamForge application.
+// Without arguments it prints build information; subcommands provide
+// operational tooling.
 package main
 
 import (
@@ -8,7 +10,49 @@ import (
 	"github.com/jonandereg/streamforge/internal/version"
 )
 
+const usage = `usage: streamforge <command> [args]
+
+commands:
+  version      print build information (default)
+  offsets      show, reset or rewind consumer group offsets
+  config       print the effective configuration
+  secrets      generate keys and seal the encrypted secrets file
+  webhook      run a local alerts webhook receiver for testing
+  corpactions  load or list corporate actions (splits, dividends)
+`
+
 func main() {
+	if len(os.Args) < 2 {
+		printVersion()
+		os.Exit(0)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "version":
+		printVersion()
+	case "offsets":
+		err = runOffsets(os.Args[2:])
+	case "config":
+		err = runConfig(os.Args[2:])
+	case "secrets":
+		err = runSecrets(os.Args[2:])
+	case "webhook":
+		err = runWebhook(os.Args[2:])
+	case "corpactions":
+		err = runCorpactions(os.Args[2:])
+	case "-h", "--help", "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func printVersion() {
 	fmt.Printf("StreamForge %s (commit %s, built %s)\n", version.Version, version.Commit, version.BuildDate)
-	os.Exit(0)
 }
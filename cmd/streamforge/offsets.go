@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/broker"
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/consumer"
+)
+
+const offsetsUsage = `usage: streamforge offsets <show|reset|rewind> [flags]
+
+  show                                    print committed, earliest and latest offsets and lag
+  reset  -to earliest|latest              move the group to the start or end of every partition
+  reset  -to timestamp -time RFC3339      move the group to the first message at or after -time
+  reset  -to offsets -offsets 0:10,1:20   move the group to explicit offsets
+  rewind -by 24h                          move the group back to now minus -by
+
+reset and rewind print the plan only; pass -execute to commit it. The group
+must have no active members while offsets are being rewritten.
+`
+
+type offsetsFlags struct {
+	cfg     config.AppConfig
+	brokers string
+	group   string
+	topic   string
+	timeout time.Duration
+}
+
+func (f *offsetsFlags) register(fs *flag.FlagSet) {
+	// Defaults come from the regular config sources; a broken config only
+	// loses the defaults, the flags still work.
+	cfg, _ := config.Load(config.Options{SkipValidation: true})
+	f.cfg = cfg
+	fs.StringVar(&f.brokers, "brokers", strings.Join(cfg.Kafka.Brokers, ","), "comma-separated broker list")
+	fs.StringVar(&f.group, "group", cfg.Kafka.GroupID, "consumer group id")
+	fs.StringVar(&f.topic, "topic", cfg.Kafka.TicksTopic, "topic name")
+	fs.DurationVar(&f.timeout, "timeout", 30*time.Second, "overall request timeout")
+}
+
+// admin connects with the TLS/SASL settings from the regular config sources.
+func (f *offsetsFlags) admin() (*consumer.OffsetAdmin, error) {
+	sec, err := broker.SecurityFor(f.cfg)
+	if err != nil {
+		return nil, err
+	}
+	return consumer.NewOffsetAdmin(strings.Split(f.brokers, ","), f.group, f.topic, sec)
+}
+
+func runOffsets(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, offsetsUsage)
+		return errors.New("missing offsets subcommand")
+	}
+	switch args[0] {
+	case "show":
+		return offsetsShow(args[1:])
+	case "reset":
+		return offsetsReset(args[1:])
+	case "rewind":
+		return offsetsRewind(args[1:])
+	default:
+		fmt.Fprint(os.Stderr, offsetsUsage)
+		return fmt.Errorf("unknown offsets subcommand %q", args[0])
+	}
+}
+
+func offsetsShow(args []string) error {
+	var f offsetsFlags
+	fs := flag.NewFlagSet("offsets show", flag.ContinueOnError)
+	f.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	admin, err := f.admin()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+
+	parts, err := admin.Describe(ctx)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "PARTITION\tCOMMITTED\tEARLIEST\tLATEST\tLAG\n")
+	var total int64
+	for _, p := range parts {
+		committed := "-"
+		if p.Committed >= 0 {
+			committed = fmt.Sprint(p.Committed)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%d\n", p.Partition, committed, p.Earliest, p.Latest, p.Lag())
+		total += p.Lag()
+	}
+	fmt.Fprintf(tw, "\t\t\tTOTAL\t%d\n", total)
+	return tw.Flush()
+}
+
+func offsetsReset(args []string) error {
+	var (
+		f       offsetsFlags
+		to      string
+		at      string
+		offs    string
+		execute bool
+	)
+	fs := flag.NewFlagSet("offsets reset", flag.ContinueOnError)
+	f.register(fs)
+	fs.StringVar(&to, "to", "", "target: earliest|latest|timestamp|offsets")
+	fs.StringVar(&at, "time", "", "RFC3339 time for -to timestamp")
+	fs.StringVar(&offs, "offsets", "", "partition:offset pairs for -to offsets")
+	fs.BoolVar(&execute, "execute", false, "commit the new offsets (default is a dry run)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	admin, err := f.admin()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+
+	var target map[int]int64
+	switch to {
+	case consumer.StartEarliest:
+		target, err = admin.Earliest(ctx)
+	case consumer.StartLatest:
+		target, err = admin.Latest(ctx)
+	case consumer.StartTimestamp:
+		var t time.Time
+		t, err = time.Parse(time.RFC3339, at)
+		if err != nil {
+			return fmt.Errorf("-time: %w", err)
+		}
+		target, err = admin.AtTime(ctx, t)
+	case consumer.StartOffsets:
+		target, err = config.ParseOffsets(offs)
+	default:
+		return fmt.Errorf("-to must be one of earliest, latest, timestamp, offsets (got %q)", to)
+	}
+	if err != nil {
+		return err
+	}
+	return applyOffsets(ctx, admin, target, execute)
+}
+
+func offsetsRewind(args []string) error {
+	var (
+		f       offsetsFlags
+		by      time.Duration
+		execute bool
+	)
+	fs := flag.NewFlagSet("offsets rewind", flag.ContinueOnError)
+	f.register(fs)
+	fs.DurationVar(&by, "by", 0, "how far back to rewind, e.g. 24h")
+	fs.BoolVar(&execute, "execute", false, "commit the new offsets (default is a dry run)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if by <= 0 {
+		return errors.New("-by must be a positive duration")
+	}
+	admin, err := f.admin()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+
+	at := time.Now().Add(-by).UTC()
+	fmt.Printf("rewinding to %s\n", at.Format(time.RFC3339))
+	target, err := admin.AtTime(ctx, at)
+	if err != nil {
+		return err
+	}
+	return applyOffsets(ctx, admin, target, execute)
+}
+
+// applyOffsets prints the current vs. target plan and commits it when execute is set.
+func applyOffsets(ctx context.Context, admin *consumer.OffsetAdmin, target map[int]int64, execute bool) error {
+	current, err := admin.Describe(ctx)
+	if err != nil {
+		return err
+	}
+	committed := make(map[int]int64, len(current))
+	for _, p := range current {
+		committed[p.Partition] = p.Committed
+	}
+
+	parts := make([]int, 0, len(target))
+	for p := range target {
+		parts = append(parts, p)
+	}
+	sort.Ints(parts)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "PARTITION\tCURRENT\tNEW\n")
+	for _, p := range parts {
+		cur := "-"
+		if c, ok := committed[p]; ok && c >= 0 {
+			cur = fmt.Sprint(c)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\n", p, cur, target[p])
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if !execute {
+		fmt.Println("dry run: pass -execute to commit")
+		return nil
+	}
+	if err := admin.Commit(ctx, target); err != nil {
+		return err
+	}
+	fmt.Println("offsets committed")
+	return nil
+}
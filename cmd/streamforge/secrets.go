@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/jonandereg/streamforge/internal/secrets"
+)
+
+const secretsUsage = `usage: streamforge secrets <keygen|seal|names> [flags]
+
+  keygen                         print a new key for STREAMFORGE_SECRETS_KEY
+  seal  -in secrets.json -out F  encrypt a JSON object of name -> secret into F
+  names -file F                  list the secret names stored in F
+
+seal and names read the key from $STREAMFORGE_SECRETS_KEY. Reference a sealed
+secret from config as enc:<name> and set secrets.encrypted_file to F.
+`
+
+func runSecrets(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, secretsUsage)
+		return errors.New("missing secrets subcommand")
+	}
+	switch args[0] {
+	case "keygen":
+		k, err := secrets.NewKey()
+		if err != nil {
+			return err
+		}
+		fmt.Println(k)
+		return nil
+	case "seal":
+		return secretsSeal(args[1:])
+	case "names":
+		return secretsNames(args[1:])
+	default:
+		fmt.Fprint(os.Stderr, secretsUsage)
+		return fmt.Errorf("unknown secrets subcommand %q", args[0])
+	}
+}
+
+func secretsSeal(args []string) error {
+	var in, out string
+	fs := flag.NewFlagSet("secrets seal", flag.ContinueOnError)
+	fs.StringVar(&in, "in", "-", "plaintext JSON object of name -> secret (- for stdin)")
+	fs.StringVar(&out, "out", "", "sealed output file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if out == "" {
+		return errors.New("-out is required")
+	}
+	key, err := secrets.ParseKey(os.Getenv("STREAMFORGE_SECRETS_KEY"))
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if in != "-" {
+		f, err := os.Open(in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	plain := make(map[string]string)
+	if err := json.NewDecoder(r).Decode(&plain); err != nil {
+		return errors.New("input must be a JSON object of string values")
+	}
+	sealed, err := secrets.Seal(plain, key)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, sealed, 0o600); err != nil {
+		return err
+	}
+	fmt.Printf("sealed %d secrets into %s\n", len(plain), out)
+	return nil
+}
+
+func secretsNames(args []string) error {
+	var file string
+	fs := flag.NewFlagSet("secrets names", flag.ContinueOnError)
+	fs.StringVar(&file, "file", os.Getenv("STREAMFORGE_SECRETS_FILE"), "sealed secrets file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	key, err := secrets.ParseKey(os.Getenv("STREAMFORGE_SECRETS_KEY"))
+	if err != nil {
+		return err
+	}
+	all, err := secrets.OpenFile(file, key)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(all))
+	for n := range all {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		fmt.Println(n)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/jonandereg/streamforge/internal/alerts"
+)
+
+const webhookUsage = `usage: streamforge webhook [-addr :9099] [-secret S] [-status 200]
+
+  Runs a local stand-in for an alerts webhook: every POST is printed to
+  stdout and answered with -status (use 500 to exercise retries). With
+  -secret (default $ALERTS_WEBHOOK_SECRET) the signature header is checked
+  and unsigned or mis-signed requests are rejected with 401.
+`
+
+func runWebhook(args []string) error {
+	var addr, secret string
+	var status int
+	fs := flag.NewFlagSet("webhook", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, webhookUsage) }
+	fs.StringVar(&addr, "addr", ":9099", "listen address")
+	fs.StringVar(&secret, "secret", os.Getenv("ALERTS_WEBHOOK_SECRET"), "HMAC secret shared with alerts.webhook.secret")
+	fs.IntVar(&status, "status", http.StatusOK, "status code to answer with")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	http.HandleFunc("POST /", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if secret != "" && !alerts.Verify([]byte(secret), body, r.Header.Get(alerts.SignatureHeader)) {
+			fmt.Fprintf(os.Stderr, "rejected %s: bad signature\n", r.Header.Get("X-Streamforge-Alert-Id"))
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		fmt.Printf("%s\n", body)
+		w.WriteHeader(status)
+	})
+	fmt.Fprintf(os.Stderr, "listening on %s\n", addr)
+	return http.ListenAndServe(addr, nil)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/admin"
+	"github.com/jonandereg/streamforge/internal/consumer"
+	"github.com/jonandereg/streamforge/internal/events"
+	"github.com/jonandereg/streamforge/internal/router"
+	"github.com/jonandereg/streamforge/internal/supervisor"
+	"github.com/jonandereg/streamforge/internal/worker"
+)
+
+// workerStatus combines a worker's router queue with its supervisor state.
+type workerStatus struct {
+	Worker        int    `json:"worker"`
+	QueueDepth    int    `json:"queue_depth"`
+	QueueCapacity int    `json:"queue_capacity"`
+	State         string `json:"state"`
+	Restarts      int    `json:"restarts"`
+	LastError     string `json:"last_error,omitempty"`
+}
+
+// registerAdmin adds the ticks-processor routes: pausing consumption, worker
+// queues and states, and flushing the processor.
+func registerAdmin(adm *admin.Server, cons consumer.Runner, outs []chan events.TickMsg, policy *router.Policy, sup *supervisor.Supervisor, pool *worker.Pool) {
+	pause := func(paused bool) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			if paused {
+				cons.Pause()
+			} else {
+				cons.Resume()
+			}
+			admin.JSON(w, http.StatusOK, map[string]bool{"paused": cons.Paused()})
+		}
+	}
+	adm.Handle("POST /admin/pause", pause(true))
+	adm.Handle("POST /admin/resume", pause(false))
+
+	adm.Handle("GET /admin/workers", func(w http.ResponseWriter, _ *http.Request) {
+		states := make(map[string]supervisor.Status)
+		for _, st := range sup.Snapshot() {
+			states[st.Name] = st
+		}
+		workers := make([]workerStatus, len(outs))
+		for i, ch := range outs {
+			st := states["worker-"+strconv.Itoa(i)]
+			workers[i] = workerStatus{
+				Worker:        i,
+				QueueDepth:    len(ch),
+				QueueCapacity: cap(ch),
+				State:         st.State,
+				Restarts:      st.Restarts,
+				LastError:     st.LastError,
+			}
+		}
+		admin.JSON(w, http.StatusOK, map[string]any{
+			"paused":      cons.Paused(),
+			"drop_policy": policy.Load(),
+			"workers":     workers,
+		})
+	})
+
+	adm.Handle("POST /admin/flush", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+		if err := pool.Flush(ctx); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, worker.ErrNotFlusher) {
+				status = http.StatusNotImplemented
+			}
+			admin.Error(w, status, err)
+			return
+		}
+		admin.JSON(w, http.StatusOK, map[string]bool{"flushed": true})
+	})
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/admin"
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/consumer"
+	"github.com/jonandereg/streamforge/internal/deadletter"
+	"github.com/jonandereg/streamforge/internal/events"
+	"github.com/jonandereg/streamforge/internal/router"
+	"github.com/jonandereg/streamforge/internal/secrets"
+	"github.com/jonandereg/streamforge/internal/supervisor"
+	"github.com/jonandereg/streamforge/internal/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// flushCounter counts flushes.
+type flushCounter struct{ flushes atomic.Int32 }
+
+func (*flushCounter) Process(context.Context, events.TickMsg) error { return nil }
+
+func (f *flushCounter) Flush(context.Context) error {
+	f.flushes.Add(1)
+	return nil
+}
+
+type noFlush struct{}
+
+func (noFlush) Process(context.Context, events.TickMsg) error { return nil }
+
+func newAdmin(t *testing.T, proc worker.Processor) (func(method, path string) *httptest.ResponseRecorder, consumer.Runner) {
+	t.Helper()
+	cons, err := consumer.NewTickConsumer(config.Kafka{Brokers: []string{"127.0.0.1:1"}, GroupID: "g", TicksTopic: "ticks"}, nil, zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cons.Close(context.Background()) })
+
+	outs := []chan events.TickMsg{make(chan events.TickMsg, 4), make(chan events.TickMsg, 4)}
+	sup := supervisor.New(zap.NewNop(), time.Millisecond, time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() { cancel(); sup.Wait() })
+	pool, err := worker.StartWorkers(ctx, outs, proc, sup, &deadletter.LogSink{Log: zap.NewNop()}, nil, nil, zap.NewNop())
+	require.NoError(t, err)
+
+	adm := admin.New([]admin.Credential{{Identity: "ops", Token: secrets.New("tok")}}, zap.NewNop())
+	registerAdmin(adm, cons, outs, router.NewPolicy(router.Block), sup, pool)
+	return func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer tok")
+		rec := httptest.NewRecorder()
+		adm.ServeHTTP(rec, req)
+		return rec
+	}, cons
+}
+
+func TestAdminPause(t *testing.T) {
+	do, cons := newAdmin(t, noFlush{})
+
+	rec := do(http.MethodPost, "/admin/pause")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"paused":true}`, rec.Body.String())
+	assert.True(t, cons.Paused())
+
+	rec = do(http.MethodGet, "/admin/workers")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Paused     bool           `json:"paused"`
+		DropPolicy string         `json:"drop_policy"`
+		Workers    []workerStatus `json:"workers"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.True(t, body.Paused)
+	assert.Equal(t, "block", body.DropPolicy)
+	require.Len(t, body.Workers, 2)
+	assert.Equal(t, 4, body.Workers[1].QueueCapacity)
+
+	rec = do(http.MethodPost, "/admin/resume")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"paused":false}`, rec.Body.String())
+	assert.False(t, cons.Paused())
+}
+
+func TestAdminFlush(t *testing.T) {
+	f := &flushCounter{}
+	do, _ := newAdmin(t, f)
+	rec := do(http.MethodPost, "/admin/flush")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"flushed":true}`, rec.Body.String())
+	assert.Equal(t, int32(1), f.flushes.Load())
+
+	do, _ = newAdmin(t, noFlush{})
+	assert.Equal(t, http.StatusNotImplemented, do(http.MethodPost, "/admin/flush").Code)
+}
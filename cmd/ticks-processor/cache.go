@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/health"
+	"github.com/jonandereg/streamforge/internal/obs"
+	"github.com/redis/go-redis/v9"
+)
+
+// openCache connects to redis.addr for the indicators cache. It returns a
+// nil client when no address is configured.
+func openCache(ctx context.Context, cfg config.AppConfig, o *obs.Obs) (*redis.Client, error) {
+	if cfg.Redis.Addr == "" {
+		return nil, nil
+	}
+	r, err := cfg.SecretsResolver()
+	if err != nil {
+		return nil, err
+	}
+	pw, err := r.Resolve(cfg.Redis.Password)
+	if err != nil {
+		return nil, fmt.Errorf("redis.password: %w", err)
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: cfg.Redis.Addr, Password: pw.Reveal(), DB: cfg.Redis.DB})
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		_ = rdb.Close()
+		return nil, fmt.Errorf("redis ping: %w", err)
+	}
+	o.Health.Register("redis", health.NonCritical, func(ctx context.Context) error {
+		return rdb.Ping(ctx).Err()
+	})
+	return rdb, nil
+}
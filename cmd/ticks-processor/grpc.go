@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/jonandereg/streamforge/internal/calendar"
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/grpcapi"
+	"github.com/jonandereg/streamforge/internal/health"
+	"github.com/jonandereg/streamforge/internal/obs"
+	"github.com/jonandereg/streamforge/internal/store"
+	"github.com/jonandereg/streamforge/internal/stream"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// openHistory connects to TimescaleDB when database.url is set and registers
+// it as a non-critical health check. It returns nil without a database.
+func openHistory(ctx context.Context, cfg config.AppConfig, o *obs.Obs) (*store.TickStore, error) {
+	r, err := cfg.SecretsResolver()
+	if err != nil {
+		return nil, err
+	}
+	ts, err := store.New(ctx, cfg.Database, r)
+	if errors.Is(err, store.ErrNotConfigured) {
+		o.Logger.Info("no database configured; gRPC history and bars are disabled")
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	o.Health.Register("timescaledb", health.NonCritical, ts.Ping)
+	return ts, nil
+}
+
+// startGRPC serves the MarketData API on cfg.GRPC.Addr. Serve errors are
+// sent to errCh. The returned function ends open streams, then stops the
+// server, waiting for in-flight unary calls until ctx is done.
+func startGRPC(cfg config.AppConfig, o *obs.Obs, ts *store.TickStore, hub *stream.Hub, calendars *calendar.Registry, errCh chan<- error) (func(ctx context.Context), error) {
+	lis, err := net.Listen("tcp", cfg.GRPC.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("grpc listen: %w", err)
+	}
+	var history grpcapi.History
+	if ts != nil {
+		history = ts
+	}
+	svc := grpcapi.NewService(history, hub, cfg.GRPC, o.Logger)
+	svc.SetCalendars(calendars)
+	srv := grpcapi.NewServer(svc, cfg.GRPC, o.TracerProvider, o.Logger)
+	go func() {
+		if err := srv.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			errCh <- fmt.Errorf("grpc: %w", err)
+		}
+	}()
+	o.Logger.Info("grpc server listening", zap.String("addr", lis.Addr().String()))
+
+	return func(ctx context.Context) {
+		hub.Close()
+		done := make(chan struct{})
+		go func() {
+			srv.GracefulStop()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			srv.Stop()
+		}
+	}, nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
@@ -9,35 +10,40 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/jonandereg/streamforge/internal/admin"
+	"github.com/jonandereg/streamforge/internal/broker"
+	"github.com/jonandereg/streamforge/internal/calendar"
 	"github.com/jonandereg/streamforge/internal/config"
 	"github.com/jonandereg/streamforge/internal/consumer"
+	"github.com/jonandereg/streamforge/internal/deadletter"
 	"github.com/jonandereg/streamforge/internal/events"
+	"github.com/jonandereg/streamforge/internal/health"
 	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
 	"github.com/jonandereg/streamforge/internal/obs"
-	"github.com/jonandereg/streamforge/internal/processing"
+	"github.com/jonandereg/streamforge/internal/refdata"
 	"github.com/jonandereg/streamforge/internal/router"
+	"github.com/jonandereg/streamforge/internal/state"
+	"github.com/jonandereg/streamforge/internal/stream"
+	"github.com/jonandereg/streamforge/internal/supervisor"
 	"github.com/jonandereg/streamforge/internal/worker"
 	"go.uber.org/zap"
 )
 
 func main() {
+	var flags config.Flags
+	flags.Register(flag.CommandLine)
+	flag.Parse()
+	opts := flags.Options(config.ServiceTicksProcessor)
+	envCfg, err := config.Load(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config error:\n%v\n", err)
+		os.Exit(1)
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	metricsPort := 2113
-
-	obsCfg := obs.Config{
-		ServiceName:    "streamforge-ticks-processor",
-		ServiceVersion: "0.1.0",
-		Env:            "dev",
-		LogLevel:       "debug",
-		LogJSON:        false,
-		OTLPEndpoint:   "localhost:4318",
-		EnablePprof:    true,
-		MetricsPath:    "/metrics",
-		HealthPath:     "/healthz",
-		ReadyPath:      "/readyz",
-	}
+	obsCfg := envCfg.ObsConfig(config.ServiceTicksProcessor)
 	o, shutdown, err := obs.Init(ctx, obsCfg)
 
 	if err != nil {
@@ -50,18 +56,36 @@ func main() {
 		}
 	}()
 
-	sfmetrics.Register(o.PromRegistry)
-	sfmetrics.Prime()
+	sfmetrics.RegisterProcessor(o.PromRegistry)
+	sfmetrics.RegisterSupervisor(o.PromRegistry)
+	sfmetrics.RegisterConfig(o.PromRegistry)
+	sfmetrics.RegisterAdmin(o.PromRegistry)
+	sfmetrics.RegisterGRPC(o.PromRegistry)
+	sfmetrics.RegisterQuality(o.PromRegistry)
+	sfmetrics.RegisterDedup(o.PromRegistry)
+	sfmetrics.RegisterPipeline(o.PromRegistry)
+	sfmetrics.RegisterState(o.PromRegistry)
+	sfmetrics.RegisterWindow(o.PromRegistry)
+	sfmetrics.RegisterIndicators(o.PromRegistry)
+	sfmetrics.RegisterAlerts(o.PromRegistry)
+	sfmetrics.RegisterAnomaly(o.PromRegistry)
+	sup := supervisor.New(o.Logger, 200*time.Millisecond, 30*time.Second)
 	mux := http.NewServeMux()
 	m := o.HTTPMetrics
 	mux.Handle(obsCfg.MetricsPath, o.MetricsHandler)
 	mux.Handle(obsCfg.HealthPath, m.Wrap(obsCfg.HealthPath, o.HealthHandler))
-	mux.Handle(obsCfg.ReadyPath, m.Wrap(obsCfg.ReadyPath, o.ReadyHandler.Handler()))
+	mux.Handle(obsCfg.ReadyPath, m.Wrap(obsCfg.ReadyPath, o.Health.ReadyHandler()))
+	adm, err := admin.NewFromConfig(envCfg, o.Logger)
+	if err != nil {
+		o.Logger.Fatal("admin config failed", zap.Error(err))
+	}
+	adm.HandleLogLevel(o.LogLevel)
+	adm.HandleSupervisor(sup)
+	mux.Handle(admin.Prefix, m.Wrap(admin.Prefix, adm))
 	obs.RegisterPprof(mux)
 
-	addr := fmt.Sprintf(":%d", metricsPort)
 	srv := &http.Server{
-		Addr:              addr,
+		Addr:              envCfg.TicksProcessor.HTTPAddr,
 		Handler:           mux,
 		ReadHeaderTimeout: 30 * time.Second,
 	}
@@ -82,44 +106,222 @@ func main() {
 		}
 	}()
 
-	envCfg, _ := config.LoadConfig()
+	sfmetrics.PrimeProcessor(envCfg.Processor.NumWorkers)
 
 	ticksCh := make(chan events.TickMsg, 1024)
-	cons, err := consumer.NewTickConsumer(envCfg.Kafka, o.Logger)
+	sec, err := broker.SecurityFor(envCfg)
+	if err != nil {
+		o.Logger.Fatal("kafka security config failed", zap.Error(err))
+	}
+	if err := consumer.ApplyStartPosition(ctx, envCfg.Kafka, sec, o.Logger); err != nil {
+		o.Logger.Fatal("apply start position failed", zap.Error(err))
+	}
+	cons, err := consumer.New(envCfg.Kafka, sec, o.Logger)
 	if err != nil {
 		o.Logger.Fatal("consumer init failed", zap.Error(err))
 	}
+	o.Health.Register("kafka-consumer", health.Critical, cons.Check)
+	// The consumer stops fetching on ctx; the rest of the pipeline runs on
+	// pipeCtx so it can drain what was already fetched.
+	pipeCtx, cancelPipe := context.WithCancel(context.Background())
+	defer cancelPipe()
+	onDrop := func(m events.TickMsg) {
+		o.Logger.Warn("router drop: worker queue full", zap.String("symbol", m.Tick.Symbol))
+	}
+
+	policy := router.NewPolicy(router.DropPolicy(envCfg.Router.DropPolicy))
+	thresholds := &worker.Thresholds{}
+	thresholds.SetSlow(envCfg.Processor.SlowThreshold)
+	thresholds.SetStuck(envCfg.Processor.StuckAfter)
+	thresholds.SetFlushEvery(envCfg.Processor.FlushInterval)
+
+	var dlq deadletter.Sink = &deadletter.LogSink{Log: o.Logger}
+	if envCfg.Processor.DeadLetterTopic != "" {
+		kdlq := deadletter.NewKafkaSink(envCfg.Kafka.Brokers, envCfg.Processor.DeadLetterTopic, sec)
+		defer func() {
+			if err := kdlq.Close(); err != nil {
+				o.Logger.Warn("dead letter sink close error", zap.Error(err))
+			}
+		}()
+		dlq = kdlq
+	}
+
+	backend, err := state.Open(envCfg.State, envCfg.Kafka.Brokers, sec)
+	if err != nil {
+		o.Logger.Fatal("state backend init failed", zap.Error(err))
+	}
+	states := state.NewManager(backend, o.Logger)
+	defer func() {
+		if err := states.Close(); err != nil {
+			o.Logger.Warn("state backend close error", zap.Error(err))
+		}
+	}()
+
+	ts, err := openHistory(ctx, envCfg, o)
+	if err != nil {
+		o.Logger.Fatal("database init failed", zap.Error(err))
+	}
+	if ts != nil {
+		defer ts.Close()
+	}
+	calendars, err := calendar.Load(envCfg.Calendar.File)
+	if err != nil {
+		o.Logger.Fatal("calendar init failed", zap.Error(err))
+	}
+	var table refdata.Table
+	if ts != nil {
+		table = ts
+	}
+	instruments, err := refdata.Open(ctx, envCfg.Instruments, table)
+	if err != nil {
+		o.Logger.Fatal("symbol master init failed", zap.Error(err))
+	}
+	calendars.SetInstruments(instruments)
+	rdb, err := openCache(ctx, envCfg, o)
+	if err != nil {
+		o.Logger.Fatal("redis init failed", zap.Error(err))
+	}
+	if rdb != nil {
+		defer func() {
+			if err := rdb.Close(); err != nil {
+				o.Logger.Warn("redis close error", zap.Error(err))
+			}
+		}()
+	}
+
+	// The hub feeds live ticks to gRPC streams.
+	hub := stream.NewHub()
+	pl, err := buildPipeline(envCfg, pipelineDeps{
+		sec:         sec,
+		hub:         hub,
+		dlq:         dlq,
+		states:      states,
+		history:     ts,
+		cache:       rdb,
+		calendars:   calendars,
+		instruments: instruments,
+	}, o.Logger)
+	if err != nil {
+		o.Logger.Fatal("pipeline config failed", zap.Error(err))
+	}
+	defer pl.close()
+	proc := pl.chain
+	o.Health.Register("pipeline", health.NonCritical, proc.Check)
+
+	// Stages registered their stores above; state is restored by the
+	// consumer as partitions are assigned. Every checkpoint flushes the
+	// stages first, so offsets are only committed once the output buffered
+	// for them is written; without a backend that is all a checkpoint does,
+	// so it runs at the commit interval.
+	cons.SetStateSync(states)
+	o.Health.Register("state", health.NonCritical, states.Check)
+	checkpointEvery := envCfg.State.CheckpointInterval
+	if backend == nil {
+		checkpointEvery = envCfg.Kafka.CommitInterval
+	}
+	sup.Go(ctx, "state-checkpoint", func(ctx context.Context) error {
+		return states.Run(ctx, checkpointEvery)
+	})
+	consumerDone := make(chan struct{})
 	go func() {
+		defer close(consumerDone)
 		if err := cons.Run(ctx, ticksCh); err != nil {
 			o.Logger.Error("consumer stopped with error", zap.Error(err))
 		}
 		close(ticksCh)
 	}()
-	onDrop := func(m events.TickMsg) {
-		o.Logger.Warn("router drop: worker queue full", zap.String("symbol", m.Tick.Symbol))
-	}
 
-	outs := router.StartRouter(ctx, ticksCh, envCfg.Processor.NumWorkers, envCfg.Processor.QueueCapacity, onDrop)
+	rl := config.NewReloader(envCfg, opts, o.Logger)
+	rl.OnChange(func(c config.AppConfig) {
+		if err := o.Reconfigure(c.ObsConfig(config.ServiceTicksProcessor)); err != nil {
+			o.Logger.Error("apply reloaded obs config", zap.Error(err))
+		}
+		policy.Set(router.DropPolicy(c.Router.DropPolicy))
+		thresholds.SetSlow(c.Processor.SlowThreshold)
+		thresholds.SetStuck(c.Processor.StuckAfter)
+		thresholds.SetFlushEvery(c.Processor.FlushInterval)
+		pl.reconfigure(c)
+	})
+	sup.Go(ctx, "config-reloader", func(ctx context.Context) error {
+		return rl.Run(ctx, 0)
+	})
+	sup.Go(ctx, "health-checks", o.Health.Run)
+
+	outs := router.StartRouter(pipeCtx, ticksCh, envCfg.Processor.NumWorkers, envCfg.Router.QueueCapacity, policy, onDrop)
 
-	proc := &processing.NoopProcessor{
-		Log: o.Logger,
+	stopGRPC := func(context.Context) {}
+	if envCfg.GRPC.Addr != "" {
+		stopGRPC, err = startGRPC(envCfg, o, ts, hub, calendars, errCh)
+		if err != nil {
+			o.Logger.Fatal("grpc init failed", zap.Error(err))
+		}
 	}
 
-	worker.StartWorkers(ctx, outs, proc, o.Logger)
+	pool, err := worker.StartWorkers(pipeCtx, outs, proc, sup, dlq, thresholds, o.Health, o.Logger)
+	if err != nil {
+		o.Logger.Fatal("processor start failed", zap.Error(err))
+	}
+	states.SetFlush(pool.Flush)
+	registerAdmin(adm, cons, outs, policy, sup, pool)
+	o.ReadyHandler.SetReady()
 
 	// ---- SHUTDOWN ----
 	select {
 	case <-ctx.Done():
 		o.Logger.Info("shutdown signal received")
 	case err := <-errCh:
-		o.Logger.Error("metrics server error", zap.Error(err))
+		o.Logger.Error("server error", zap.Error(err))
+		stop()
 	}
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	o.ReadyHandler.SetNotReady()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), envCfg.Processor.ShutdownTimeout)
 	defer cancel()
+	stopGRPC(shutdownCtx)
+	drain(shutdownCtx, o.Logger, consumerDone, sup, pool, cons)
+
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		o.Logger.Error("server shutdown error", zap.Error(err))
 	} else {
 		o.Logger.Info("server stopped cleanly")
 	}
+}
+
+// drain shuts the pipeline down in order: wait for the consumer to stop
+// fetching (which closes the router input), let workers finish the queued
+// messages, flush and close the processor and finally commit offsets and close the
+// consumer. Each step is bounded by ctx; on timeout the remaining steps still
+// run so whatever was acknowledged gets committed.
+func drain(ctx context.Context, log *zap.Logger, consumerDone <-chan struct{}, sup *supervisor.Supervisor, pool *worker.Pool, cons consumer.Runner) {
+	start := time.Now()
+	select {
+	case <-consumerDone:
+		log.Info("consumer stopped fetching")
+	case <-ctx.Done():
+		log.Warn("timed out waiting for consumer to stop")
+	}
+
+	workersDone := make(chan struct{})
+	go func() {
+		sup.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+		log.Info("workers drained")
+	case <-ctx.Done():
+		log.Warn("timed out draining workers")
+	}
 
+	if err := pool.Stop(ctx); err != nil {
+		log.Error("processor stop error", zap.Error(err))
+	}
+
+	// Close must still get a chance to commit after a timeout.
+	closeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := cons.Close(closeCtx); err != nil {
+		log.Error("consumer close error", zap.Error(err))
+	}
+	log.Info("pipeline drained", zap.Duration("elapsed", time.Since(start)))
 }
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jonandereg/streamforge/internal/alerts"
+	"github.com/jonandereg/streamforge/internal/anomaly"
+	"github.com/jonandereg/streamforge/internal/broker"
+	"github.com/jonandereg/streamforge/internal/calendar"
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/deadletter"
+	"github.com/jonandereg/streamforge/internal/dedup"
+	"github.com/jonandereg/streamforge/internal/indicators"
+	"github.com/jonandereg/streamforge/internal/pipeline"
+	"github.com/jonandereg/streamforge/internal/processing"
+	"github.com/jonandereg/streamforge/internal/quality"
+	"github.com/jonandereg/streamforge/internal/refdata"
+	"github.com/jonandereg/streamforge/internal/state"
+	"github.com/jonandereg/streamforge/internal/store"
+	"github.com/jonandereg/streamforge/internal/stream"
+	"github.com/jonandereg/streamforge/internal/worker"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// processorPipeline is the built processing chain plus the stages that need
+// config reloads or closing.
+type processorPipeline struct {
+	chain       *pipeline.Chain
+	checkers    []*quality.Stage
+	alerters    []*alerts.Engine
+	sinks       []*quality.KafkaSink
+	states      *state.Manager  // stateful stages register their stores here
+	instruments *refdata.Master // nil without a symbol master
+	log         *zap.Logger
+}
+
+// pipelineDeps are the shared resources stages are built with.
+type pipelineDeps struct {
+	sec         *broker.Security
+	hub         *stream.Hub
+	dlq         deadletter.Sink
+	states      *state.Manager     // stateful stages register their stores here
+	history     *store.TickStore   // nil without a database
+	cache       *redis.Client      // nil without Redis
+	calendars   *calendar.Registry // market hours
+	instruments *refdata.Master    // nil without a symbol master
+}
+
+// buildPipeline builds pipeline.stages, or the built-in chain when none are
+// configured. Stage types: dedup, quality, anomaly, indicators, alerts, log
+// and hub.
+func buildPipeline(cfg config.AppConfig, deps pipelineDeps, log *zap.Logger) (*processorPipeline, error) {
+	sec, states := deps.sec, deps.states
+	pl := &processorPipeline{states: states, instruments: deps.instruments, log: log}
+	reg := pipeline.Registry{
+		"dedup": func(config.Stage) (worker.Processor, error) {
+			return dedup.New("processor", cfg.Dedup)
+		},
+		"quality": func(config.Stage) (worker.Processor, error) {
+			c, err := quality.NewChecker("processor", cfg.Quality, knownSymbols(cfg, deps.instruments))
+			if err != nil {
+				return nil, err
+			}
+			d, err := anomaly.NewDetector(cfg.Anomaly)
+			if err == nil {
+				d.SetInstruments(deps.instruments)
+				c.SetAnomalies(anomaly.NewTracker(d))
+			} else if cfg.Quality.Actions.Anomaly != "off" {
+				return nil, err
+			}
+			st := &quality.Stage{Checker: c, Log: log}
+			if cfg.Quality.QuarantineTopic != "" {
+				sink := quality.NewKafkaSink(cfg.Kafka.Brokers, cfg.Quality.QuarantineTopic, sec)
+				pl.sinks = append(pl.sinks, sink)
+				st.Quarantine = sink
+			}
+			pl.checkers = append(pl.checkers, st)
+			return st, nil
+		},
+		"anomaly": func(st config.Stage) (worker.Processor, error) {
+			d, err := anomaly.NewDetector(cfg.Anomaly)
+			if err != nil {
+				return nil, err
+			}
+			d.SetInstruments(deps.instruments)
+			baselines, err := state.Register[anomaly.Baseline](states, "anomaly/"+st.Name)
+			if err != nil {
+				return nil, err
+			}
+			var sink anomaly.Sink
+			if cfg.Anomaly.Topic != "" {
+				sink = anomaly.NewKafkaSink(cfg.Kafka.Brokers, cfg.Anomaly.Topic, sec)
+			}
+			return anomaly.NewStage(d, baselines, sink, log), nil
+		},
+		"indicators": func(st config.Stage) (worker.Processor, error) {
+			series, err := state.Register[indicators.Series](states, "indicators/"+st.Name)
+			if err != nil {
+				return nil, err
+			}
+			return indicators.NewProcessor(st.Name, cfg.Indicators, series, indicatorSinks(cfg, sec, deps.history, deps.cache), log), nil
+		},
+		"alerts": func(st config.Stage) (worker.Processor, error) {
+			symbols, err := state.Register[alerts.Symbol](states, "alerts/"+st.Name)
+			if err != nil {
+				return nil, err
+			}
+			e, err := alerts.NewEngine(cfg.Alerts, symbols)
+			if err != nil {
+				return nil, err
+			}
+			e.SetCalendars(deps.calendars)
+			sinks, err := alertSinks(cfg, sec, deps.history, log)
+			if err != nil {
+				return nil, err
+			}
+			pl.alerters = append(pl.alerters, e)
+			return alerts.NewStage(e, sinks, cfg.Alerts.QueueSize, log), nil
+		},
+		"log": func(config.Stage) (worker.Processor, error) {
+			return &processing.NoopProcessor{Log: log}, nil
+		},
+		"hub": func(config.Stage) (worker.Processor, error) {
+			return deps.hub, nil
+		},
+	}
+
+	stages := cfg.Pipeline.Stages
+	if len(stages) == 0 {
+		stages = defaultStages(cfg)
+	}
+	chain, err := pipeline.Build(stages, reg, deps.dlq, log)
+	if err != nil {
+		pl.close()
+		return nil, err
+	}
+	pl.chain = chain
+	log.Info("pipeline built", zap.Strings("stages", chain.Names()))
+	return pl, nil
+}
+
+// defaultStages is the chain used without pipeline.stages.
+func defaultStages(cfg config.AppConfig) []config.Stage {
+	var out []config.Stage
+	if cfg.Dedup.Processor {
+		out = append(out, config.Stage{Name: "dedup", Type: "dedup"})
+	}
+	if cfg.Quality.Processor {
+		out = append(out, config.Stage{Name: "quality", Type: "quality"})
+	}
+	if cfg.Anomaly.Processor {
+		out = append(out, config.Stage{Name: "anomaly", Type: "anomaly"})
+	}
+	if cfg.Indicators.Processor {
+		out = append(out, config.Stage{Name: "indicators", Type: "indicators"})
+	}
+	if cfg.Alerts.Processor {
+		out = append(out, config.Stage{Name: "alerts", Type: "alerts"})
+	}
+	return append(out,
+		config.Stage{Name: "log", Type: "log"},
+		config.Stage{Name: "hub", Type: "hub"},
+	)
+}
+
+// indicatorSinks returns the configured outputs of an indicators stage.
+func indicatorSinks(cfg config.AppConfig, sec *broker.Security, ts *store.TickStore, rdb *redis.Client) []indicators.Sink {
+	var sinks []indicators.Sink
+	if cfg.Indicators.Topic != "" {
+		sinks = append(sinks, indicators.NewKafkaSink(cfg.Kafka.Brokers, cfg.Indicators.Topic, sec))
+	}
+	if cfg.Indicators.Table && ts != nil {
+		sinks = append(sinks, &indicators.TableSink{Table: ts})
+	}
+	if rdb != nil {
+		sinks = append(sinks, &indicators.RedisSink{Client: rdb, Prefix: cfg.Indicators.RedisPrefix, TTL: cfg.Indicators.RedisTTL})
+	}
+	return sinks
+}
+
+// alertSinks returns the configured outputs of an alerts stage.
+func alertSinks(cfg config.AppConfig, sec *broker.Security, ts *store.TickStore, log *zap.Logger) ([]alerts.Sink, error) {
+	a := cfg.Alerts
+	var sinks []alerts.Sink
+	if a.Log {
+		sinks = append(sinks, &alerts.LogSink{Log: log})
+	}
+	if a.Webhook.URL != "" {
+		r, err := cfg.SecretsResolver()
+		if err != nil {
+			return nil, err
+		}
+		secret, err := r.Resolve(a.Webhook.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("alerts.webhook.secret: %w", err)
+		}
+		sinks = append(sinks, alerts.NewWebhookSink(a.Webhook.URL, secret.Reveal(), a.Webhook.Timeout))
+	}
+	if a.Topic != "" {
+		sinks = append(sinks, alerts.NewKafkaSink(cfg.Kafka.Brokers, a.Topic, sec))
+	}
+	if a.Table {
+		if ts == nil {
+			return nil, errors.New("alerts.table requires database.url")
+		}
+		sinks = append(sinks, &alerts.TableSink{Table: ts})
+	}
+	return sinks, nil
+}
+
+// knownSymbols is the unknown_symbol lookup: quality.symbols when set,
+// otherwise the symbol master, otherwise the provider subscriptions.
+func knownSymbols(cfg config.AppConfig, m *refdata.Master) func(string) bool {
+	if len(cfg.Quality.Symbols) > 0 {
+		return quality.Known(cfg.Quality.Symbols)
+	}
+	if m != nil {
+		return m.Known
+	}
+	return quality.Known(cfg.DataProvider.Symbols)
+}
+
+// reconfigure applies a reloaded config to the quality and alerts stages.
+func (pl *processorPipeline) reconfigure(c config.AppConfig) {
+	for _, q := range pl.checkers {
+		if err := q.Configure(c.Quality); err != nil {
+			pl.log.Error("apply reloaded quality config", zap.Error(err))
+			continue
+		}
+		q.Checker.SetKnown(knownSymbols(c, pl.instruments))
+	}
+	for _, e := range pl.alerters {
+		if err := e.Configure(c.Alerts); err != nil {
+			pl.log.Error("apply reloaded alerts config", zap.Error(err))
+		}
+	}
+}
+
+func (pl *processorPipeline) close() {
+	for _, s := range pl.sinks {
+		if err := s.Close(); err != nil {
+			pl.log.Warn("quarantine sink close error", zap.Error(err))
+		}
+	}
+}
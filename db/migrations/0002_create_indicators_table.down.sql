@@ -0,0 +1,4 @@
+SELECT remove_retention_policy('indicators', if_exists => TRUE);
+
+DROP INDEX IF EXISTS indicators_symbol_indicator_ts_desc_idx;
+DROP TABLE IF EXISTS indicators CASCADE;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS indicators (
+  ts          timestamptz       NOT NULL,
+  symbol      text              NOT NULL,
+  indicator   text              NOT NULL,
+  value       double precision  NOT NULL
+);
+
+SELECT create_hypertable('indicators','ts', if_not_exists => TRUE, chunk_time_interval => INTERVAL '1 day');
+
+CREATE INDEX IF NOT EXISTS indicators_symbol_indicator_ts_desc_idx ON indicators (symbol, indicator, ts DESC);
+
+SELECT add_retention_policy('indicators', INTERVAL '90 days', if_not_exists => TRUE);
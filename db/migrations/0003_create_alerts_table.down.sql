@@ -0,0 +1,5 @@
+SELECT remove_retention_policy('alerts', if_exists => TRUE);
+
+DROP INDEX IF EXISTS alerts_symbol_ts_desc_idx;
+DROP INDEX IF EXISTS alerts_rule_ts_desc_idx;
+DROP TABLE IF EXISTS alerts CASCADE;
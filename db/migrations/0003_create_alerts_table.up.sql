@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS alerts (
+  id          text              NOT NULL,
+  ts          timestamptz       NOT NULL,
+  rule        text              NOT NULL,
+  kind        text              NOT NULL,
+  symbol      text              NOT NULL,
+  price       double precision  DEFAULT 0 NOT NULL,
+  message     text              DEFAULT '' NOT NULL,
+  created_at  timestamptz       NOT NULL DEFAULT now(),
+  CONSTRAINT alerts_pk PRIMARY KEY (id, ts)
+);
+
+SELECT create_hypertable('alerts','ts', if_not_exists => TRUE, chunk_time_interval => INTERVAL '7 days');
+
+CREATE INDEX IF NOT EXISTS alerts_symbol_ts_desc_idx ON alerts (symbol, ts DESC);
+CREATE INDEX IF NOT EXISTS alerts_rule_ts_desc_idx ON alerts (rule, ts DESC);
+
+SELECT add_retention_policy('alerts', INTERVAL '365 days', if_not_exists => TRUE);
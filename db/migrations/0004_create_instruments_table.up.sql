@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS instruments (
+  id           text              NOT NULL PRIMARY KEY,
+  asset_class  text              DEFAULT '' NOT NULL,
+  venue        text              DEFAULT '' NOT NULL,
+  currency     text              DEFAULT '' NOT NULL,
+  tick_size    double precision  DEFAULT 0 NOT NULL,
+  aliases      jsonb             DEFAULT '{}' NOT NULL,  -- provider -> provider symbol
+  updated_at   timestamptz       NOT NULL DEFAULT now()
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS corporate_actions (
+  symbol      text              NOT NULL,
+  ex_date     date              NOT NULL,
+  kind        text              NOT NULL CHECK (kind IN ('split', 'dividend')),
+  ratio       double precision  DEFAULT 1 NOT NULL,  -- split: new shares per old share
+  amount      double precision  DEFAULT 0 NOT NULL,  -- dividend: cash per share
+  updated_at  timestamptz       NOT NULL DEFAULT now(),
+  CONSTRAINT corporate_actions_pk PRIMARY KEY (symbol, ex_date, kind)
+);
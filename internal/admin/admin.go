@@ -0,0 +1,212 @@
+// Package admin serves the authenticated control endpoints of a running
+// service under /admin/. Callers authenticate with a bearer token that maps
+// to an identity; every request is audit-logged with that identity.
+package admin
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/config"
+	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
+	"github.com/jonandereg/streamforge/internal/secrets"
+	"go.uber.org/zap"
+)
+
+// Prefix is the path every admin route lives under.
+const Prefix = "/admin/"
+
+// DefaultIdentity is the caller identity of config.Admin.Token.
+const DefaultIdentity = "admin"
+
+type callerKey struct{}
+
+// Caller returns the authenticated identity of an admin request.
+func Caller(r *http.Request) string {
+	id, _ := r.Context().Value(callerKey{}).(string)
+	return id
+}
+
+// Credential is one accepted token and the identity it authenticates.
+type Credential struct {
+	Identity string
+	Token    secrets.Value
+}
+
+// Tokens resolves the admin credentials configured in cfg: Token as
+// DefaultIdentity plus every "identity token" line of TokensFile. An empty
+// result disables the admin API.
+func Tokens(cfg config.Admin, r *secrets.Resolver) ([]Credential, error) {
+	var creds []Credential
+	if cfg.Token != "" {
+		tok, err := r.Resolve(cfg.Token)
+		if err != nil {
+			return nil, fmt.Errorf("admin.token: %w", err)
+		}
+		creds = append(creds, Credential{Identity: DefaultIdentity, Token: tok})
+	}
+	if cfg.TokensFile != "" {
+		fromFile, err := readTokensFile(cfg.TokensFile)
+		if err != nil {
+			return nil, fmt.Errorf("admin.tokens_file: %w", err)
+		}
+		creds = append(creds, fromFile...)
+	}
+	return creds, nil
+}
+
+// readTokensFile parses "identity token" lines; blank lines and lines
+// starting with # are skipped.
+func readTokensFile(path string) ([]Credential, error) {
+	f, err := os.Open(path) //nolint:gosec // path comes from operator config
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var creds []Credential
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: want \"identity token\"", n)
+		}
+		creds = append(creds, Credential{Identity: fields[0], Token: secrets.New(fields[1])})
+	}
+	return creds, sc.Err()
+}
+
+// Server authenticates, audit-logs and dispatches admin requests. Routes
+// can be added while it serves.
+type Server struct {
+	mux   *http.ServeMux
+	creds []Credential
+	log   *zap.Logger
+}
+
+// New creates a Server accepting creds. With no credentials every request is
+// rejected.
+func New(creds []Credential, log *zap.Logger) *Server {
+	return &Server{
+		mux:   http.NewServeMux(),
+		creds: creds,
+		log:   log.Named("audit"),
+	}
+}
+
+// NewFromConfig is New with the credentials configured in cfg.
+func NewFromConfig(cfg config.AppConfig, log *zap.Logger) (*Server, error) {
+	r, err := cfg.SecretsResolver()
+	if err != nil {
+		return nil, err
+	}
+	creds, err := Tokens(cfg.Admin, r)
+	if err != nil {
+		return nil, err
+	}
+	if len(creds) == 0 {
+		log.Warn("admin API disabled: no admin.token or admin.tokens_file configured")
+	}
+	return New(creds, log), nil
+}
+
+// Handle registers h for pattern, a ServeMux pattern such as
+// "POST /admin/pause". Patterns must start with Prefix.
+func (s *Server) Handle(pattern string, h http.HandlerFunc) {
+	s.mux.HandleFunc(pattern, h)
+}
+
+// ServeHTTP authenticates the request, runs the route and writes the audit entry.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	identity, ok := s.authenticate(r)
+	if !ok {
+		sfmetrics.AdminRequestsTotal.WithLabelValues("denied").Inc()
+		s.log.Warn("admin request denied",
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.String("remote", r.RemoteAddr),
+		)
+		w.Header().Set("WWW-Authenticate", `Bearer realm="streamforge-admin"`)
+		Error(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	s.mux.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), callerKey{}, identity)))
+
+	result := "ok"
+	if rec.status >= http.StatusBadRequest {
+		result = "error"
+	}
+	sfmetrics.AdminRequestsTotal.WithLabelValues(result).Inc()
+	s.log.Info("admin request",
+		zap.String("caller", identity),
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path),
+		zap.String("query", r.URL.RawQuery),
+		zap.Int("status", rec.status),
+		zap.String("remote", r.RemoteAddr),
+		zap.Duration("took", time.Since(start)),
+	)
+}
+
+// authenticate matches the bearer token against every credential in
+// constant time.
+func (s *Server) authenticate(r *http.Request) (string, bool) {
+	tok, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || tok == "" {
+		return "", false
+	}
+	identity, found := "", false
+	for _, c := range s.creds {
+		if subtle.ConstantTimeCompare([]byte(tok), []byte(c.Token.Reveal())) == 1 && !found {
+			identity, found = c.Identity, true
+		}
+	}
+	return identity, found
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// JSON writes v with status code.
+func JSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// Error writes {"error": err} with status code.
+func Error(w http.ResponseWriter, code int, err error) {
+	JSON(w, code, map[string]string{"error": err.Error()})
+}
+
+// Decode reads a JSON request body of at most 1 MiB into v.
+func Decode(r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("bad request body: %w", err)
+	}
+	return nil
+}
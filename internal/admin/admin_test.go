@@ -0,0 +1,80 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAuthAndAudit(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	s := New([]Credential{
+		{Identity: "alice", Token: secrets.New("tok-a")},
+		{Identity: "bob", Token: secrets.New("tok-b")},
+	}, zap.New(core))
+	s.Handle("POST /admin/pause", func(w http.ResponseWriter, r *http.Request) {
+		JSON(w, http.StatusOK, map[string]string{"caller": Caller(r)})
+	})
+
+	do := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/pause", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, do("").Code)
+	assert.Equal(t, http.StatusUnauthorized, do("wrong").Code)
+
+	rec := do("tok-b")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"caller":"bob"}`, rec.Body.String())
+
+	audit := logs.FilterMessage("admin request").All()
+	require.Len(t, audit, 1)
+	fields := audit[0].ContextMap()
+	assert.Equal(t, "bob", fields["caller"])
+	assert.Equal(t, "/admin/pause", fields["path"])
+	assert.EqualValues(t, http.StatusOK, fields["status"])
+	assert.Len(t, logs.FilterMessage("admin request denied").All(), 2)
+}
+
+func TestNoCredentialsRejectsEverything(t *testing.T) {
+	s := New(nil, zap.NewNop())
+	s.Handle("GET /admin/symbols", func(http.ResponseWriter, *http.Request) {})
+	req := httptest.NewRequest(http.MethodGet, "/admin/symbols", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestTokensFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "admins")
+	require.NoError(t, os.WriteFile(path, []byte("# operators\nalice tok-a\n\nbob tok-b\n"), 0o600))
+	t.Setenv("TEST_ADMIN_TOKEN", "root-token")
+
+	creds, err := Tokens(config.Admin{Token: "env:TEST_ADMIN_TOKEN", TokensFile: path}, secrets.NewResolver(secrets.Options{}))
+	require.NoError(t, err)
+	require.Len(t, creds, 3)
+	assert.Equal(t, DefaultIdentity, creds[0].Identity)
+	assert.Equal(t, "root-token", creds[0].Token.Reveal())
+	assert.Equal(t, "bob", creds[2].Identity)
+
+	require.NoError(t, os.WriteFile(path, []byte("alice\n"), 0o600))
+	_, err = Tokens(config.Admin{TokensFile: path}, secrets.NewResolver(secrets.Options{}))
+	assert.ErrorContains(t, err, "line 1")
+}
@@ -0,0 +1,45 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/jonandereg/streamforge/internal/supervisor"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// HandleLogLevel registers GET and PUT /admin/log-level for level. A change
+// lasts until the next restart or config reload that touches the log level.
+func (s *Server) HandleLogLevel(level zap.AtomicLevel) {
+	s.Handle("GET /admin/log-level", func(w http.ResponseWriter, _ *http.Request) {
+		JSON(w, http.StatusOK, map[string]string{"level": level.Level().String()})
+	})
+	s.Handle("PUT /admin/log-level", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Level string `json:"level"`
+		}
+		if err := Decode(r, &body); err != nil {
+			Error(w, http.StatusBadRequest, err)
+			return
+		}
+		lvl, err := zapcore.ParseLevel(body.Level)
+		if err != nil {
+			Error(w, http.StatusBadRequest, err)
+			return
+		}
+		old := level.Level()
+		level.SetLevel(lvl)
+		s.log.Info("log level changed",
+			zap.String("caller", Caller(r)),
+			zap.String("old", old.String()),
+			zap.String("new", lvl.String()),
+		)
+		JSON(w, http.StatusOK, map[string]string{"level": lvl.String()})
+	})
+}
+
+// HandleSupervisor registers GET /admin/supervisor listing the supervised
+// components (workers, provider, publisher, ...) and their states.
+func (s *Server) HandleSupervisor(sup *supervisor.Supervisor) {
+	s.Handle("GET /admin/supervisor", sup.Handler().ServeHTTP)
+}
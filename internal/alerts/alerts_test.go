@@ -0,0 +1,205 @@
+package alerts
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/calendar"
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/events"
+	"github.com/jonandereg/streamforge/internal/model"
+	"github.com/jonandereg/streamforge/internal/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var t0 = time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC) // a Thursday
+
+func tick(symbol string, at time.Duration, price float64) model.Tick {
+	return model.Tick{Symbol: symbol, Ts: t0.Add(at), Price: price}
+}
+
+var offset atomic.Int64
+
+// next returns the position of a new message.
+func next() events.KafkaMeta { return events.KafkaMeta{Offset: offset.Add(1)} }
+
+func newEngine(t *testing.T, rules ...config.AlertRule) *Engine {
+	t.Helper()
+	e, err := NewEngine(config.Alerts{Rules: rules, Cooldown: time.Minute}, nil)
+	require.NoError(t, err)
+	return e
+}
+
+func TestCrossWithCooldown(t *testing.T) {
+	e := newEngine(t, config.AlertRule{Name: "aapl-250", Kind: KindCross, Symbols: []string{"AAPL"}, Level: 250, Direction: "up"})
+	assert.Empty(t, e.Observe(tick("AAPL", 0, 251), next()), "no previous price")
+	assert.Empty(t, e.Observe(tick("AAPL", time.Second, 249), next()), "crossed down")
+	out := e.Observe(tick("AAPL", 2*time.Second, 250), next())
+	require.Len(t, out, 1)
+	assert.Equal(t, "aapl-250", out[0].Rule)
+	assert.Equal(t, "AAPL crossed above 250 at 250", out[0].Message)
+
+	e.Observe(tick("AAPL", 3*time.Second, 249), next())
+	assert.Empty(t, e.Observe(tick("AAPL", 4*time.Second, 251), next()), "cooling down")
+	e.Observe(tick("AAPL", 2*time.Minute, 249), next())
+	assert.Len(t, e.Observe(tick("AAPL", 2*time.Minute+time.Second, 251), next()), 1)
+	assert.Empty(t, e.Observe(tick("MSFT", 0, 1), next()), "other symbol")
+}
+
+func TestMoveWithinWindow(t *testing.T) {
+	e := newEngine(t, config.AlertRule{Name: "btc-2pct", Kind: KindMove, Percent: 2, Window: 5 * time.Minute})
+	e.Observe(tick("BTCUSDT", 0, 100), next())
+	assert.Empty(t, e.Observe(tick("BTCUSDT", time.Minute, 101.5), next()))
+	assert.Empty(t, e.Observe(tick("BTCUSDT", 6*time.Minute, 103), next()), "100 and 101.5 left the window")
+	out := e.Observe(tick("BTCUSDT", 7*time.Minute, 99.4), next())
+	require.Len(t, out, 1)
+	assert.Equal(t, "BTCUSDT moved -3.50% in 5m0s (103 -> 99.4)", out[0].Message)
+}
+
+func TestMoveKeepsWindowsOnReload(t *testing.T) {
+	cfg := config.Alerts{Rules: []config.AlertRule{{Name: "btc-2pct", Kind: KindMove, Percent: 2, Window: 5 * time.Minute}}}
+	e, err := NewEngine(cfg, nil)
+	require.NoError(t, err)
+	e.Observe(tick("BTCUSDT", 0, 100), next())
+	require.NoError(t, e.Configure(cfg))
+	out := e.Observe(tick("BTCUSDT", time.Minute, 103), next())
+	require.Len(t, out, 1)
+	assert.Equal(t, "BTCUSDT moved +3.00% in 5m0s (100 -> 103)", out[0].Message)
+}
+
+func TestStateSurvivesRestart(t *testing.T) {
+	store, err := state.Register[Symbol](state.NewManager(nil, zap.NewNop()), "alerts/test")
+	require.NoError(t, err)
+	cfg := config.Alerts{Rules: []config.AlertRule{{Name: "aapl-250", Kind: KindCross, Symbols: []string{"AAPL"}, Level: 250, Direction: "up"}}, Cooldown: time.Hour}
+	at := func(offset int64) events.KafkaMeta { return events.KafkaMeta{Offset: offset} }
+
+	e, err := NewEngine(cfg, store)
+	require.NoError(t, err)
+	e.Observe(tick("AAPL", 0, 249), at(1))
+	require.Len(t, e.Observe(tick("AAPL", time.Second, 251), at(2)), 1)
+
+	// A new engine on the restored store.
+	e, err = NewEngine(cfg, store)
+	require.NoError(t, err)
+	assert.Empty(t, e.Observe(tick("AAPL", time.Second, 251), at(2)), "replayed")
+	e.Observe(tick("AAPL", 2*time.Second, 249), at(3))
+	assert.Empty(t, e.Observe(tick("AAPL", 3*time.Second, 251), at(4)), "cooling down")
+	st, ok := store.Get("AAPL")
+	require.True(t, ok)
+	assert.Equal(t, t0.Add(time.Second), st.Fired["aapl-250"])
+}
+
+func TestStaleDuringHours(t *testing.T) {
+	now := t0 // 10:00 in New York
+	e, err := NewEngine(config.Alerts{Rules: []config.AlertRule{{
+		Name: "msft-stale", Kind: KindStale, Symbols: []string{"MSFT"}, After: time.Minute,
+		Hours: "09:30-16:00", Timezone: "America/New_York",
+	}}}, nil)
+	require.NoError(t, err)
+	e.now = func() time.Time { return now }
+	e.Observe(tick("MSFT", 0, 400), next())
+
+	now = t0.Add(30 * time.Second)
+	assert.Empty(t, e.Expire())
+	now = t0.Add(2 * time.Minute)
+	require.Len(t, e.Expire(), 1)
+	assert.Empty(t, e.Expire(), "fires once per silence")
+
+	e.Observe(tick("MSFT", 3*time.Minute, 400), next())
+	now = t0.Add(10 * time.Hour) // 01:00 the next day in New York
+	assert.Empty(t, e.Expire(), "market closed")
+}
+
+func TestStaleFollowsCalendar(t *testing.T) {
+	cals, err := calendar.Parse([]byte(`
+calendars:
+  - name: XNYS
+    timezone: America/New_York
+    sessions: [{days: [mon, tue, wed, thu, fri], open: "09:30", close: "16:00"}]
+    holidays: ["2025-01-03"]
+symbols:
+  - {calendar: XNYS, symbols: [MSFT]}
+`))
+	require.NoError(t, err)
+	now := t0
+	e := newEngine(t, config.AlertRule{Name: "msft-stale", Kind: KindStale, Symbols: []string{"MSFT"}, After: 5 * time.Minute})
+	e.SetCalendars(cals)
+	e.now = func() time.Time { return now }
+	e.Observe(tick("MSFT", 0, 400), next())
+
+	now = t0.Add(24 * time.Hour)
+	assert.Empty(t, e.Expire(), "holiday")
+	monday := time.Date(2025, 1, 6, 14, 30, 0, 0, time.UTC)
+	now = monday.Add(time.Minute)
+	assert.Empty(t, e.Expire(), "silence counts from the open")
+	now = monday.Add(6 * time.Minute)
+	assert.Len(t, e.Expire(), 1)
+}
+
+func TestWebhookSignsAndRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.True(t, Verify([]byte("s3cret"), body, r.Header.Get(SignatureHeader)))
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	s := NewWebhookSink(srv.URL, "s3cret", time.Second)
+	s.backoff = time.Millisecond
+	require.NoError(t, s.Send(context.Background(), Alert{ID: "a/AAPL/1", Rule: "a", Symbol: "AAPL"}))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+// panicSink panics on every alert.
+type panicSink struct{}
+
+func (panicSink) Name() string                      { return "panic" }
+func (panicSink) Send(context.Context, Alert) error { panic("boom") }
+func (panicSink) Close() error                      { return nil }
+
+// countSink counts the alerts sent to it.
+type countSink struct{ sent atomic.Int32 }
+
+func (*countSink) Name() string { return "count" }
+func (c *countSink) Send(context.Context, Alert) error {
+	c.sent.Add(1)
+	return nil
+}
+func (*countSink) Close() error { return nil }
+
+func TestStageSurvivesSinkPanic(t *testing.T) {
+	e := newEngine(t, config.AlertRule{Name: "up", Kind: KindCross, Level: 100})
+	counted := &countSink{}
+	s := NewStage(e, []Sink{panicSink{}, counted}, 4, zap.NewNop())
+	require.NoError(t, s.Start(context.Background()))
+
+	for _, p := range []float64{99, 101} {
+		require.NoError(t, s.Process(context.Background(), events.TickMsg{Tick: tick("AAPL", 0, p), Kafka: next()}))
+	}
+	require.NoError(t, s.Close(context.Background()))
+	assert.Equal(t, int32(1), counted.sent.Load())
+}
+
+func TestStageFlushAfterClose(t *testing.T) {
+	e := newEngine(t, config.AlertRule{Name: "quiet", Kind: KindStale, Symbols: []string{"AAPL"}, After: time.Minute})
+	now := t0
+	e.now = func() time.Time { return now }
+	e.started = now
+	s := NewStage(e, nil, 4, zap.NewNop())
+	require.NoError(t, s.Start(context.Background()))
+	require.NoError(t, s.Close(context.Background()))
+
+	now = now.Add(time.Hour)
+	assert.NotPanics(t, func() { require.NoError(t, s.Flush(context.Background())) })
+	assert.NoError(t, s.Close(context.Background()))
+}
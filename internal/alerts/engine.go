@@ -0,0 +1,379 @@
+// Package alerts evaluates user-defined price alert rules on the tick
+// stream and delivers the alerts they fire to pluggable sinks. Rules are
+// configured under alerts.rules:
+//
+//   - cross: the price crosses a level, e.g. AAPL crossing 250 upwards;
+//   - move: the price moves more than a percentage within a window, e.g.
+//     BTCUSDT moving 2% in 5 minutes;
+//   - stale: a symbol has no ticks for a while, optionally only within
+//     market hours.
+//
+// An alert of a rule and symbol is not repeated within the rule's cooldown.
+package alerts
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/calendar"
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/events"
+	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
+	"github.com/jonandereg/streamforge/internal/model"
+	"github.com/jonandereg/streamforge/internal/processing/window"
+	"github.com/jonandereg/streamforge/internal/state"
+	"go.uber.org/zap"
+)
+
+// Rule kinds.
+const (
+	KindCross = "cross"
+	KindMove  = "move"
+	KindStale = "stale"
+)
+
+// Alert is one fired alert. ID is derived from the rule, symbol and time,
+// so an alert fired again for a replayed tick has the same ID.
+type Alert struct {
+	ID      string    `json:"id"`
+	Rule    string    `json:"rule"`
+	Kind    string    `json:"kind"`
+	Symbol  string    `json:"symbol"`
+	Ts      time.Time `json:"ts"`
+	Price   float64   `json:"price,omitempty"`
+	Message string    `json:"message"`
+}
+
+type rule struct {
+	config.AlertRule
+	symbols  map[string]bool // nil matches every symbol
+	cooldown time.Duration
+	hours    bool
+	loc      *time.Location
+	open     time.Duration // offsets of Hours from midnight
+	close    time.Duration
+	moves    *window.Windower[first] // move rules
+}
+
+func (r *rule) matches(symbol string) bool { return r.symbols == nil || r.symbols[symbol] }
+
+// session returns the start of the market-hours session containing now,
+// or false outside market hours. Rules without hours are always in session.
+func (r *rule) session(now time.Time) (time.Time, bool) {
+	if !r.hours {
+		return time.Time{}, true
+	}
+	local := now.In(r.loc)
+	if wd := local.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return time.Time{}, false
+	}
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, r.loc)
+	since := local.Sub(midnight)
+	if since < r.open || since >= r.close {
+		return time.Time{}, false
+	}
+	return midnight.Add(r.open), true
+}
+
+// moveSlices is how many hopping windows start within a move rule's window.
+// The reference price is the first one of the oldest window still open, so
+// it is at most the rule's window old, and misses at most a slice of it.
+const moveSlices = 10
+
+// first is the earliest price of a window.
+type first struct {
+	ts    time.Time
+	price float64
+}
+
+var firstPrice = window.Aggregator[first]{
+	Add: func(acc first, t model.Tick) first {
+		if acc.ts.IsZero() || t.Ts.Before(acc.ts) {
+			return first{t.Ts, t.Price}
+		}
+		return acc
+	},
+}
+
+// Symbol is what the engine keeps of a symbol between ticks.
+type Symbol struct {
+	Last  float64              `json:"last"`            // previous price
+	Fired map[string]time.Time `json:"fired,omitempty"` // last alert by rule, for cooldowns
+	Stale map[string]bool      `json:"stale,omitempty"` // stale alerts not yet ended by a tick
+}
+
+// Engine evaluates rules. The state of each symbol lives in a state.Store,
+// so cooldowns and open stale alerts survive restarts when state is
+// checkpointed, and replayed ticks are not judged twice. It is safe for
+// concurrent use.
+type Engine struct {
+	now       func() time.Time
+	started   time.Time
+	calendars *calendar.Registry
+	symbols   *state.Store[Symbol]
+
+	mu    sync.Mutex
+	rules []*rule
+	seen  map[string]time.Time
+	quiet map[string]Symbol // stale alerts of symbols without ticks here
+}
+
+// NewEngine creates an engine for cfg keeping symbol state in store, or in
+// memory when store is nil.
+func NewEngine(cfg config.Alerts, store *state.Store[Symbol]) (*Engine, error) {
+	if store == nil {
+		var err error
+		if store, err = state.Register[Symbol](state.NewManager(nil, zap.NewNop()), "alerts"); err != nil {
+			return nil, err
+		}
+	}
+	e := &Engine{
+		now:       time.Now,
+		calendars: calendar.Default(),
+		symbols:   store,
+		seen:      make(map[string]time.Time),
+		quiet:     make(map[string]Symbol),
+	}
+	e.started = e.now()
+	if err := e.Configure(cfg); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Configure replaces the rules, e.g. after a config reload. Cooldowns, and
+// the windows of move rules whose window is unchanged, are kept.
+func (e *Engine) Configure(cfg config.Alerts) error {
+	var rules []*rule
+	for _, rc := range cfg.Rules {
+		r := &rule{AlertRule: rc, cooldown: rc.Cooldown}
+		if r.cooldown == 0 {
+			r.cooldown = cfg.Cooldown
+		}
+		if r.Direction == "" {
+			r.Direction = "any"
+		}
+		if len(rc.Symbols) > 0 {
+			r.symbols = make(map[string]bool, len(rc.Symbols))
+			for _, s := range rc.Symbols {
+				r.symbols[s] = true
+			}
+		}
+		if rc.Hours != "" {
+			open, closing, err := config.ParseHours(rc.Hours)
+			if err != nil {
+				return fmt.Errorf("alert rule %s: hours: %w", rc.Name, err)
+			}
+			loc, err := time.LoadLocation(rc.Timezone)
+			if err != nil {
+				return fmt.Errorf("alert rule %s: timezone: %w", rc.Name, err)
+			}
+			r.hours, r.loc, r.open, r.close = true, loc, open, closing
+		}
+		sfmetrics.AlertsFiredTotal.WithLabelValues(rc.Name, rc.Kind).Add(0)
+		rules = append(rules, r)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range rules {
+		if r.Kind != KindMove {
+			continue
+		}
+		if old := e.rule(r.Name); old != nil && old.moves != nil && old.Window == r.Window {
+			r.moves = old.moves
+			continue
+		}
+		w, err := window.New("alerts/"+r.Name, window.Spec{
+			Kind:  window.Hopping,
+			Size:  r.Window,
+			Slide: max(r.Window/moveSlices, 1),
+			Early: true,
+		}, firstPrice)
+		if err != nil {
+			return fmt.Errorf("alert rule %s: %w", r.Name, err)
+		}
+		r.moves = w
+	}
+	e.rules = rules
+	return nil
+}
+
+func (e *Engine) rule(name string) *rule {
+	for _, r := range e.rules {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// SetCalendars sets the market hours stale rules follow: a symbol is only
+// stale while its market is open.
+func (e *Engine) SetCalendars(r *calendar.Registry) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calendars = r
+}
+
+// Observe evaluates the cross and move rules on t, read at at, and returns
+// the alerts fired. It also ends open stale alerts of t's symbol. Ticks
+// already applied to the symbol's state are skipped.
+func (e *Engine) Observe(t model.Tick, at events.KafkaMeta) []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.seen[t.Symbol] = e.now()
+	var out []Alert
+	e.symbols.Update(t.Symbol, at, func(st *Symbol, exists bool) {
+		if !exists {
+			*st = e.quiet[t.Symbol]
+			delete(e.quiet, t.Symbol)
+		}
+		out = e.observe(st, exists, t, at)
+		st.Last = t.Price
+	})
+	return out
+}
+
+func (e *Engine) observe(st *Symbol, hasPrev bool, t model.Tick, at events.KafkaMeta) []Alert {
+	prev := st.Last
+	var out []Alert
+	for _, r := range e.rules {
+		if !r.matches(t.Symbol) {
+			continue
+		}
+		switch r.Kind {
+		case KindCross:
+			if !hasPrev {
+				continue
+			}
+			up := prev < r.Level && t.Price >= r.Level
+			down := prev > r.Level && t.Price <= r.Level
+			if r.Direction == "up" && up || r.Direction == "down" && down || r.Direction == "any" && (up || down) {
+				dir := "above"
+				if down {
+					dir = "below"
+				}
+				out = e.fire(out, r, st, t.Symbol, t.Ts, t.Price,
+					fmt.Sprintf("%s crossed %s %g at %g", t.Symbol, dir, r.Level, t.Price))
+			}
+		case KindMove:
+			ref, ok := reference(r.moves, t, at)
+			if !ok || ref <= 0 {
+				continue
+			}
+			if pct := (t.Price - ref) / ref * 100; pct >= r.Percent || -pct >= r.Percent {
+				out = e.fire(out, r, st, t.Symbol, t.Ts, t.Price,
+					fmt.Sprintf("%s moved %+.2f%% in %s (%g -> %g)", t.Symbol, pct, r.Window, ref, t.Price))
+			}
+		case KindStale:
+			delete(st.Stale, r.Name)
+		}
+	}
+	return out
+}
+
+// reference adds t to the move windows of its symbol and returns the first
+// price of the oldest one still open. Ticks later than all open windows
+// have no reference.
+func reference(w *window.Windower[first], t model.Tick, at events.KafkaMeta) (float64, bool) {
+	res, err := w.Add(t.Symbol, t, at)
+	if err != nil {
+		return 0, false
+	}
+	// Early results are the open windows of t, ordered by end.
+	for _, r := range res {
+		if r.Early {
+			return r.Value.price, true
+		}
+	}
+	return 0, false
+}
+
+// Expire evaluates the stale rules against the wall clock and returns the
+// alerts fired. A stale alert fires once per silence; the next tick of the
+// symbol ends it. Silence before a session opens, by the rule's hours or the
+// symbol's calendar, does not count.
+func (e *Engine) Expire() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now()
+	var out []Alert
+	for _, r := range e.rules {
+		if r.Kind != KindStale {
+			continue
+		}
+		open, ok := r.session(now)
+		if !ok {
+			continue
+		}
+		for _, sym := range r.Symbols {
+			st, ok := e.symbols.Get(sym)
+			if !ok {
+				st = e.quiet[sym]
+			}
+			if st.Stale[r.Name] {
+				continue
+			}
+			since, ok := e.seen[sym]
+			if !ok {
+				since = e.started
+			}
+			sess, ok := e.calendars.For(sym).SessionAt(now)
+			if !ok {
+				continue
+			}
+			for _, t := range []time.Time{open, sess.Open} {
+				if t.After(since) {
+					since = t
+				}
+			}
+			if now.Sub(since) < r.After {
+				continue
+			}
+			msg := fmt.Sprintf("no ticks for %s since %s", sym, since.UTC().Format(time.RFC3339))
+			e.modify(sym, func(st *Symbol) {
+				if st.Stale == nil {
+					st.Stale = make(map[string]bool)
+				}
+				st.Stale[r.Name] = true
+				out = e.fire(out, r, st, sym, now, 0, msg)
+			})
+		}
+	}
+	return out
+}
+
+// modify changes the state of symbol outside of a tick: in the store, or
+// in memory until the symbol's first tick here.
+func (e *Engine) modify(symbol string, fn func(st *Symbol)) {
+	if e.symbols.Modify(symbol, fn) {
+		return
+	}
+	st := e.quiet[symbol]
+	fn(&st)
+	e.quiet[symbol] = st
+}
+
+// fire appends the alert unless the rule is cooling down for symbol, whose
+// state is st.
+func (e *Engine) fire(out []Alert, r *rule, st *Symbol, symbol string, ts time.Time, price float64, msg string) []Alert {
+	if last, ok := st.Fired[r.Name]; ok && ts.Sub(last).Abs() < r.cooldown {
+		sfmetrics.AlertsSuppressedTotal.WithLabelValues(r.Name).Inc()
+		return out
+	}
+	if st.Fired == nil {
+		st.Fired = make(map[string]time.Time)
+	}
+	st.Fired[r.Name] = ts
+	sfmetrics.AlertsFiredTotal.WithLabelValues(r.Name, r.Kind).Inc()
+	return append(out, Alert{
+		ID:      fmt.Sprintf("%s/%s/%d", r.Name, symbol, ts.UnixNano()),
+		Rule:    r.Name,
+		Kind:    r.Kind,
+		Symbol:  symbol,
+		Ts:      ts,
+		Price:   price,
+		Message: msg,
+	})
+}
@@ -0,0 +1,201 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/broker"
+	"github.com/jonandereg/streamforge/internal/store"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// Sink delivers alerts.
+type Sink interface {
+	// Name labels the sink in metrics and logs.
+	Name() string
+	Send(ctx context.Context, a Alert) error
+	Close() error
+}
+
+// SignatureHeader carries the HMAC-SHA256 of a webhook body as "sha256=<hex>".
+const SignatureHeader = "X-Streamforge-Signature"
+
+// Sign returns the SignatureHeader value of body for secret.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether sig is the signature of body for secret.
+func Verify(secret, body []byte, sig string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(sig))
+}
+
+// WebhookSink POSTs each alert as JSON. Network errors, 429 and 5xx
+// responses are retried a few times with backoff.
+type WebhookSink struct {
+	url     string
+	secret  []byte
+	client  *http.Client
+	retries int
+	backoff time.Duration
+}
+
+// NewWebhookSink creates a sink posting to url, signing bodies with secret
+// when it is not empty.
+func NewWebhookSink(url, secret string, timeout time.Duration) *WebhookSink {
+	s := &WebhookSink{url: url, client: &http.Client{Timeout: timeout}, retries: 3, backoff: 200 * time.Millisecond}
+	if secret != "" {
+		s.secret = []byte(secret)
+	}
+	return s
+}
+
+// Name implements Sink.
+func (s *WebhookSink) Name() string { return "webhook" }
+
+// Send implements Sink.
+func (s *WebhookSink) Send(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	wait := s.backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := s.post(ctx, a.ID, body)
+		if err == nil || !retry || attempt == s.retries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+func (s *WebhookSink) post(ctx context.Context, id string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Streamforge-Alert-Id", id)
+	if s.secret != nil {
+		req.Header.Set(SignatureHeader, Sign(s.secret, body))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("webhook: %s", resp.Status)
+	}
+	return false, nil
+}
+
+// Close implements Sink.
+func (s *WebhookSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// KafkaSink publishes each alert as JSON keyed by symbol.
+type KafkaSink struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaSink creates a sink writing to topic.
+func NewKafkaSink(brokers []string, topic string, sec *broker.Security) *KafkaSink {
+	return &KafkaSink{writer: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Transport:    sec.Transport(),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: 10 * time.Millisecond,
+	}}
+}
+
+// Name implements Sink.
+func (s *KafkaSink) Name() string { return "kafka" }
+
+// Send implements Sink.
+func (s *KafkaSink) Send(ctx context.Context, a Alert) error {
+	val, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return s.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(a.Symbol),
+		Value: val,
+		Headers: []kafka.Header{
+			{Key: "content-type", Value: []byte("application/json")},
+			{Key: "alert_rule", Value: []byte(a.Rule)},
+		},
+		Time: a.Ts,
+	})
+}
+
+// Close flushes and closes the underlying writer.
+func (s *KafkaSink) Close() error { return s.writer.Close() }
+
+// Table is the part of store.TickStore the TableSink writes through.
+type Table interface {
+	InsertAlert(ctx context.Context, a store.AlertRecord) error
+}
+
+// TableSink persists alerts to the alerts hypertable.
+type TableSink struct {
+	Table Table
+}
+
+// Name implements Sink.
+func (s *TableSink) Name() string { return "table" }
+
+// Send implements Sink.
+func (s *TableSink) Send(ctx context.Context, a Alert) error {
+	return s.Table.InsertAlert(ctx, store.AlertRecord{
+		ID: a.ID, Ts: a.Ts, Rule: a.Rule, Kind: a.Kind, Symbol: a.Symbol, Price: a.Price, Message: a.Message,
+	})
+}
+
+// Close implements Sink; the store is owned by the caller.
+func (s *TableSink) Close() error { return nil }
+
+// LogSink writes alerts to the service log.
+type LogSink struct {
+	Log *zap.Logger
+}
+
+// Name implements Sink.
+func (s *LogSink) Name() string { return "log" }
+
+// Send implements Sink.
+func (s *LogSink) Send(_ context.Context, a Alert) error {
+	s.Log.Warn("alert",
+		zap.String("rule", a.Rule),
+		zap.String("kind", a.Kind),
+		zap.String("symbol", a.Symbol),
+		zap.Time("ts", a.Ts),
+		zap.String("message", a.Message),
+	)
+	return nil
+}
+
+// Close implements Sink.
+func (s *LogSink) Close() error { return nil }
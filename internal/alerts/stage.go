@@ -0,0 +1,131 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/jonandereg/streamforge/internal/events"
+	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// Stage runs an Engine as a worker.Processor. Ticks always pass on. Fired
+// alerts are queued and delivered to every sink by one goroutine, so a slow
+// webhook does not hold up the workers; when the queue is full, alerts are
+// dropped. Stale rules are evaluated on every Flush.
+type Stage struct {
+	Engine *Engine
+	sinks  []Sink
+	queue  chan Alert
+	log    *zap.Logger
+
+	once sync.Once
+	done chan struct{}
+
+	mu     sync.Mutex // guards closed and sends on queue
+	closed bool
+}
+
+// NewStage creates a stage delivering to sinks through a queue of queueSize.
+func NewStage(engine *Engine, sinks []Sink, queueSize int, log *zap.Logger) *Stage {
+	return &Stage{
+		Engine: engine,
+		sinks:  sinks,
+		queue:  make(chan Alert, queueSize),
+		log:    log,
+		done:   make(chan struct{}),
+	}
+}
+
+// Process implements worker.Processor.
+func (s *Stage) Process(_ context.Context, msg events.TickMsg) error {
+	s.enqueue(s.Engine.Observe(msg.Tick, msg.Kafka))
+	return nil
+}
+
+// Start implements worker.Starter, starting delivery.
+func (s *Stage) Start(context.Context) error {
+	s.once.Do(func() { go s.deliver() })
+	return nil
+}
+
+// Flush implements worker.Flusher.
+func (s *Stage) Flush(context.Context) error {
+	s.enqueue(s.Engine.Expire())
+	return nil
+}
+
+// Close implements worker.Closer. It waits until the queued alerts are
+// delivered or ctx ends, then closes the sinks. Alerts fired after Close,
+// e.g. by a late Flush, are dropped.
+func (s *Stage) Close(ctx context.Context) error {
+	s.once.Do(func() { close(s.done) }) // never started
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.queue)
+	s.mu.Unlock()
+	var errs []error
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("alerts: %d undelivered: %w", len(s.queue), ctx.Err()))
+	}
+	for _, sink := range s.sinks {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s sink: %w", sink.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Stage) enqueue(alerts []Alert) {
+	if len(alerts) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range alerts {
+		if s.closed {
+			sfmetrics.AlertsDroppedTotal.Inc()
+			s.log.Warn("alert dropped: stage closed", zap.String("rule", a.Rule), zap.String("symbol", a.Symbol))
+			continue
+		}
+		select {
+		case s.queue <- a:
+		default:
+			sfmetrics.AlertsDroppedTotal.Inc()
+			s.log.Warn("alert dropped: delivery queue full", zap.String("rule", a.Rule), zap.String("symbol", a.Symbol))
+		}
+	}
+}
+
+func (s *Stage) deliver() {
+	defer close(s.done)
+	for a := range s.queue {
+		for _, sink := range s.sinks {
+			if err := send(sink, a); err != nil {
+				sfmetrics.AlertsDeliveredTotal.WithLabelValues(sink.Name(), "failure").Inc()
+				s.log.Warn("alert delivery failed", zap.String("sink", sink.Name()), zap.String("id", a.ID), zap.Error(err))
+				continue
+			}
+			sfmetrics.AlertsDeliveredTotal.WithLabelValues(sink.Name(), "success").Inc()
+		}
+	}
+}
+
+// send delivers a to sink, turning a panic into an error: delivery runs
+// outside the supervised workers, so a panic would end the process.
+func send(sink Sink, a Alert) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("sink panicked: %v", p)
+		}
+	}()
+	return sink.Send(context.Background(), a)
+}
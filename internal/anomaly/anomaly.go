@@ -0,0 +1,276 @@
+// Package anomaly flags statistical anomalies per symbol: price jumps
+// (log returns), volume surges (trade sizes) and gaps in tick arrival (time
+// between ticks, or since the last one while none arrive), each measured in
+// standard deviations from an exponentially weighted baseline of the
+// symbol. Baselines keep adapting after an anomaly, so a lasting change
+// stops being flagged.
+package anomaly
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/model"
+	"github.com/jonandereg/streamforge/internal/refdata"
+)
+
+// Kind is the type of anomaly.
+type Kind string
+
+// Anomaly kinds, as used in config, metric labels and events.
+const (
+	PriceJump   Kind = "price_jump"
+	VolumeSurge Kind = "volume_surge"
+	ArrivalGap  Kind = "arrival_gap"
+)
+
+// Severity grades an anomaly by how far it deviates.
+type Severity int
+
+// Severities from least to most severe.
+const (
+	Warning Severity = iota + 1
+	Critical
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Warning:
+		return "warning"
+	case Critical:
+		return "critical"
+	}
+	return "none"
+}
+
+// ParseSeverity parses a severity name from the config.
+func ParseSeverity(s string) (Severity, error) {
+	switch s {
+	case "warning":
+		return Warning, nil
+	case "critical":
+		return Critical, nil
+	}
+	return 0, fmt.Errorf("unknown anomaly severity %q", s)
+}
+
+// MarshalText encodes the severity by name.
+func (s Severity) MarshalText() ([]byte, error) { return []byte(s.String()), nil }
+
+// Event is one detected anomaly with the context it was judged in.
+type Event struct {
+	Symbol   string    `json:"symbol"`
+	Class    string    `json:"class"`
+	Kind     Kind      `json:"kind"`
+	Severity Severity  `json:"severity"`
+	Ts       time.Time `json:"ts"`
+	// Value is the observed log return, size or gap in seconds; Mean and
+	// StdDev describe the baseline it was compared with.
+	Value  float64 `json:"value"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	Score  float64 `json:"score"` // (Value-Mean)/StdDev
+}
+
+func (e Event) String() string {
+	return fmt.Sprintf("%s %s: %g vs mean %g (%.1f sd)", e.Severity, e.Kind, e.Value, e.Mean, e.Score)
+}
+
+// EWM is an exponentially weighted mean and variance.
+type EWM struct {
+	Mean float64 `json:"mean"`
+	Var  float64 `json:"var"`
+	N    int     `json:"n"`
+}
+
+func (w *EWM) add(x, alpha float64) {
+	if w.N == 0 {
+		w.Mean = x
+	} else {
+		d := x - w.Mean
+		w.Mean += alpha * d
+		w.Var = (1 - alpha) * (w.Var + alpha*d*d)
+	}
+	w.N++
+}
+
+// Baseline is the detector state of one symbol. It is plain data so it can
+// be checkpointed.
+type Baseline struct {
+	Last      time.Time `json:"last"`
+	LastPrice float64   `json:"last_price"`
+	Seen      time.Time `json:"seen"`   // wall clock of the last tick
+	Silent    bool      `json:"silent"` // the current gap was reported by Silence
+	Return    EWM       `json:"return"`
+	Volume    EWM       `json:"volume"`
+	Gap       EWM       `json:"gap"`
+}
+
+// Detector judges ticks against baselines. It holds no per-symbol state;
+// see Tracker for an in-memory set of baselines.
+type Detector struct {
+	now         func() time.Time
+	cfg         config.Anomaly
+	kinds       map[Kind]bool
+	classes     []config.SymbolClass
+	instruments *refdata.Master
+}
+
+// NewDetector creates a detector for cfg.
+func NewDetector(cfg config.Anomaly) (*Detector, error) {
+	d := &Detector{now: time.Now, cfg: cfg, kinds: make(map[Kind]bool), classes: cfg.Classes}
+	for _, k := range cfg.Kinds {
+		switch Kind(k) {
+		case PriceJump, VolumeSurge, ArrivalGap:
+			d.kinds[Kind(k)] = true
+		default:
+			return nil, fmt.Errorf("unknown anomaly kind %q", k)
+		}
+	}
+	for _, c := range cfg.Classes {
+		for _, g := range c.Symbols {
+			if _, err := path.Match(g, ""); err != nil {
+				return nil, fmt.Errorf("anomaly class %s: %w", c.Name, err)
+			}
+		}
+	}
+	return d, nil
+}
+
+// SetInstruments sets the symbol master that classes matching by asset
+// class look symbols up in. Call it before the detector is shared.
+func (d *Detector) SetInstruments(m *refdata.Master) { d.instruments = m }
+
+// Class returns the configured class of symbol, or "other".
+func (d *Detector) Class(symbol string) string {
+	in, known := d.instruments.Lookup(symbol)
+	for _, c := range d.classes {
+		if known && in.AssetClass != "" && slices.ContainsFunc(c.AssetClasses, func(ac string) bool {
+			return strings.EqualFold(ac, in.AssetClass)
+		}) {
+			return c.Name
+		}
+		for _, g := range c.Symbols {
+			if ok, _ := path.Match(g, symbol); ok {
+				return c.Name
+			}
+		}
+	}
+	return "other"
+}
+
+// Observe judges t against b, then adds t to b. Ticks older than the last
+// one update only the volume baseline. A gap Silence already reported is
+// not reported again when t ends it.
+func (d *Detector) Observe(b *Baseline, t model.Tick) []Event {
+	silent := b.Silent
+	b.Seen, b.Silent = d.now(), false
+	var out []Event
+	judge := func(k Kind, w *EWM, x float64, upOnly bool) {
+		if d.kinds[k] && !(k == ArrivalGap && silent) {
+			if e, ok := d.judge(w, x, upOnly); ok {
+				e.Symbol, e.Class, e.Kind, e.Ts = t.Symbol, d.Class(t.Symbol), k, t.Ts
+				out = append(out, e)
+			}
+		}
+		w.add(x, d.cfg.Alpha)
+	}
+	if t.Size > 0 {
+		judge(VolumeSurge, &b.Volume, t.Size, true)
+	}
+	if !b.Last.IsZero() && t.Ts.Before(b.Last) {
+		return out
+	}
+	if !b.Last.IsZero() {
+		judge(ArrivalGap, &b.Gap, t.Ts.Sub(b.Last).Seconds(), true)
+		if b.LastPrice > 0 && t.Price > 0 {
+			judge(PriceJump, &b.Return, math.Log(t.Price/b.LastPrice), false)
+		}
+	}
+	b.Last = t.Ts
+	if t.Price > 0 {
+		b.LastPrice = t.Price
+	}
+	return out
+}
+
+// Silence judges the time since the last tick of symbol arrived, or since
+// from if later, as an arrival gap that is still open at now. It reports a
+// gap once; the baseline takes it in when the next tick ends it.
+func (d *Detector) Silence(b *Baseline, symbol string, from, now time.Time) (Event, bool) {
+	if !d.kinds[ArrivalGap] || b.Seen.IsZero() || b.Silent {
+		return Event{}, false
+	}
+	e, ok := d.judge(&b.Gap, now.Sub(maxTime(b.Seen, from)).Seconds(), true)
+	if !ok {
+		return Event{}, false
+	}
+	b.Silent = true
+	e.Symbol, e.Class, e.Kind, e.Ts = symbol, d.Class(symbol), ArrivalGap, now
+	return e, true
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+func (d *Detector) judge(w *EWM, x float64, upOnly bool) (Event, bool) {
+	if w.N < d.cfg.MinSamples {
+		return Event{}, false
+	}
+	// A perfectly regular series (a feed ticking every second) has no
+	// variance; 1% of the mean stands in so a change still registers.
+	sd := max(math.Sqrt(w.Var), 0.01*math.Abs(w.Mean))
+	if sd == 0 {
+		return Event{}, false
+	}
+	z := (x - w.Mean) / sd
+	dev := z
+	if !upOnly {
+		dev = math.Abs(z)
+	}
+	e := Event{Value: x, Mean: w.Mean, StdDev: sd, Score: z}
+	switch {
+	case dev >= d.cfg.Critical:
+		e.Severity = Critical
+	case dev >= d.cfg.Warning:
+		e.Severity = Warning
+	default:
+		return Event{}, false
+	}
+	return e, true
+}
+
+// Tracker keeps baselines in memory and is safe for concurrent use.
+type Tracker struct {
+	d *Detector
+
+	mu        sync.Mutex
+	baselines map[string]*Baseline
+}
+
+// NewTracker creates a tracker judging with d.
+func NewTracker(d *Detector) *Tracker {
+	return &Tracker{d: d, baselines: make(map[string]*Baseline)}
+}
+
+// Observe judges t against its symbol's baseline, then adds it.
+func (tr *Tracker) Observe(t model.Tick) []Event {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	b := tr.baselines[t.Symbol]
+	if b == nil {
+		b = &Baseline{}
+		tr.baselines[t.Symbol] = b
+	}
+	return tr.d.Observe(b, t)
+}
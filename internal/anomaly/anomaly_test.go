@@ -0,0 +1,168 @@
+package anomaly
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/events"
+	"github.com/jonandereg/streamforge/internal/model"
+	"github.com/jonandereg/streamforge/internal/refdata"
+	"github.com/jonandereg/streamforge/internal/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var t0 = time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+
+func testConfig() config.Anomaly {
+	cfg := config.Defaults().Anomaly
+	cfg.MinSamples = 10
+	return cfg
+}
+
+// warm feeds n ticks a second apart with prices and sizes alternating a
+// little around 100 and 10.
+func warm(tr *Tracker, symbol string, n int) []Event {
+	var out []Event
+	for i := range n {
+		d := float64(i%2)*0.2 - 0.1
+		out = append(out, tr.Observe(model.Tick{Symbol: symbol, Ts: t0.Add(time.Duration(i) * time.Second), Price: 100 + d, Size: 10 + d})...)
+	}
+	return out
+}
+
+func TestDetectsJumpSurgeAndGap(t *testing.T) {
+	d, err := NewDetector(testConfig())
+	require.NoError(t, err)
+	tr := NewTracker(d)
+	require.Empty(t, warm(tr, "AAPL", 40))
+
+	evs := tr.Observe(model.Tick{Symbol: "AAPL", Ts: t0.Add(100 * time.Second), Price: 110, Size: 500})
+	kinds := map[Kind]Severity{}
+	for _, e := range evs {
+		kinds[e.Kind] = e.Severity
+		assert.Equal(t, "equity", e.Class)
+	}
+	assert.Equal(t, map[Kind]Severity{PriceJump: Critical, VolumeSurge: Critical, ArrivalGap: Critical}, kinds)
+}
+
+func TestSmallVolumeIsNotASurge(t *testing.T) {
+	d, err := NewDetector(testConfig())
+	require.NoError(t, err)
+	tr := NewTracker(d)
+	warm(tr, "AAPL", 40)
+	assert.Empty(t, tr.Observe(model.Tick{Symbol: "AAPL", Ts: t0.Add(40 * time.Second), Price: 100.1, Size: 0.01}), "only surges count")
+}
+
+func TestClass(t *testing.T) {
+	d, err := NewDetector(testConfig())
+	require.NoError(t, err)
+	assert.Equal(t, "crypto", d.Class("BINANCE:BTCUSDT"))
+	assert.Equal(t, "equity", d.Class("MSFT"))
+
+	m, err := refdata.New([]refdata.Instrument{{ID: "BTC-USD", AssetClass: "crypto"}, {ID: "EUR-USD", AssetClass: "fx"}})
+	require.NoError(t, err)
+	d.SetInstruments(m)
+	assert.Equal(t, "crypto", d.Class("BTC-USD"))
+	assert.Equal(t, "fx", d.Class("EUR-USD"))
+	assert.Equal(t, "equity", d.Class("BRK-B"))
+
+	cfg := testConfig()
+	cfg.Classes = nil
+	d, err = NewDetector(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "other", d.Class("MSFT"))
+}
+
+type memSink struct {
+	got []Event
+	err error
+}
+
+func (m *memSink) Write(_ context.Context, e []Event) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.got = append(m.got, e...)
+	return nil
+}
+func (m *memSink) Close() error { return nil }
+
+func TestStageSkipsReplayedTicks(t *testing.T) {
+	d, err := NewDetector(testConfig())
+	require.NoError(t, err)
+	mgr := state.NewManager(nil, zap.NewNop())
+	store, err := state.Register[Baseline](mgr, "anomaly/test")
+	require.NoError(t, err)
+	sink := &memSink{}
+	s := NewStage(d, store, sink, zap.NewNop())
+
+	ctx := context.Background()
+	for i := range 40 {
+		dp := float64(i%2)*0.2 - 0.1
+		msg := events.TickMsg{Tick: model.Tick{Symbol: "AAPL", Ts: t0.Add(time.Duration(i) * time.Second), Price: 100 + dp}, Kafka: events.KafkaMeta{Offset: int64(i)}}
+		require.NoError(t, s.Process(ctx, msg))
+	}
+	jump := model.Tick{Symbol: "AAPL", Ts: t0.Add(40 * time.Second), Price: 120}
+	require.NoError(t, s.Process(ctx, events.TickMsg{Tick: jump, Kafka: events.KafkaMeta{Offset: 40}}))
+	require.NoError(t, s.Process(ctx, events.TickMsg{Tick: jump, Kafka: events.KafkaMeta{Offset: 40}}), "replayed")
+	require.NoError(t, s.Flush(ctx))
+	require.Len(t, sink.got, 1)
+	assert.Equal(t, PriceJump, sink.got[0].Kind)
+	b, _ := store.Get("AAPL")
+	assert.Equal(t, 120.0, b.LastPrice)
+}
+
+func TestStageReportsSilence(t *testing.T) {
+	d, err := NewDetector(testConfig())
+	require.NoError(t, err)
+	now := t0
+	d.now = func() time.Time { return now }
+	store, err := state.Register[Baseline](state.NewManager(nil, zap.NewNop()), "anomaly/test")
+	require.NoError(t, err)
+	sink := &memSink{}
+	s := NewStage(d, store, sink, zap.NewNop())
+	s.started = t0
+
+	ctx := context.Background()
+	tickAt := func(i int) events.TickMsg {
+		now = t0.Add(time.Duration(i) * time.Second)
+		return events.TickMsg{Tick: model.Tick{Symbol: "AAPL", Ts: now, Price: 100}, Kafka: events.KafkaMeta{Offset: int64(i)}}
+	}
+	for i := range 40 {
+		require.NoError(t, s.Process(ctx, tickAt(i)))
+	}
+	require.NoError(t, s.Flush(ctx))
+	assert.Empty(t, sink.got)
+
+	now = t0.Add(100 * time.Second)
+	require.NoError(t, s.Flush(ctx))
+	require.Len(t, sink.got, 1, "reported before the next tick")
+	assert.Equal(t, ArrivalGap, sink.got[0].Kind)
+	assert.Equal(t, now, sink.got[0].Ts)
+	require.NoError(t, s.Flush(ctx))
+	require.NoError(t, s.Process(ctx, tickAt(120)))
+	require.NoError(t, s.Flush(ctx))
+	assert.Len(t, sink.got, 1, "once per gap")
+}
+
+func TestStageKeepsUnpublishedEvents(t *testing.T) {
+	d, err := NewDetector(testConfig())
+	require.NoError(t, err)
+	store, err := state.Register[Baseline](state.NewManager(nil, zap.NewNop()), "anomaly/test")
+	require.NoError(t, err)
+	sink := &memSink{err: errors.New("broker down")}
+	s := NewStage(d, store, sink, zap.NewNop())
+	s.pending = []Event{{Symbol: "AAPL", Kind: PriceJump}}
+
+	ctx := context.Background()
+	require.Error(t, s.Flush(ctx))
+	sink.err = nil
+	require.NoError(t, s.Flush(ctx))
+	require.Len(t, sink.got, 1)
+	assert.Equal(t, PriceJump, sink.got[0].Kind)
+}
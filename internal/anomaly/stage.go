@@ -0,0 +1,156 @@
+package anomaly
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/broker"
+	"github.com/jonandereg/streamforge/internal/events"
+	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
+	"github.com/jonandereg/streamforge/internal/state"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// Sink receives batches of anomaly events.
+type Sink interface {
+	Write(ctx context.Context, events []Event) error
+	Close() error
+}
+
+// KafkaSink publishes events as JSON keyed by symbol, with the kind and
+// severity in headers.
+type KafkaSink struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaSink creates a sink writing to topic.
+func NewKafkaSink(brokers []string, topic string, sec *broker.Security) *KafkaSink {
+	return &KafkaSink{writer: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Transport:    sec.Transport(),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: 10 * time.Millisecond,
+	}}
+}
+
+// Write implements Sink.
+func (s *KafkaSink) Write(ctx context.Context, evs []Event) error {
+	msgs := make([]kafka.Message, 0, len(evs))
+	for _, e := range evs {
+		val, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, kafka.Message{
+			Key:   []byte(e.Symbol),
+			Value: val,
+			Headers: []kafka.Header{
+				{Key: "content-type", Value: []byte("application/json")},
+				{Key: "anomaly_kind", Value: []byte(e.Kind)},
+				{Key: "anomaly_severity", Value: []byte(e.Severity.String())},
+			},
+			Time: e.Ts,
+		})
+	}
+	return s.writer.WriteMessages(ctx, msgs...)
+}
+
+// Close flushes and closes the underlying writer.
+func (s *KafkaSink) Close() error { return s.writer.Close() }
+
+// Stage runs a Detector as a worker.Processor. Baselines live in a
+// state.Store, so they survive restarts when state is checkpointed and
+// replayed ticks are not judged twice. Events are logged and counted as
+// they happen and written to Sink, if any, on Flush. Flush also judges the
+// silence of every symbol against the wall clock, so a feed that stops is
+// reported without waiting for its next tick. Ticks always pass on.
+type Stage struct {
+	d         *Detector
+	baselines *state.Store[Baseline]
+	sink      Sink
+	log       *zap.Logger
+	started   time.Time // silence before it does not count
+
+	mu      sync.Mutex
+	pending []Event
+	flushMu sync.Mutex // keeps batches in order
+}
+
+// NewStage creates a stage keeping baselines in store; sink may be nil.
+func NewStage(d *Detector, store *state.Store[Baseline], sink Sink, log *zap.Logger) *Stage {
+	return &Stage{d: d, baselines: store, sink: sink, log: log, started: time.Now()}
+}
+
+// Process implements worker.Processor.
+func (s *Stage) Process(_ context.Context, msg events.TickMsg) error {
+	var evs []Event
+	s.baselines.Update(msg.Tick.Symbol, msg.Kafka, func(b *Baseline, _ bool) {
+		evs = s.d.Observe(b, msg.Tick)
+	})
+	s.report(evs)
+	return nil
+}
+
+// report logs and counts evs and queues them for the sink.
+func (s *Stage) report(evs []Event) {
+	for _, e := range evs {
+		sfmetrics.AnomaliesTotal.WithLabelValues(e.Class, string(e.Kind), e.Severity.String()).Inc()
+		s.log.Info("anomaly", zap.String("symbol", e.Symbol), zap.Stringer("event", e))
+	}
+	if len(evs) > 0 && s.sink != nil {
+		s.mu.Lock()
+		s.pending = append(s.pending, evs...)
+		s.mu.Unlock()
+	}
+}
+
+// silences judges the open arrival gap of every symbol.
+func (s *Stage) silences(now time.Time) {
+	var evs []Event
+	for _, sym := range s.baselines.Keys() {
+		s.baselines.Modify(sym, func(b *Baseline) {
+			if e, ok := s.d.Silence(b, sym, s.started, now); ok {
+				evs = append(evs, e)
+			}
+		})
+	}
+	s.report(evs)
+}
+
+// Flush implements worker.Flusher. A batch that fails to publish is kept
+// and published again, ahead of newer events, on the next Flush.
+func (s *Stage) Flush(ctx context.Context) error {
+	s.silences(s.d.now())
+	if s.sink == nil {
+		return nil
+	}
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+	s.mu.Lock()
+	batch := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+	if err := s.sink.Write(ctx, batch); err != nil {
+		s.mu.Lock()
+		s.pending = append(batch, s.pending...)
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Close implements worker.Closer.
+func (s *Stage) Close(context.Context) error {
+	if s.sink == nil {
+		return nil
+	}
+	return s.sink.Close()
+}
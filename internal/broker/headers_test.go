@@ -0,0 +1,31 @@
+package broker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestHeaderCarrierRoundTrip(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+	prop := propagation.TraceContext{}
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "publish")
+	defer span.End()
+
+	headers := []kafka.Header{{Key: "content-type", Value: []byte("application/json")}}
+	prop.Inject(ctx, HeaderCarrier{Headers: &headers})
+	require.Len(t, headers, 2)
+
+	got := trace.SpanContextFromContext(prop.Extract(context.Background(), HeaderCarrier{Headers: &headers}))
+	assert.Equal(t, span.SpanContext().TraceID(), got.TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), got.SpanID())
+	assert.True(t, got.IsRemote())
+}
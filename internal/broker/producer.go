@@ -10,11 +10,20 @@ import (
 	"github.com/jonandereg/streamforge/internal/model"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const tracerName = "github.com/jonandereg/streamforge/internal/broker"
+
 // Producer wraps a Kafka writer for publishing market data ticks.
 type Producer struct {
-	writer *kafka.Writer
+	writer  *kafka.Writer
+	topic   string
+	brokers []string
+	dialer  *kafka.Dialer
 }
 
 var (
@@ -48,12 +57,15 @@ type Config struct {
 	Compression   kafka.CompressionCodec
 	RetryAttempts int
 	RetryBackoff  time.Duration
+	// Security enables TLS/SASL; nil connects in plaintext.
+	Security *Security
 }
 
 // NewProducer creates a new Kafka producer and pings the broker.
 func NewProducer(ctx context.Context, cfg Config) (*Producer, error) {
+	dialer := cfg.Security.Dialer()
 	writer := kafka.NewWriter(kafka.WriterConfig{
-
+		Dialer:           dialer,
 		Brokers:          cfg.Brokers,
 		Topic:            cfg.Topic,
 		Balancer:         &kafka.Hash{},
@@ -66,7 +78,7 @@ func NewProducer(ctx context.Context, cfg Config) (*Producer, error) {
 		CompressionCodec: cfg.Compression,
 	})
 
-	conn, err := kafka.DialContext(ctx, "tcp", cfg.Brokers[0])
+	conn, err := dialer.DialContext(ctx, "tcp", cfg.Brokers[0])
 	if err != nil {
 		BrokerConnectTotal.WithLabelValues("failure").Inc()
 		_ = writer.Close() // Ignore close error, prioritize connection error
@@ -75,7 +87,21 @@ func NewProducer(ctx context.Context, cfg Config) (*Producer, error) {
 	_ = conn.Close()
 	BrokerConnectTotal.WithLabelValues("success").Inc()
 
-	return &Producer{writer: writer}, nil
+	return &Producer{writer: writer, topic: cfg.Topic, brokers: cfg.Brokers, dialer: dialer}, nil
+}
+
+// Ping dials the brokers until one accepts a connection. It is registered as
+// a readiness check.
+func (p *Producer) Ping(ctx context.Context) error {
+	var err error
+	for _, b := range p.brokers {
+		var conn *kafka.Conn
+		conn, err = p.dialer.DialContext(ctx, "tcp", b)
+		if err == nil {
+			return conn.Close()
+		}
+	}
+	return err
 }
 
 // Close flushes and closes the producer.
@@ -89,13 +115,27 @@ func (p *Producer) Close() error {
 	return nil
 }
 
-// Publish sends one normalized Tick to Kafka with key=symbol and JSON value.
-func (p *Producer) Publish(ctx context.Context, t model.Tick) error {
+// Publish sends one normalized Tick to Kafka with key=symbol and JSON value,
+// adding headers to the standard ones. The trace context of the publish span
+// is injected into the message headers.
+func (p *Producer) Publish(ctx context.Context, t model.Tick, headers ...kafka.Header) error {
 	start := time.Now()
 
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "kafka.publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", p.topic),
+			attribute.String("symbol", t.Symbol),
+		),
+	)
+	defer span.End()
+
 	val, err := json.Marshal(t)
 	if err != nil {
 		sfmetrics.IngestorPublishErrorsTotal.WithLabelValues("marshal").Inc()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "marshal")
 		return err
 	}
 
@@ -109,12 +149,16 @@ func (p *Producer) Publish(ctx context.Context, t model.Tick) error {
 		},
 		Time: t.Ts,
 	}
+	msg.Headers = append(msg.Headers, headers...)
+	otel.GetTextMapPropagator().Inject(ctx, HeaderCarrier{Headers: &msg.Headers})
 
 	err = p.writer.WriteMessages(ctx, msg)
 	sfmetrics.IngestorPublishLatencySeconds.Observe(float64(time.Since(start).Seconds()))
 
 	if err != nil {
 		sfmetrics.IngestorPublishErrorsTotal.WithLabelValues("error").Inc()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "write")
 		return err
 	}
 
@@ -0,0 +1,136 @@
+package broker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/secrets"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// SASL mechanisms accepted in config.KafkaSASL.Mechanism.
+const (
+	SASLPlain       = "PLAIN"
+	SASLScramSHA256 = "SCRAM-SHA-256"
+	SASLScramSHA512 = "SCRAM-SHA-512"
+)
+
+// dialTimeout matches kafka-go's default dialer timeout.
+const dialTimeout = 10 * time.Second
+
+// Security carries the TLS and SASL settings applied to every Kafka
+// connection: writers, readers, consumer groups and admin clients. A nil
+// *Security means plaintext without authentication.
+type Security struct {
+	TLS  *tls.Config
+	SASL sasl.Mechanism
+}
+
+// NewSecurity builds Security from the kafka config section. The SASL
+// password may be a secret reference and is resolved with r. It returns nil
+// when neither TLS nor SASL is enabled.
+func NewSecurity(k config.Kafka, r *secrets.Resolver) (*Security, error) {
+	var s Security
+	if k.TLS.Enabled {
+		tc, err := tlsConfig(k.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("kafka tls: %w", err)
+		}
+		s.TLS = tc
+	}
+	if k.SASL.Mechanism != "" {
+		password, err := r.Resolve(k.SASL.Password)
+		if err != nil {
+			return nil, fmt.Errorf("kafka sasl: %w", err)
+		}
+		m, err := saslMechanism(k.SASL.Mechanism, k.SASL.Username, password)
+		if err != nil {
+			return nil, fmt.Errorf("kafka sasl: %w", err)
+		}
+		s.SASL = m
+	}
+	if s.TLS == nil && s.SASL == nil {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+// SecurityFor is NewSecurity with the secrets resolver configured in cfg.
+func SecurityFor(cfg config.AppConfig) (*Security, error) {
+	r, err := cfg.SecretsResolver()
+	if err != nil {
+		return nil, err
+	}
+	return NewSecurity(cfg.Kafka, r)
+}
+
+// Dialer returns a kafka-go dialer using s.
+func (s *Security) Dialer() *kafka.Dialer {
+	d := &kafka.Dialer{Timeout: dialTimeout, DualStack: true}
+	if s != nil {
+		d.TLS = s.TLS
+		d.SASLMechanism = s.SASL
+	}
+	return d
+}
+
+// Transport returns a kafka-go transport (for kafka.Writer and kafka.Client) using s.
+func (s *Security) Transport() *kafka.Transport {
+	t := &kafka.Transport{DialTimeout: dialTimeout}
+	if s != nil {
+		t.TLS = s.TLS
+		t.SASL = s.SASL
+	}
+	return t
+}
+
+func tlsConfig(c config.KafkaTLS) (*tls.Config, error) {
+	tc := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify, //nolint:gosec // explicit opt-in for test clusters
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", c.CAFile)
+		}
+		tc.RootCAs = pool
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+	return tc, nil
+}
+
+func saslMechanism(name, user string, password secrets.Value) (sasl.Mechanism, error) {
+	if user == "" || password.IsZero() {
+		return nil, errors.New("username and password are required")
+	}
+	switch name {
+	case SASLPlain:
+		return plain.Mechanism{Username: user, Password: password.Reveal()}, nil
+	case SASLScramSHA256:
+		return scram.Mechanism(scram.SHA256, user, password.Reveal())
+	case SASLScramSHA512:
+		return scram.Mechanism(scram.SHA512, user, password.Reveal())
+	default:
+		return nil, fmt.Errorf("unsupported mechanism %q", name)
+	}
+}
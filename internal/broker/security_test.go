@@ -0,0 +1,134 @@
+package broker
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSecurityDisabled(t *testing.T) {
+	sec, err := NewSecurity(config.Kafka{}, secrets.NewResolver(secrets.Options{}))
+	require.NoError(t, err)
+	assert.Nil(t, sec)
+	assert.Nil(t, sec.Dialer().TLS, "nil Security dials plaintext")
+}
+
+func TestNewSecuritySASL(t *testing.T) {
+	t.Setenv("SF_TEST_KAFKA_PASSWORD", "pw-from-env")
+	r := secrets.NewResolver(secrets.Options{})
+	for _, mech := range []string{SASLPlain, SASLScramSHA256, SASLScramSHA512} {
+		sec, err := NewSecurity(config.Kafka{SASL: config.KafkaSASL{
+			Mechanism: mech, Username: "sf", Password: "env:SF_TEST_KAFKA_PASSWORD",
+		}}, r)
+		require.NoError(t, err, mech)
+		assert.Equal(t, mech, sec.SASL.Name())
+		assert.Equal(t, mech, sec.Transport().SASL.Name())
+	}
+	_, err := NewSecurity(config.Kafka{SASL: config.KafkaSASL{Mechanism: "GSSAPI", Username: "sf", Password: "x"}}, r)
+	assert.Error(t, err)
+}
+
+// TestTLSHandshake runs a mutual-TLS listener standing in for a broker and
+// checks that the client config built from files verifies it and presents
+// the client certificate.
+func TestTLSHandshake(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := newCert(t, nil, nil, "test-ca", true)
+	srv, srvKey := newCert(t, ca, caKey, "kafka.test", false)
+	cli, cliKey := newCert(t, ca, caKey, "streamforge", false)
+	caFile := writePEM(t, dir, "ca.pem", ca.Raw, nil)
+	certFile := writePEM(t, dir, "client.pem", cli.Raw, nil)
+	keyFile := writePEM(t, dir, "client-key.pem", nil, cliKey)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{srv.Raw}, PrivateKey: srvKey}},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	})
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_ = c.(*tls.Conn).Handshake()
+			_ = c.Close()
+		}
+	}()
+
+	handshake := func(k config.KafkaTLS) error {
+		k.Enabled = true
+		sec, err := NewSecurity(config.Kafka{TLS: k}, secrets.NewResolver(secrets.Options{}))
+		if err != nil {
+			return err
+		}
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 2 * time.Second}, "tcp", ln.Addr().String(), sec.Dialer().TLS)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		return conn.Handshake()
+	}
+
+	require.NoError(t, handshake(config.KafkaTLS{CAFile: caFile, CertFile: certFile, KeyFile: keyFile, ServerName: "kafka.test"}))
+	assert.Error(t, handshake(config.KafkaTLS{CAFile: caFile, CertFile: certFile, KeyFile: keyFile, ServerName: "other.test"}), "wrong server name")
+	assert.Error(t, handshake(config.KafkaTLS{CertFile: certFile, KeyFile: keyFile, ServerName: "kafka.test"}), "unknown CA")
+}
+
+func newCert(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, cn string, isCA bool) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		DNSNames:              []string{cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func writePEM(t *testing.T, dir, name string, certDER []byte, key *ecdsa.PrivateKey) string {
+	t.Helper()
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: certDER}
+	if key != nil {
+		der, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+	}
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(block), 0o600))
+	return path
+}
@@ -0,0 +1,357 @@
+// Package calendar knows when markets are open: per exchange, the weekly
+// trading sessions, holidays and early closes, loaded from a YAML file (see
+// configs/calendars.example.yaml). Symbols are assigned to calendars by
+// glob, or by the asset class or venue the symbol master lists for them;
+// symbols without one, such as crypto pairs, trade around the clock.
+package calendar
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/refdata"
+	"gopkg.in/yaml.v3"
+)
+
+// Session is one interval [Open, Close) during which a market trades.
+type Session struct {
+	Open  time.Time
+	Close time.Time
+}
+
+// Contains reports whether t falls within the session.
+func (s Session) Contains(t time.Time) bool { return !t.Before(s.Open) && t.Before(s.Close) }
+
+type span struct{ open, close time.Duration } // offsets from local midnight
+
+// Calendar is the trading schedule of one market.
+type Calendar struct {
+	Name     string
+	always   bool
+	loc      *time.Location
+	weekly   map[time.Weekday][]span
+	holidays map[string]bool
+	early    map[string]time.Duration // date -> close offset
+}
+
+// AlwaysOpen is the calendar of markets trading around the clock.
+var AlwaysOpen = &Calendar{Name: "24x7", always: true}
+
+// IsAlwaysOpen reports whether the market never closes.
+func (c *Calendar) IsAlwaysOpen() bool { return c.always }
+
+// Location returns the market's time zone; UTC for always-open markets.
+func (c *Calendar) Location() *time.Location {
+	if c.loc == nil {
+		return time.UTC
+	}
+	return c.loc
+}
+
+// IsOpen reports whether the market is open at t.
+func (c *Calendar) IsOpen(t time.Time) bool {
+	_, ok := c.SessionAt(t)
+	return ok
+}
+
+// forever is the session of an always-open market.
+var forever = Session{Close: time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)}
+
+// SessionAt returns the session containing t. For an always-open market it
+// is unbounded: Open is the zero time.
+func (c *Calendar) SessionAt(t time.Time) (Session, bool) {
+	if c.always {
+		return forever, true
+	}
+	for _, s := range c.sessionsOn(t.In(c.loc)) {
+		if s.Contains(t) {
+			return s, true
+		}
+	}
+	return Session{}, false
+}
+
+// Sessions returns the sessions overlapping [from, to), in order and
+// clipped to the range. An always-open market has one session, the range.
+func (c *Calendar) Sessions(from, to time.Time) []Session {
+	if !to.After(from) {
+		return nil
+	}
+	if c.always {
+		return []Session{{Open: from, Close: to}}
+	}
+	var out []Session
+	local := from.In(c.loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, c.loc)
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		for _, s := range c.sessionsOn(day) {
+			if !s.Close.After(from) || !s.Open.Before(to) {
+				continue
+			}
+			if s.Open.Before(from) {
+				s.Open = from
+			}
+			if s.Close.After(to) {
+				s.Close = to
+			}
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// sessionsOn returns the sessions of the local day of t.
+func (c *Calendar) sessionsOn(t time.Time) []Session {
+	date := t.Format(time.DateOnly)
+	if c.holidays[date] {
+		return nil
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.loc)
+	at := func(d time.Duration) time.Time {
+		// Wall-clock offsets, so sessions keep their local times across
+		// daylight saving changes.
+		h, m := int(d/time.Hour), int(d%time.Hour/time.Minute)
+		return time.Date(midnight.Year(), midnight.Month(), midnight.Day(), h, m, 0, 0, c.loc)
+	}
+	early, isEarly := c.early[date]
+	var out []Session
+	for _, sp := range c.weekly[t.Weekday()] {
+		end := sp.close
+		if isEarly {
+			if early <= sp.open {
+				continue
+			}
+			end = min(end, early)
+		}
+		out = append(out, Session{Open: at(sp.open), Close: at(end)})
+	}
+	return out
+}
+
+// File is the calendars file format.
+type File struct {
+	Calendars []Spec `yaml:"calendars"`
+	// Symbols assigns calendars to symbols; the first matching entry wins.
+	Symbols []Assignment `yaml:"symbols"`
+}
+
+// Spec describes one calendar.
+type Spec struct {
+	Name       string        `yaml:"name"`
+	Timezone   string        `yaml:"timezone"`
+	AlwaysOpen bool          `yaml:"always_open"`
+	Sessions   []SessionSpec `yaml:"sessions"`
+	// Holidays lists closed dates as YYYY-MM-DD.
+	Holidays []string `yaml:"holidays"`
+	// EarlyCloses maps half days (YYYY-MM-DD) to their local close (HH:MM).
+	EarlyCloses map[string]string `yaml:"early_closes"`
+}
+
+// SessionSpec is a weekly session in the calendar's time zone.
+type SessionSpec struct {
+	Days  []string `yaml:"days"` // mon..sun
+	Open  string   `yaml:"open"` // HH:MM
+	Close string   `yaml:"close"`
+}
+
+// Assignment maps symbols to a calendar: by path.Match glob, or by the
+// asset class or venue of their instrument in the symbol master.
+type Assignment struct {
+	Calendar     string   `yaml:"calendar"`
+	Symbols      []string `yaml:"symbols"`
+	AssetClasses []string `yaml:"asset_classes"`
+	Venues       []string `yaml:"venues"`
+}
+
+func (a Assignment) matches(symbol string, in refdata.Instrument, known bool) bool {
+	for _, g := range a.Symbols {
+		if ok, _ := path.Match(g, symbol); ok {
+			return true
+		}
+	}
+	if !known {
+		return false
+	}
+	return containsFold(a.AssetClasses, in.AssetClass) || containsFold(a.Venues, in.Venue)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, x := range list {
+		if s != "" && strings.EqualFold(x, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Registry finds the calendar of a symbol.
+type Registry struct {
+	calendars   map[string]*Calendar
+	assign      []Assignment
+	instruments *refdata.Master
+}
+
+// SetInstruments sets the symbol master that asset class and venue
+// assignments look symbols up in. Call it before the registry is shared.
+func (r *Registry) SetInstruments(m *refdata.Master) { r.instruments = m }
+
+// Default returns a registry where every market is always open.
+func Default() *Registry {
+	return &Registry{calendars: map[string]*Calendar{AlwaysOpen.Name: AlwaysOpen}}
+}
+
+// Load reads a calendars file; an empty path returns Default.
+func Load(file string) (*Registry, error) {
+	if file == "" {
+		return Default(), nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("calendar: %w", err)
+	}
+	r, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("calendar %s: %w", file, err)
+	}
+	return r, nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Parse parses the calendars file format.
+func Parse(data []byte) (*Registry, error) {
+	var f File
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil {
+		return nil, err
+	}
+	r := Default()
+	var errs []error
+	for _, spec := range f.Calendars {
+		c, err := build(spec)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("calendar %q: %w", spec.Name, err))
+			continue
+		}
+		if _, dup := r.calendars[c.Name]; dup {
+			errs = append(errs, fmt.Errorf("calendar %q defined twice", c.Name))
+		}
+		r.calendars[c.Name] = c
+	}
+	for _, a := range f.Symbols {
+		if _, ok := r.calendars[a.Calendar]; !ok {
+			errs = append(errs, fmt.Errorf("symbols: unknown calendar %q", a.Calendar))
+		}
+		if len(a.Symbols)+len(a.AssetClasses)+len(a.Venues) == 0 {
+			errs = append(errs, fmt.Errorf("symbols: calendar %q: symbols, asset_classes or venues is required", a.Calendar))
+		}
+		for _, g := range a.Symbols {
+			if _, err := path.Match(g, ""); err != nil {
+				errs = append(errs, fmt.Errorf("symbols: bad glob %q", g))
+			}
+		}
+	}
+	r.assign = f.Symbols
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func build(spec Spec) (*Calendar, error) {
+	if spec.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if spec.AlwaysOpen {
+		return &Calendar{Name: spec.Name, always: true}, nil
+	}
+	loc, err := time.LoadLocation(spec.Timezone)
+	if err != nil || spec.Timezone == "" {
+		return nil, fmt.Errorf("timezone %q: want an IANA zone such as America/New_York", spec.Timezone)
+	}
+	c := &Calendar{
+		Name:     spec.Name,
+		loc:      loc,
+		weekly:   make(map[time.Weekday][]span),
+		holidays: make(map[string]bool),
+		early:    make(map[string]time.Duration),
+	}
+	if len(spec.Sessions) == 0 {
+		return nil, errors.New("sessions or always_open is required")
+	}
+	for _, s := range spec.Sessions {
+		open, err := clock(s.Open)
+		if err != nil {
+			return nil, err
+		}
+		closing, err := clock(s.Close)
+		if err != nil {
+			return nil, err
+		}
+		if closing <= open {
+			return nil, fmt.Errorf("session %s-%s closes before it opens", s.Open, s.Close)
+		}
+		for _, d := range s.Days {
+			wd, ok := weekdays[strings.ToLower(d)]
+			if !ok {
+				return nil, fmt.Errorf("unknown day %q", d)
+			}
+			c.weekly[wd] = append(c.weekly[wd], span{open, closing})
+		}
+	}
+	for _, spans := range c.weekly {
+		sort.Slice(spans, func(i, j int) bool { return spans[i].open < spans[j].open })
+	}
+	for _, d := range spec.Holidays {
+		if _, err := time.Parse(time.DateOnly, d); err != nil {
+			return nil, fmt.Errorf("holiday %q: want YYYY-MM-DD", d)
+		}
+		c.holidays[d] = true
+	}
+	for d, hm := range spec.EarlyCloses {
+		if _, err := time.Parse(time.DateOnly, d); err != nil {
+			return nil, fmt.Errorf("early close %q: want YYYY-MM-DD", d)
+		}
+		at, err := clock(hm)
+		if err != nil {
+			return nil, err
+		}
+		c.early[d] = at
+	}
+	return c, nil
+}
+
+func clock(hm string) (time.Duration, error) {
+	t, err := time.Parse("15:04", hm)
+	if err != nil {
+		return 0, fmt.Errorf("time %q: want HH:MM", hm)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// For returns the calendar of symbol; symbols no entry matches are always open.
+func (r *Registry) For(symbol string) *Calendar {
+	in, known := r.instruments.Lookup(symbol)
+	for _, a := range r.assign {
+		if a.matches(symbol, in, known) {
+			return r.calendars[a.Calendar]
+		}
+	}
+	return AlwaysOpen
+}
+
+// Get returns the calendar called name.
+func (r *Registry) Get(name string) (*Calendar, bool) {
+	c, ok := r.calendars[name]
+	return c, ok
+}
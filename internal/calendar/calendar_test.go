@@ -0,0 +1,95 @@
+package calendar
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/refdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func load(t *testing.T) *Registry {
+	t.Helper()
+	data, err := os.ReadFile("../../configs/calendars.example.yaml")
+	require.NoError(t, err)
+	r, err := Parse(data)
+	require.NoError(t, err)
+	return r
+}
+
+func ny(t *testing.T, s string) time.Time {
+	t.Helper()
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	ts, err := time.ParseInLocation("2006-01-02 15:04", s, loc)
+	require.NoError(t, err)
+	return ts
+}
+
+func TestSessions(t *testing.T) {
+	xnys := load(t).For("MSFT")
+	require.Equal(t, "XNYS", xnys.Name)
+
+	assert.True(t, xnys.IsOpen(ny(t, "2025-01-02 09:30")))
+	assert.False(t, xnys.IsOpen(ny(t, "2025-01-02 16:00")))
+	assert.False(t, xnys.IsOpen(ny(t, "2025-01-04 12:00")), "Saturday")
+	assert.False(t, xnys.IsOpen(ny(t, "2025-12-25 12:00")), "holiday")
+	assert.False(t, xnys.IsOpen(ny(t, "2025-11-28 13:30")), "half day")
+	assert.True(t, xnys.IsOpen(ny(t, "2025-11-28 12:59")))
+
+	// The week of Thanksgiving: Wednesday, (closed Thursday), half-day Friday.
+	got := xnys.Sessions(ny(t, "2025-11-26 12:00"), ny(t, "2025-11-29 00:00"))
+	assert.Equal(t, []Session{
+		{Open: ny(t, "2025-11-26 12:00"), Close: ny(t, "2025-11-26 16:00")},
+		{Open: ny(t, "2025-11-28 09:30"), Close: ny(t, "2025-11-28 13:00")},
+	}, got)
+
+	// Local times hold across the daylight saving change.
+	s, ok := xnys.SessionAt(ny(t, "2025-03-10 10:00"))
+	require.True(t, ok)
+	assert.Equal(t, 13, s.Open.UTC().Hour())
+}
+
+func TestAlwaysOpen(t *testing.T) {
+	r := load(t)
+	btc := r.For("BINANCE:BTCUSDT")
+	assert.Equal(t, "CRYPTO", btc.Name)
+	assert.True(t, btc.IsAlwaysOpen())
+	s, ok := btc.SessionAt(ny(t, "2025-12-25 03:00"))
+	require.True(t, ok)
+	assert.True(t, s.Open.IsZero())
+	assert.Same(t, AlwaysOpen, Default().For("MSFT"))
+}
+
+func TestAssignByInstrument(t *testing.T) {
+	m, err := refdata.New([]refdata.Instrument{
+		{ID: "BTC-USD", AssetClass: "crypto", Venue: "BINANCE"},
+		{ID: "EUR-USD", AssetClass: "fx", Venue: "OANDA"},
+		{ID: "BRK-B", AssetClass: "equity", Venue: "XNYS"},
+	})
+	require.NoError(t, err)
+	r := load(t)
+	r.SetInstruments(m)
+	assert.Equal(t, "CRYPTO", r.For("BTC-USD").Name)
+	assert.Same(t, AlwaysOpen, r.For("EUR-USD"))
+	assert.Equal(t, "XNYS", r.For("BRK-B").Name, "not a pair despite the dash")
+	assert.Equal(t, "CRYPTO", r.For("KRAKEN:XBTUSD").Name, "globs still apply")
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse([]byte(`
+calendars:
+  - {name: X, timezone: Nowhere/City, sessions: [{days: [mon], open: "09:00", close: "17:00"}]}
+  - {name: Y, timezone: UTC, sessions: [{days: [funday], open: "09:00", close: "08:00"}]}
+symbols:
+  - {calendar: Z, symbols: ["*"]}
+  - {calendar: X}
+`))
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "Nowhere/City")
+	assert.ErrorContains(t, err, "closes before it opens")
+	assert.ErrorContains(t, err, `unknown calendar "Z"`)
+	assert.ErrorContains(t, err, "symbols, asset_classes or venues is required")
+}
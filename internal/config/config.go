@@ -0,0 +1,726 @@
+// Package config loads the StreamForge configuration. Values are layered:
+// built-in defaults, then an optional YAML file, then environment variables,
+// then command-line overrides. Each layer only replaces what it sets.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/obs"
+	"github.com/jonandereg/streamforge/internal/secrets"
+	"github.com/jonandereg/streamforge/internal/version"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Service names accepted by Options.Service and Validate.
+const (
+	ServiceIngestor       = "ingestor"
+	ServiceTicksProcessor = "ticks-processor"
+)
+
+// AppConfig is the effective configuration shared by all StreamForge binaries.
+type AppConfig struct {
+	Obs          Obs          `yaml:"obs"`
+	DataProvider DataProvider `yaml:"provider"`
+	Kafka        Kafka        `yaml:"kafka"`
+	Producer     Producer     `yaml:"producer"`
+	Router       Router       `yaml:"router"`
+	Processor    Processor    `yaml:"processor"`
+	Secrets      Secrets      `yaml:"secrets"`
+	Admin        Admin        `yaml:"admin"`
+	Database     Database     `yaml:"database"`
+	GRPC         GRPC         `yaml:"grpc"`
+	Quality      Quality      `yaml:"quality"`
+	Dedup        Dedup        `yaml:"dedup"`
+	Pipeline     Pipeline     `yaml:"pipeline"`
+	State        State        `yaml:"state"`
+	Redis        Redis        `yaml:"redis"`
+	Indicators   Indicators   `yaml:"indicators"`
+	Alerts       Alerts       `yaml:"alerts"`
+	Anomaly      Anomaly      `yaml:"anomaly"`
+	Calendar     Calendar     `yaml:"calendar"`
+	Instruments  Instruments  `yaml:"instruments"`
+
+	Ingestor       Service `yaml:"ingestor"`
+	TicksProcessor Service `yaml:"ticks_processor"`
+}
+
+// Obs holds logging, tracing and HTTP endpoint settings common to all services.
+type Obs struct {
+	Env          string  `yaml:"env"`       // dev|staging|prod
+	LogLevel     string  `yaml:"log_level"` // debug|info|warn|error
+	LogJSON      bool    `yaml:"log_json"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint"`
+	TraceSampler string  `yaml:"trace_sampler"` // always|never|ratio; "" picks by Env
+	TraceRatio   float64 `yaml:"trace_ratio"`
+	EnablePprof  bool    `yaml:"enable_pprof"`
+	MetricsPath  string  `yaml:"metrics_path"`
+	HealthPath   string  `yaml:"health_path"`
+	ReadyPath    string  `yaml:"ready_path"`
+	// HealthInterval is how often readiness checks run; HealthTimeout bounds each one.
+	HealthInterval time.Duration `yaml:"health_interval"`
+	HealthTimeout  time.Duration `yaml:"health_timeout"`
+}
+
+// Service holds per-binary settings. Empty fields fall back to the shared section.
+type Service struct {
+	Name     string `yaml:"name"`
+	HTTPAddr string `yaml:"http_addr"`
+	LogLevel string `yaml:"log_level"`
+}
+
+// Secrets configures how secret references (env:, file:, enc:) in other
+// sections are resolved; see package secrets.
+type Secrets struct {
+	Dir           string `yaml:"dir"`            // base for relative file: references
+	EncryptedFile string `yaml:"encrypted_file"` // sealed file for enc: references
+	Key           string `yaml:"key" secret:"true"`
+	// Refresh is how often references are re-resolved to pick up rotations.
+	Refresh time.Duration `yaml:"refresh"`
+}
+
+// DataProvider holds market data provider settings.
+type DataProvider struct {
+	// Token is the API key, literal or a secret reference such as
+	// "file:/run/secrets/finnhub_token". Rotated without restart.
+	Token         string        `yaml:"token" secret:"true"`
+	BaseURL       string        `yaml:"base_url"`
+	WsURL         string        `yaml:"ws_url"`
+	Symbols       []string      `yaml:"symbols"`
+	ReconnectBase time.Duration `yaml:"reconnect_base"`
+	ReconnectMax  time.Duration `yaml:"reconnect_max"`
+	// StaleAfter is how long a subscribed symbol may go without ticks while
+	// its market is open before the feed is reported stale; 0 disables it.
+	StaleAfter time.Duration `yaml:"stale_after"`
+}
+
+// Kafka holds broker and consumer settings.
+type Kafka struct {
+	Brokers    []string  `yaml:"brokers"`
+	GroupID    string    `yaml:"group_id"`
+	TicksTopic string    `yaml:"ticks_topic"`
+	TLS        KafkaTLS  `yaml:"tls"`
+	SASL       KafkaSASL `yaml:"sasl"`
+
+	MinBytes int           `yaml:"min_bytes"`
+	MaxBytes int           `yaml:"max_bytes"`
+	MaxWait  time.Duration `yaml:"max_wait"`
+
+	// ConsumerMode selects the consumer implementation: "single" (one reader)
+	// or "partitioned" (one fetch loop per partition).
+	ConsumerMode   string        `yaml:"consumer_mode"`
+	BatchSize      int           `yaml:"batch_size"`
+	CommitInterval time.Duration `yaml:"commit_interval"`
+	// DrainTimeout bounds how long a partition revoked by a rebalance waits
+	// for its in-flight messages before committing and letting go.
+	DrainTimeout time.Duration `yaml:"drain_timeout"`
+
+	// StartPosition applies to partitions without a committed offset:
+	// "earliest", "latest", "timestamp" (uses StartTime) or "offsets"
+	// (uses StartOffsets).
+	StartPosition string        `yaml:"start_position"`
+	StartTime     time.Time     `yaml:"start_time,omitempty"`
+	StartOffsets  map[int]int64 `yaml:"start_offsets,omitempty"`
+}
+
+// KafkaTLS configures TLS for broker connections.
+type KafkaTLS struct {
+	Enabled    bool   `yaml:"enabled"`
+	CAFile     string `yaml:"ca_file"`   // PEM bundle; empty uses the system roots
+	CertFile   string `yaml:"cert_file"` // client certificate for mutual TLS
+	KeyFile    string `yaml:"key_file"`
+	ServerName string `yaml:"server_name"` // overrides the host name verified
+	// InsecureSkipVerify disables certificate verification. Test clusters only.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+}
+
+// KafkaSASL configures SASL authentication for broker connections.
+type KafkaSASL struct {
+	Mechanism string `yaml:"mechanism"` // "", PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512
+	Username  string `yaml:"username"`
+	// Password is a literal or a secret reference (env:, file:, enc:).
+	Password string `yaml:"password" secret:"true"`
+}
+
+// Producer holds settings for the ingestor's Kafka writer.
+type Producer struct {
+	ClientID      string        `yaml:"client_id"`
+	Acks          int           `yaml:"acks"` // -1 all, 0 none, 1 leader
+	BatchTimeout  time.Duration `yaml:"batch_timeout"`
+	BatchBytes    int           `yaml:"batch_bytes"`
+	Compression   string        `yaml:"compression"` // none|gzip|snappy|lz4|zstd
+	RetryAttempts int           `yaml:"retry_attempts"`
+	RetryBackoff  time.Duration `yaml:"retry_backoff"`
+}
+
+// Router holds settings for the symbol router in front of the workers.
+type Router struct {
+	QueueCapacity int `yaml:"queue_capacity"`
+	// DropPolicy is "drop" (discard when a worker queue is full) or "block"
+	// (wait, applying backpressure). Reloadable.
+	DropPolicy string `yaml:"drop_policy"`
+}
+
+// Processor holds ticks-processor worker settings.
+type Processor struct {
+	NumWorkers int `yaml:"num_workers"`
+	// SlowThreshold logs a warning for messages whose processing takes
+	// longer; 0 disables it. Reloadable.
+	SlowThreshold time.Duration `yaml:"slow_threshold"`
+	// StuckAfter fails the liveness check when a worker spends longer on
+	// one message; 0 disables it. Reloadable.
+	StuckAfter time.Duration `yaml:"stuck_after"`
+	// FlushInterval flushes a buffering processor while the workers run,
+	// even when no messages arrive; 0 flushes only on shutdown. Reloadable.
+	FlushInterval time.Duration `yaml:"flush_interval"`
+	// DeadLetterTopic receives messages whose processing panicked; empty logs them instead.
+	DeadLetterTopic string `yaml:"dead_letter_topic"`
+	// ShutdownTimeout bounds the whole drain sequence on SIGTERM.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+// Admin configures the authenticated admin API. With neither field set every
+// admin request is rejected.
+type Admin struct {
+	// Token authenticates as identity "admin"; usually a secret reference.
+	Token string `yaml:"token" secret:"true"`
+	// TokensFile lists one "identity token" pair per line, one per operator.
+	TokensFile string `yaml:"tokens_file"`
+}
+
+// Database configures the TimescaleDB connection pool.
+type Database struct {
+	// URL is a postgres:// connection string, usually a secret reference.
+	// Empty disables history features.
+	URL      string `yaml:"url" secret:"true"`
+	MaxConns int32  `yaml:"max_conns"`
+}
+
+// GRPC configures the ticks-processor gRPC API.
+type GRPC struct {
+	// Addr is the listen address; empty disables the server.
+	Addr string `yaml:"addr"`
+	// StreamBuffer is how many live ticks a stream may fall behind before
+	// the client is disconnected.
+	StreamBuffer int `yaml:"stream_buffer"`
+	// RequestTimeout applies to unary calls without a client deadline.
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	// MaxBars caps the bars returned by one GetBars call.
+	MaxBars int `yaml:"max_bars"`
+	// MaxStreams caps concurrent streams per client connection.
+	MaxStreams uint32 `yaml:"max_streams"`
+}
+
+// Quality configures the data-quality stage. Each rule has an action:
+// "off", "flag" (pass the tick on, marked with the rule), "drop" or
+// "quarantine" (publish it to QuarantineTopic instead).
+type Quality struct {
+	// Ingestor and Processor select where the stage runs: before publishing
+	// and/or at the head of the ticks-processor pipeline.
+	Ingestor  bool `yaml:"ingestor"`
+	Processor bool `yaml:"processor"`
+	// QuarantineTopic receives ticks whose verdict is quarantine.
+	QuarantineTopic string `yaml:"quarantine_topic"`
+	// Symbols lists the known symbols for the unknown_symbol rule; empty
+	// uses the provider subscriptions. Reloadable.
+	Symbols []string `yaml:"symbols"`
+	// SpikeWindow is how many recent prices per symbol a spike is measured
+	// against; SpikeThreshold is the relative deviation from their mean
+	// (0.2 = 20%) that counts as a spike. Reloadable.
+	SpikeWindow    int     `yaml:"spike_window"`
+	SpikeThreshold float64 `yaml:"spike_threshold"`
+	// MaxFuture is how far ahead of the local clock a timestamp may be;
+	// MaxLateness how far behind the newest tick of its symbol. Reloadable.
+	MaxFuture   time.Duration `yaml:"max_future"`
+	MaxLateness time.Duration `yaml:"max_lateness"`
+	// Actions holds the action of every rule. Reloadable.
+	Actions QualityActions `yaml:"actions"`
+	// AnomalySeverity is the lowest anomaly severity ("warning" or
+	// "critical") the anomaly rule acts on. Reloadable.
+	AnomalySeverity string `yaml:"anomaly_severity"`
+}
+
+// QualityActions sets the action taken by each data-quality rule.
+type QualityActions struct {
+	Invalid       string `yaml:"invalid"` // model.Tick.Validate fails
+	ZeroPrice     string `yaml:"zero_price"`
+	Future        string `yaml:"future"`
+	OutOfOrder    string `yaml:"out_of_order"`
+	Spike         string `yaml:"spike"`
+	UnknownSymbol string `yaml:"unknown_symbol"`
+	Anomaly       string `yaml:"anomaly"` // see Anomaly
+}
+
+// Quarantines reports whether any rule's action is "quarantine".
+func (a QualityActions) Quarantines() bool {
+	return slices.Contains([]string{a.Invalid, a.ZeroPrice, a.Future, a.OutOfOrder, a.Spike, a.UnknownSymbol, a.Anomaly}, "quarantine")
+}
+
+// State configures checkpointing of ticks-processor stage state.
+type State struct {
+	// Backend is "" (state lives in memory only), "disk" or "kafka".
+	Backend string `yaml:"backend"`
+	// Dir holds the snapshot file of the disk backend.
+	Dir string `yaml:"dir"`
+	// ChangelogTopic is the compacted topic of the kafka backend.
+	ChangelogTopic string `yaml:"changelog_topic"`
+	// CheckpointInterval is how often changed state is saved. Offsets are
+	// only committed once the state covering them is saved, so it also
+	// bounds how far commits trail processing.
+	CheckpointInterval time.Duration `yaml:"checkpoint_interval"`
+}
+
+// Redis configures the cache connection.
+type Redis struct {
+	// Addr is host:port; empty disables caching.
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password" secret:"true"`
+	DB       int    `yaml:"db"`
+}
+
+// Indicators configures the technical indicators stage. Periods count ticks.
+type Indicators struct {
+	// Processor adds the stage to the built-in ticks-processor chain.
+	Processor  bool        `yaml:"processor"`
+	SMA        []int       `yaml:"sma"`
+	EMA        []int       `yaml:"ema"`
+	VWAP       []int       `yaml:"vwap"`
+	Volatility []int       `yaml:"volatility"` // stddev of log returns
+	RSI        []int       `yaml:"rsi"`
+	Bollinger  []Bollinger `yaml:"bollinger"`
+	// Topic receives every update; empty disables publishing.
+	Topic string `yaml:"topic"`
+	// Table writes updates to the indicators hypertable when database.url is set.
+	Table bool `yaml:"table"`
+	// RedisPrefix and RedisTTL shape the cache of latest values,
+	// one hash per symbol, when redis.addr is set.
+	RedisPrefix string        `yaml:"redis_prefix"`
+	RedisTTL    time.Duration `yaml:"redis_ttl"`
+	// BatchSize buffers updates between flushes; a full buffer is written
+	// by the worker that filled it.
+	BatchSize int `yaml:"batch_size"`
+}
+
+// Bollinger is one Bollinger band: the Period mean plus and minus K standard deviations.
+type Bollinger struct {
+	Period int     `yaml:"period"`
+	K      float64 `yaml:"k"`
+}
+
+// Alerts configures the price alerting stage.
+type Alerts struct {
+	// Processor adds the stage to the built-in ticks-processor chain.
+	Processor bool `yaml:"processor"`
+	// Rules are evaluated on every tick of their symbols. Reloadable.
+	Rules []AlertRule `yaml:"rules"`
+	// Cooldown is the minimum time between two alerts of one rule and
+	// symbol, unless the rule sets its own. Reloadable.
+	Cooldown time.Duration `yaml:"cooldown"`
+	// Topic receives every alert; empty disables publishing.
+	Topic string `yaml:"topic"`
+	// Table persists alerts to the alerts hypertable; it requires
+	// database.url.
+	Table bool `yaml:"table"`
+	// Log writes alerts to the service log.
+	Log     bool    `yaml:"log"`
+	Webhook Webhook `yaml:"webhook"`
+	// QueueSize bounds the alerts waiting for delivery; beyond it alerts
+	// are dropped.
+	QueueSize int `yaml:"queue_size"`
+}
+
+// AlertRule is one alert rule. Kind selects the condition:
+//   - "cross": the price crosses Level in Direction (up, down or any);
+//   - "move": the price moves more than Percent within Window;
+//   - "stale": no tick for After, only within Hours when set.
+type AlertRule struct {
+	Name string `yaml:"name"`
+	Kind string `yaml:"kind"`
+	// Symbols the rule applies to; empty means every symbol, except for
+	// stale rules, which need them listed.
+	Symbols   []string      `yaml:"symbols"`
+	Level     float64       `yaml:"level"`
+	Direction string        `yaml:"direction"`
+	Percent   float64       `yaml:"percent"`
+	Window    time.Duration `yaml:"window"`
+	After     time.Duration `yaml:"after"`
+	// Hours limits stale rules to "HH:MM-HH:MM" on weekdays in Timezone.
+	Hours    string `yaml:"hours"`
+	Timezone string `yaml:"timezone"`
+	// Cooldown overrides alerts.cooldown for this rule.
+	Cooldown time.Duration `yaml:"cooldown"`
+}
+
+// Webhook configures HTTP delivery of alerts.
+type Webhook struct {
+	// URL receives a JSON POST per alert; empty disables the webhook.
+	URL string `yaml:"url"`
+	// Secret signs the body with HMAC-SHA256 in the X-Streamforge-Signature header.
+	Secret  string        `yaml:"secret" secret:"true"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Calendar configures market hours.
+type Calendar struct {
+	// File holds exchange sessions, holidays and early closes, and which
+	// symbols trade on them (see configs/calendars.example.yaml). Empty
+	// treats every market as always open.
+	File string `yaml:"file"`
+}
+
+// Instruments configures the symbol master: reference data and
+// per-provider aliases of every known instrument.
+type Instruments struct {
+	// Source is "" (no symbol master; symbols are used as the provider
+	// sends them), "file" or "table" (the instruments table at database.url).
+	Source string `yaml:"source"`
+	// File lists the instruments when Source is "file" (see
+	// configs/instruments.example.yaml).
+	File string `yaml:"file"`
+}
+
+// Anomaly configures statistical anomaly detection. Each symbol keeps
+// exponentially weighted baselines of its log returns, trade sizes and the
+// time between ticks; a tick deviating from a baseline by Warning (or
+// Critical) standard deviations is an anomaly.
+type Anomaly struct {
+	// Processor adds the anomaly stage to the built-in ticks-processor chain.
+	Processor bool `yaml:"processor"`
+	// Kinds selects the detectors: price_jump, volume_surge, arrival_gap.
+	Kinds []string `yaml:"kinds"`
+	// Alpha is the weight of the newest value in the baselines.
+	Alpha float64 `yaml:"alpha"`
+	// MinSamples is how many values a baseline needs before it is used.
+	MinSamples int     `yaml:"min_samples"`
+	Warning    float64 `yaml:"warning"`
+	Critical   float64 `yaml:"critical"`
+	// Topic receives anomaly events; empty disables publishing.
+	Topic string `yaml:"topic"`
+	// Classes group symbols for metrics; the first matching class wins,
+	// and unmatched symbols are "other".
+	Classes []SymbolClass `yaml:"classes"`
+}
+
+// SymbolClass names a group of symbols matched by path.Match globs or by
+// the asset class of their instrument in the symbol master.
+type SymbolClass struct {
+	Name         string   `yaml:"name"`
+	Symbols      []string `yaml:"symbols"`
+	AssetClasses []string `yaml:"asset_classes"`
+}
+
+// Dedup configures duplicate tick suppression.
+type Dedup struct {
+	// Ingestor and Processor select where the stage runs: before publishing
+	// and/or in the ticks-processor pipeline.
+	Ingestor  bool `yaml:"ingestor"`
+	Processor bool `yaml:"processor"`
+	// Fields make up the tick fingerprint: any of symbol, ts, price, size,
+	// exchange and src_id. Leave out src_id to catch trades reported by
+	// several providers.
+	Fields []string `yaml:"fields"`
+	// Window is how far behind the newest tick of a symbol duplicates are
+	// still caught; MaxPerSymbol bounds the fingerprints kept per symbol.
+	Window       time.Duration `yaml:"window"`
+	MaxPerSymbol int           `yaml:"max_per_symbol"`
+}
+
+// Pipeline declares the ticks-processor processing chain. With no stages
+// the built-in chain is used: dedup and quality when enabled for the
+// processor, then log and hub.
+type Pipeline struct {
+	Stages []Stage `yaml:"stages"`
+}
+
+// Stage is one step of the pipeline. Type "chain" runs Stages in order and
+// "fanout" runs them concurrently; other types name a registered processor.
+type Stage struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+	// Timeout bounds one call of the stage; 0 means no limit.
+	Timeout time.Duration `yaml:"timeout"`
+	// OnError is what happens to a message the stage failed on: "skip"
+	// (acknowledge it and end the chain), "retry" (retry up to Retries times,
+	// then dead-letter), "dlq" (dead-letter it) or "halt" (retry until it
+	// succeeds, holding back the worker). Empty means skip.
+	OnError      string        `yaml:"on_error"`
+	Retries      int           `yaml:"retries"`
+	RetryBackoff time.Duration `yaml:"retry_backoff"`
+	// Stages are the children of a chain or fanout stage.
+	Stages []Stage `yaml:"stages,omitempty"`
+}
+
+// Defaults returns the built-in configuration, suitable for the local compose stack.
+func Defaults() AppConfig {
+	return AppConfig{
+		Obs: Obs{
+			Env:          "dev",
+			LogLevel:     "info",
+			OTLPEndpoint: "localhost:4318",
+			EnablePprof:  true,
+			MetricsPath:  "/metrics",
+			HealthPath:   "/healthz",
+			ReadyPath:    "/readyz",
+
+			HealthInterval: 5 * time.Second,
+			HealthTimeout:  2 * time.Second,
+		},
+		DataProvider: DataProvider{
+			BaseURL:       "https://finnhub.io/api/v1",
+			WsURL:         "wss://ws.finnhub.io",
+			Symbols:       []string{"AAPL", "MSFT", "BINANCE:BTCUSDT"},
+			ReconnectBase: 200 * time.Millisecond,
+			ReconnectMax:  5 * time.Second,
+			StaleAfter:    2 * time.Minute,
+		},
+		Kafka: Kafka{
+			Brokers:        []string{"localhost:29092"},
+			GroupID:        "streamforge-ticks-processor",
+			TicksTopic:     "ticks",
+			MinBytes:       1,
+			MaxBytes:       10 << 20,
+			MaxWait:        250 * time.Millisecond,
+			ConsumerMode:   "single",
+			BatchSize:      500,
+			CommitInterval: time.Second,
+			DrainTimeout:   10 * time.Second,
+			StartPosition:  "earliest",
+		},
+		Producer: Producer{
+			ClientID:      "streamforge-ingestor",
+			Acks:          -1,
+			BatchTimeout:  5 * time.Millisecond,
+			BatchBytes:    1_048_576,
+			Compression:   "lz4",
+			RetryAttempts: 5,
+			RetryBackoff:  100 * time.Millisecond,
+		},
+		Router: Router{
+			QueueCapacity: 1024,
+			DropPolicy:    "drop",
+		},
+		Processor: Processor{
+			NumWorkers:      4,
+			SlowThreshold:   250 * time.Millisecond,
+			StuckAfter:      30 * time.Second,
+			FlushInterval:   5 * time.Second,
+			ShutdownTimeout: 15 * time.Second,
+		},
+		Secrets: Secrets{
+			Dir:     "/run/secrets",
+			Refresh: 30 * time.Second,
+		},
+		Database: Database{
+			MaxConns: 4,
+		},
+		GRPC: GRPC{
+			StreamBuffer:   4096,
+			RequestTimeout: 10 * time.Second,
+			MaxBars:        5000,
+			MaxStreams:     100,
+		},
+		Quality: Quality{
+			Ingestor:       true,
+			SpikeWindow:    20,
+			SpikeThreshold: 0.2,
+			MaxFuture:      5 * time.Second,
+			MaxLateness:    time.Second,
+			Actions: QualityActions{
+				Invalid:       "drop",
+				ZeroPrice:     "drop",
+				Future:        "drop",
+				OutOfOrder:    "flag",
+				Spike:         "flag",
+				UnknownSymbol: "drop",
+				Anomaly:       "off",
+			},
+			AnomalySeverity: "critical",
+		},
+		Anomaly: Anomaly{
+			Kinds:      []string{"price_jump", "volume_surge", "arrival_gap"},
+			Alpha:      0.05,
+			MinSamples: 30,
+			Warning:    4,
+			Critical:   6,
+			Topic:      "anomalies",
+			Classes: []SymbolClass{
+				{Name: "crypto", AssetClasses: []string{"crypto"}, Symbols: []string{"BINANCE:*", "COINBASE:*", "KRAKEN:*"}},
+				{Name: "fx", AssetClasses: []string{"fx"}, Symbols: []string{"OANDA:*"}},
+				{Name: "equity", AssetClasses: []string{"equity", "etf"}, Symbols: []string{"*"}},
+			},
+		},
+		Dedup: Dedup{
+			Ingestor:     true,
+			Fields:       []string{"symbol", "ts", "price", "size", "exchange"},
+			Window:       time.Minute,
+			MaxPerSymbol: 10_000,
+		},
+		State: State{
+			Dir:                "data/state",
+			ChangelogTopic:     "ticks-processor-state",
+			CheckpointInterval: 10 * time.Second,
+		},
+		Indicators: Indicators{
+			SMA:         []int{20},
+			EMA:         []int{12, 26},
+			VWAP:        []int{100},
+			Volatility:  []int{20},
+			RSI:         []int{14},
+			Bollinger:   []Bollinger{{Period: 20, K: 2}},
+			Topic:       "indicators",
+			Table:       true,
+			RedisPrefix: "indicators:",
+			RedisTTL:    24 * time.Hour,
+			BatchSize:   500,
+		},
+		Alerts: Alerts{
+			Cooldown:  5 * time.Minute,
+			Topic:     "alerts",
+			Table:     true,
+			Log:       true,
+			Webhook:   Webhook{Timeout: 5 * time.Second},
+			QueueSize: 1000,
+		},
+		Ingestor: Service{
+			Name:     "streamforge-ingestor",
+			HTTPAddr: ":2112",
+		},
+		TicksProcessor: Service{
+			Name:     "streamforge-ticks-processor",
+			HTTPAddr: ":2113",
+		},
+	}
+}
+
+// Options selects the sources Load reads from.
+type Options struct {
+	// File is a YAML or TOML config file, by extension; empty falls back to
+	// $STREAMFORGE_CONFIG.
+	File string
+	// Overrides are "path=value" assignments applied last, e.g. "kafka.brokers=a:9092,b:9092".
+	Overrides []string
+	// Service restricts validation to the settings one binary needs; empty validates everything.
+	Service string
+	// SkipValidation returns the merged config even if it is invalid.
+	SkipValidation bool
+}
+
+// Load builds the effective configuration from defaults, file, environment and
+// overrides. All invalid or missing values are reported together.
+func Load(opts Options) (AppConfig, error) {
+	cfg := Defaults()
+	loadDotEnv()
+
+	file := opts.File
+	if file == "" {
+		file = os.Getenv("STREAMFORGE_CONFIG")
+	}
+	if file != "" {
+		if err := loadFile(&cfg, file); err != nil {
+			return cfg, err
+		}
+	}
+
+	var errs []error
+	errs = append(errs, applyEnv(&cfg)...)
+	for _, o := range opts.Overrides {
+		path, value, ok := strings.Cut(o, "=")
+		if !ok {
+			errs = append(errs, fmt.Errorf("override %q: expected path=value", o))
+			continue
+		}
+		if err := Set(&cfg, strings.TrimSpace(path), value); err != nil {
+			errs = append(errs, fmt.Errorf("override %q: %w", o, err))
+		}
+	}
+	if len(errs) > 0 {
+		return cfg, errors.Join(errs...)
+	}
+	if opts.SkipValidation {
+		return cfg, nil
+	}
+	return cfg, cfg.Validate(opts.Service)
+}
+
+func loadFile(cfg *AppConfig, path string) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".yaml" && ext != ".yml" && ext != ".toml" {
+		return fmt.Errorf("config file %s: unsupported format %q (use .yaml or .toml)", path, ext)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	if ext == ".toml" {
+		// TOML is re-encoded as YAML so both formats share the yaml keys,
+		// value parsing and unknown-key checks.
+		var doc map[string]any
+		if err := toml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+		if data, err = yaml.Marshal(doc); err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// ServiceConfig returns the per-binary section for name.
+func (c AppConfig) ServiceConfig(name string) Service {
+	if name == ServiceIngestor {
+		return c.Ingestor
+	}
+	return c.TicksProcessor
+}
+
+// ObsConfig returns the observability settings for the named service.
+func (c AppConfig) ObsConfig(name string) obs.Config {
+	svc := c.ServiceConfig(name)
+	level := c.Obs.LogLevel
+	if svc.LogLevel != "" {
+		level = svc.LogLevel
+	}
+	return obs.Config{
+		ServiceName:    svc.Name,
+		ServiceVersion: version.Version,
+		Env:            c.Obs.Env,
+		LogLevel:       level,
+		LogJSON:        c.Obs.LogJSON,
+		OTLPEndpoint:   c.Obs.OTLPEndpoint,
+		TraceSampler:   c.Obs.TraceSampler,
+		TraceRatio:     c.Obs.TraceRatio,
+		EnablePprof:    c.Obs.EnablePprof,
+		MetricsPath:    c.Obs.MetricsPath,
+		HealthPath:     c.Obs.HealthPath,
+		ReadyPath:      c.Obs.ReadyPath,
+		HealthInterval: c.Obs.HealthInterval,
+		HealthTimeout:  c.Obs.HealthTimeout,
+	}
+}
+
+// SecretsResolver returns a resolver for the secret references in c.
+func (c AppConfig) SecretsResolver() (*secrets.Resolver, error) {
+	opts := secrets.Options{Dir: c.Secrets.Dir, EncryptedFile: c.Secrets.EncryptedFile}
+	if c.Secrets.EncryptedFile != "" {
+		key, err := secrets.ParseKey(c.Secrets.Key)
+		if err != nil {
+			return nil, fmt.Errorf("secrets.key: %w", err)
+		}
+		opts.Key = key
+	}
+	return secrets.NewResolver(opts), nil
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadLayering(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "sf.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+kafka:
+  batch_size: 100
+  commit_interval: 2s
+processor:
+  num_workers: 8
+`), 0o600))
+	t.Setenv("KAFKA_COMMIT_INTERVAL_MS", "250")
+	t.Setenv("KAFKA_BROKERS", "a:9092, b:9092")
+
+	cfg, err := Load(Options{
+		File:      file,
+		Overrides: []string{"processor.num_workers=2"},
+		Service:   ServiceTicksProcessor,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 100, cfg.Kafka.BatchSize, "file")
+	assert.Equal(t, 250*time.Millisecond, cfg.Kafka.CommitInterval, "env over file")
+	assert.Equal(t, []string{"a:9092", "b:9092"}, cfg.Kafka.Brokers)
+	assert.Equal(t, 2, cfg.Processor.NumWorkers, "override over file")
+	assert.Equal(t, "ticks", cfg.Kafka.TicksTopic, "default")
+}
+
+func TestLoadTOML(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "sf.toml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+[kafka]
+brokers = ["a:9092", "b:9092"]
+commit_interval = "2s"
+
+[processor]
+num_workers = 8
+
+[[pipeline.stages]]
+name = "log"
+type = "log"
+`), 0o600))
+
+	cfg, err := Load(Options{File: file, Service: ServiceTicksProcessor})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a:9092", "b:9092"}, cfg.Kafka.Brokers)
+	assert.Equal(t, 2*time.Second, cfg.Kafka.CommitInterval)
+	assert.Equal(t, 8, cfg.Processor.NumWorkers)
+	assert.Equal(t, []Stage{{Name: "log", Type: "log"}}, cfg.Pipeline.Stages)
+
+	require.NoError(t, os.WriteFile(file, []byte("[kafka]\nnope = 1\n"), 0o600))
+	_, err = Load(Options{File: file, Service: ServiceTicksProcessor})
+	assert.ErrorContains(t, err, "nope", "unknown keys are rejected")
+}
+
+func TestLoadAggregatesErrors(t *testing.T) {
+	_, err := Load(Options{
+		Overrides: []string{"kafka.batch_size=x", "kafka.nope=1", "bad"},
+	})
+	require.Error(t, err)
+	for _, want := range []string{"kafka.batch_size", "kafka.nope", `"bad"`} {
+		assert.Contains(t, err.Error(), want)
+	}
+
+	cfg := Defaults()
+	cfg.Processor.NumWorkers = 0
+	cfg.Kafka.ConsumerMode = "fancy"
+	err = cfg.Validate(ServiceTicksProcessor)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "processor.num_workers")
+	assert.Contains(t, err.Error(), "kafka.consumer_mode")
+	assert.NotContains(t, err.Error(), "provider.token", "ingestor-only setting")
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Defaults()
+	cfg.DataProvider.Token = "s3cret"
+	red := cfg.Redacted()
+	assert.Equal(t, redactedValue, red.DataProvider.Token)
+	assert.Equal(t, "s3cret", cfg.DataProvider.Token, "original untouched")
+}
+
+func TestAlertsTableNeedsDatabase(t *testing.T) {
+	cfg := Defaults()
+	cfg.Alerts.Processor = true
+	err := cfg.Validate(ServiceTicksProcessor)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "alerts.table requires database.url")
+
+	cfg.Alerts.Table = false
+	assert.NoError(t, cfg.Validate(ServiceTicksProcessor))
+	cfg.Alerts.Table, cfg.Database.URL = true, "postgres://localhost/streamforge"
+	assert.NoError(t, cfg.Validate(ServiceTicksProcessor))
+
+	cfg = Defaults()
+	cfg.Pipeline.Stages = []Stage{{Name: "out", Type: "fanout", Stages: []Stage{
+		{Name: "log", Type: "log"},
+		{Name: "alerts", Type: "alerts"},
+	}}}
+	err = cfg.Validate(ServiceTicksProcessor)
+	require.Error(t, err, "nested stage")
+	assert.Contains(t, err.Error(), "alerts.table requires database.url")
+}
+
+func TestNestedStagesAreValidated(t *testing.T) {
+	cfg := Defaults()
+	cfg.Anomaly.Alpha = 2
+	assert.NoError(t, cfg.Validate(ServiceTicksProcessor), "no anomaly stage")
+
+	cfg.Pipeline.Stages = []Stage{{Name: "main", Type: "chain", Stages: []Stage{
+		{Name: "log", Type: "log"},
+		{Name: "side", Type: "fanout", Stages: []Stage{{Name: "anomaly", Type: "anomaly"}}},
+	}}}
+	err := cfg.Validate(ServiceTicksProcessor)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "anomaly.alpha must be within (0,1)")
+}
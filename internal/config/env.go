@@ -5,95 +5,170 @@ import (
 	"os"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/joho/godotenv"
 )
 
-type AppConfig struct {
-	DataProvider DataProvider
-	Kafka        Kafka
-	Processor    Processor
+// envBinding maps an environment variable onto a config path understood by Set.
+type envBinding struct {
+	env  string
+	path string
 }
 
-type Processor struct {
-	NumWorkers    int
-	QueueCapacity int
-}
+// envBindings lists every environment variable Load reads. Durations given
+// in *_MS variables are milliseconds; the others use Go duration syntax.
+var envBindings = []envBinding{
+	{"STREAMFORGE_ENV", "obs.env"},
+	{"LOG_LEVEL", "obs.log_level"},
+	{"LOG_JSON", "obs.log_json"},
+	{"OTEL_EXPORTER_OTLP_ENDPOINT", "obs.otlp_endpoint"},
+	{"TRACE_SAMPLER", "obs.trace_sampler"},
+	{"TRACE_RATIO", "obs.trace_ratio"},
+	{"HEALTH_INTERVAL_MS", "obs.health_interval"},
+	{"HEALTH_TIMEOUT_MS", "obs.health_timeout"},
 
-// DataProvider holds configuration values loaded from environment variables.
-type DataProvider struct {
-	Token   string
-	BaseURL string
-	WsURL   string
-}
+	{"STREAMFORGE_SECRETS_DIR", "secrets.dir"},
+	{"STREAMFORGE_SECRETS_FILE", "secrets.encrypted_file"},
+	{"STREAMFORGE_SECRETS_KEY", "secrets.key"},
 
-type Kafka struct {
-	Brokers    []string
-	GroupID    string
-	TicksTopic string
+	{"STREAMFORGE_ADMIN_TOKEN", "admin.token"},
+	{"STREAMFORGE_ADMIN_TOKENS_FILE", "admin.tokens_file"},
 
-	MinBytes int
-	MaxBytes int
-	MaxWait  time.Duration
-}
+	{"DATABASE_URL", "database.url"},
+	{"DATABASE_MAX_CONNS", "database.max_conns"},
 
-// LoadConfig loads configuration values from environment variables.
-func LoadConfig() (AppConfig, error) {
-	if err := godotenv.Load(".env"); err != nil {
-		fmt.Println("warning: no .env file found")
-	}
-	dp := DataProvider{
-		Token:   mustEnv("FINNHUB_TOKEN"),
-		BaseURL: mustEnv("FINNHUB_BASE_URL"),
-		WsURL:   mustEnv("FINNHUB_WS_URL"),
-	}
+	{"FINNHUB_TOKEN", "provider.token"},
+	{"FINNHUB_BASE_URL", "provider.base_url"},
+	{"FINNHUB_WS_URL", "provider.ws_url"},
+	{"FINNHUB_SYMBOLS", "provider.symbols"},
+	{"FINNHUB_STALE_AFTER_MS", "provider.stale_after"},
+	{"CALENDAR_FILE", "calendar.file"},
+	{"INSTRUMENTS_SOURCE", "instruments.source"},
+	{"INSTRUMENTS_FILE", "instruments.file"},
 
-	k := Kafka{
-		Brokers:    splitAndTrim(mustEnv("KAFKA_BROKERS")),
-		GroupID:    mustEnv("KAFKA_GROUP_ID"),
-		TicksTopic: mustEnv("KAFKA_TICKS_TOPIC"),
-		MinBytes:   mustEnvInt("KAFKA_MIN_BYTES"),
-		MaxBytes:   mustEnvInt("KAFKA_MAX_BYTES"),
-		MaxWait:    time.Duration(mustEnvInt("KAFKA_MAX_WAIT_MS")) * time.Millisecond,
-	}
+	{"KAFKA_BROKERS", "kafka.brokers"},
+	{"KAFKA_GROUP_ID", "kafka.group_id"},
+	{"KAFKA_TICKS_TOPIC", "kafka.ticks_topic"},
+	{"KAFKA_TLS_ENABLED", "kafka.tls.enabled"},
+	{"KAFKA_TLS_CA_FILE", "kafka.tls.ca_file"},
+	{"KAFKA_TLS_CERT_FILE", "kafka.tls.cert_file"},
+	{"KAFKA_TLS_KEY_FILE", "kafka.tls.key_file"},
+	{"KAFKA_TLS_SERVER_NAME", "kafka.tls.server_name"},
+	{"KAFKA_SASL_MECHANISM", "kafka.sasl.mechanism"},
+	{"KAFKA_SASL_USERNAME", "kafka.sasl.username"},
+	{"KAFKA_SASL_PASSWORD", "kafka.sasl.password"},
+	{"KAFKA_MIN_BYTES", "kafka.min_bytes"},
+	{"KAFKA_MAX_BYTES", "kafka.max_bytes"},
+	{"KAFKA_MAX_WAIT_MS", "kafka.max_wait"},
+	{"KAFKA_CONSUMER_MODE", "kafka.consumer_mode"},
+	{"KAFKA_BATCH_SIZE", "kafka.batch_size"},
+	{"KAFKA_COMMIT_INTERVAL_MS", "kafka.commit_interval"},
+	{"KAFKA_DRAIN_TIMEOUT_MS", "kafka.drain_timeout"},
+	{"KAFKA_START_POSITION", "kafka.start_position"},
+	{"KAFKA_START_TIMESTAMP", "kafka.start_time"},
+	{"KAFKA_START_OFFSETS", "kafka.start_offsets"},
 
-	p := Processor{
-		NumWorkers:    mustEnvInt("TICKS_NUM_WORKERS"),
-		QueueCapacity: mustEnvInt("TICKS_QUEUE_CAPACITY"),
-	}
+	{"TICKS_QUEUE_CAPACITY", "router.queue_capacity"},
+	{"TICKS_DROP_POLICY", "router.drop_policy"},
+	{"TICKS_NUM_WORKERS", "processor.num_workers"},
+	{"TICKS_SLOW_THRESHOLD_MS", "processor.slow_threshold"},
+	{"TICKS_STUCK_AFTER_MS", "processor.stuck_after"},
+	{"TICKS_FLUSH_INTERVAL_MS", "processor.flush_interval"},
+	{"TICKS_DLQ_TOPIC", "processor.dead_letter_topic"},
+	{"TICKS_SHUTDOWN_TIMEOUT_MS", "processor.shutdown_timeout"},
+
+	{"QUALITY_INGESTOR", "quality.ingestor"},
+	{"QUALITY_PROCESSOR", "quality.processor"},
+	{"QUALITY_QUARANTINE_TOPIC", "quality.quarantine_topic"},
+	{"QUALITY_SYMBOLS", "quality.symbols"},
 
-	return AppConfig{
-		DataProvider: dp,
-		Kafka:        k,
-		Processor:    p,
-	}, nil
+	{"DEDUP_INGESTOR", "dedup.ingestor"},
+	{"DEDUP_PROCESSOR", "dedup.processor"},
+	{"DEDUP_FIELDS", "dedup.fields"},
+	{"DEDUP_WINDOW_MS", "dedup.window"},
 
+	{"STATE_BACKEND", "state.backend"},
+	{"STATE_DIR", "state.dir"},
+	{"STATE_CHANGELOG_TOPIC", "state.changelog_topic"},
+	{"STATE_CHECKPOINT_INTERVAL_MS", "state.checkpoint_interval"},
+
+	{"REDIS_ADDR", "redis.addr"},
+	{"REDIS_PASSWORD", "redis.password"},
+	{"REDIS_DB", "redis.db"},
+
+	{"INDICATORS_PROCESSOR", "indicators.processor"},
+	{"INDICATORS_TOPIC", "indicators.topic"},
+
+	{"ANOMALY_PROCESSOR", "anomaly.processor"},
+	{"ANOMALY_TOPIC", "anomaly.topic"},
+
+	{"ALERTS_PROCESSOR", "alerts.processor"},
+	{"ALERTS_TOPIC", "alerts.topic"},
+	{"ALERTS_WEBHOOK_URL", "alerts.webhook.url"},
+	{"ALERTS_WEBHOOK_SECRET", "alerts.webhook.secret"},
+
+	{"INGESTOR_HTTP_ADDR", "ingestor.http_addr"},
+	{"TICKS_HTTP_ADDR", "ticks_processor.http_addr"},
+	{"TICKS_GRPC_ADDR", "grpc.addr"},
 }
 
-func mustEnv(key string) string {
-	v := os.Getenv(key)
-	if v == "" {
-		panic(fmt.Errorf("missing %s", key))
+// loadDotEnv adds variables from ./.env to the environment without
+// overriding ones already set.
+func loadDotEnv() {
+	if err := godotenv.Load(".env"); err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "warning: .env: %v\n", err)
 	}
-	return v
 }
 
-func mustEnvInt(key string) int {
-	v := os.Getenv(key)
-	if v == "" {
-		panic(fmt.Errorf("missing %s", key))
+// applyEnv applies every bound variable that is set.
+func applyEnv(cfg *AppConfig) []error {
+	var errs []error
+	for _, b := range envBindings {
+		v, ok := os.LookupEnv(b.env)
+		if !ok || v == "" {
+			continue
+		}
+		if strings.HasSuffix(b.env, "_MS") {
+			v += "ms"
+		}
+		if err := Set(cfg, b.path, v); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.env, err))
+		}
 	}
-	n, err := strconv.Atoi(v)
-	if err != nil {
-		panic(fmt.Errorf("invalid int for %s: %v", key, err))
+	return errs
+}
+
+// ParseOffsets parses a comma-separated list of "partition:offset" pairs.
+func ParseOffsets(s string) (map[int]int64, error) {
+	out := make(map[int]int64)
+	for _, pair := range splitAndTrim(s) {
+		if pair == "" {
+			continue
+		}
+		ps, offStr, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("expected partition:offset, got %q", pair)
+		}
+		p, err := strconv.Atoi(strings.TrimSpace(ps))
+		if err != nil {
+			return nil, fmt.Errorf("bad partition in %q: %w", pair, err)
+		}
+		off, err := strconv.ParseInt(strings.TrimSpace(offStr), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad offset in %q: %w", pair, err)
+		}
+		out[p] = off
 	}
-	return n
+	return out, nil
 }
+
 func splitAndTrim(s string) []string {
 	parts := strings.Split(s, ",")
-	for i := range parts {
-		parts[i] = strings.TrimSpace(parts[i])
+	out := parts[:0]
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
 	}
-	return parts
+	return out
 }
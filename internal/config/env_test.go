@@ -0,0 +1,33 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOffsets(t *testing.T) {
+	offs, err := ParseOffsets("0:1200, 1:980,2:0")
+	require.NoError(t, err)
+	assert.Equal(t, map[int]int64{0: 1200, 1: 980, 2: 0}, offs)
+
+	_, err = ParseOffsets("0=12")
+	assert.Error(t, err)
+	_, err = ParseOffsets("x:12")
+	assert.Error(t, err)
+}
+
+func TestTraceEnv(t *testing.T) {
+	t.Setenv("TRACE_SAMPLER", "ratio")
+	t.Setenv("TRACE_RATIO", "0.25")
+
+	cfg, err := Load(Options{Service: ServiceTicksProcessor})
+	require.NoError(t, err)
+	assert.Equal(t, "ratio", cfg.Obs.TraceSampler)
+	assert.Equal(t, 0.25, cfg.Obs.TraceRatio)
+
+	t.Setenv("TRACE_SAMPLER", "sometimes")
+	_, err = Load(Options{Service: ServiceTicksProcessor})
+	assert.ErrorContains(t, err, "obs.trace_sampler")
+}
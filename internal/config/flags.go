@@ -0,0 +1,32 @@
+package config
+
+import (
+	"flag"
+	"strings"
+)
+
+// Flags are the command-line options every binary accepts for configuration.
+type Flags struct {
+	File      string
+	Overrides overrides
+}
+
+// Register adds -config and -set to fs.
+func (f *Flags) Register(fs *flag.FlagSet) {
+	fs.StringVar(&f.File, "config", "", "YAML or TOML config file (default $STREAMFORGE_CONFIG)")
+	fs.Var(&f.Overrides, "set", "override a config value, e.g. -set kafka.brokers=host:9092 (repeatable)")
+}
+
+// Options returns load options for service built from the parsed flags.
+func (f *Flags) Options(service string) Options {
+	return Options{File: f.File, Overrides: f.Overrides, Service: service}
+}
+
+type overrides []string
+
+func (o *overrides) String() string { return strings.Join(*o, " ") }
+
+func (o *overrides) Set(s string) error {
+	*o = append(*o, s)
+	return nil
+}
@@ -0,0 +1,229 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// Reloadable lists the config keys that take effect without a restart.
+// Changes to any other key are reported and ignored until the next start.
+var Reloadable = []string{
+	"obs.log_level",
+	"obs.trace_sampler",
+	"obs.trace_ratio",
+	"ingestor.log_level",
+	"ticks_processor.log_level",
+	"provider.symbols",
+	"router.drop_policy",
+	"processor.slow_threshold",
+	"processor.stuck_after",
+	"processor.flush_interval",
+	"quality.symbols",
+	"quality.spike_window",
+	"quality.spike_threshold",
+	"quality.max_future",
+	"quality.max_lateness",
+	"quality.actions.invalid",
+	"quality.actions.zero_price",
+	"quality.actions.future",
+	"quality.actions.out_of_order",
+	"quality.actions.spike",
+	"quality.actions.unknown_symbol",
+	"quality.actions.anomaly",
+	"quality.anomaly_severity",
+	"alerts.rules",
+	"alerts.cooldown",
+}
+
+// Reloader re-reads the configuration on SIGHUP or when the config file
+// changes, and hands the reloadable part of it to registered callbacks.
+type Reloader struct {
+	opts Options
+	file string
+	log  *zap.Logger
+
+	mu      sync.Mutex // serializes reloads
+	current AppConfig
+	hooks   []func(AppConfig)
+}
+
+// NewReloader returns a Reloader starting from cfg, which was loaded with opts.
+func NewReloader(cfg AppConfig, opts Options, log *zap.Logger) *Reloader {
+	file := opts.File
+	if file == "" {
+		file = os.Getenv("STREAMFORGE_CONFIG")
+	}
+	return &Reloader{opts: opts, file: file, log: log.Named("config"), current: cfg}
+}
+
+// OnChange registers fn to be called with the new config after every reload
+// that changed a reloadable key. Callbacks must not block.
+func (r *Reloader) OnChange(fn func(AppConfig)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, fn)
+}
+
+// Current returns the effective configuration.
+func (r *Reloader) Current() AppConfig {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Reload loads and validates the configuration and, if it is valid, applies
+// the reloadable keys. An invalid configuration leaves everything unchanged.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded, err := Load(r.opts)
+	if err != nil {
+		sfmetrics.ConfigReloadTotal.WithLabelValues("failure").Inc()
+		r.log.Error("config reload rejected", zap.Error(err))
+		return err
+	}
+
+	next := r.current
+	changed := false
+	for _, d := range diff(r.current, loaded) {
+		if !slices.Contains(Reloadable, d.key) {
+			r.log.Warn("config change requires restart, ignored",
+				zap.String("key", d.key), zap.String("old", d.old), zap.String("new", d.new))
+			continue
+		}
+		if err := copyKey(&next, loaded, d.key); err != nil {
+			sfmetrics.ConfigReloadTotal.WithLabelValues("failure").Inc()
+			return err
+		}
+		r.log.Info("config changed",
+			zap.String("key", d.key), zap.String("old", d.old), zap.String("new", d.new))
+		changed = true
+	}
+	if !changed {
+		sfmetrics.ConfigReloadTotal.WithLabelValues("unchanged").Inc()
+		return nil
+	}
+
+	r.current = next
+	for _, fn := range r.hooks {
+		fn(next)
+	}
+	sfmetrics.ConfigReloadTotal.WithLabelValues("success").Inc()
+	return nil
+}
+
+// Run reloads on SIGHUP and, when a config file is in use, whenever its
+// modification time or size changes (checked every poll). It returns when ctx
+// is done.
+func (r *Reloader) Run(ctx context.Context, poll time.Duration) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	if poll <= 0 {
+		poll = 2 * time.Second
+	}
+	t := time.NewTicker(poll)
+	defer t.Stop()
+	last := r.stat()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			r.log.Info("SIGHUP received, reloading config")
+			_ = r.Reload()
+		case <-t.C:
+			if r.file == "" {
+				continue
+			}
+			if st := r.stat(); st != last {
+				last = st
+				r.log.Info("config file changed, reloading", zap.String("file", r.file))
+				_ = r.Reload()
+			}
+		}
+	}
+}
+
+type fileStamp struct {
+	mod  time.Time
+	size int64
+}
+
+func (r *Reloader) stat() fileStamp {
+	if r.file == "" {
+		return fileStamp{}
+	}
+	fi, err := os.Stat(r.file)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{mod: fi.ModTime(), size: fi.Size()}
+}
+
+type change struct {
+	key, old, new string
+}
+
+// diff lists the keys whose values differ between a and b, with secrets redacted.
+func diff(a, b AppConfig) []change {
+	fa, fb := flatten(a.Redacted()), flatten(b.Redacted())
+	var out []change
+	for k, va := range fa {
+		if vb := fb[k]; va != vb {
+			out = append(out, change{key: k, old: va, new: vb})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].key < out[j].key })
+	return out
+}
+
+// flatten maps every leaf config key to its formatted value.
+func flatten(c AppConfig) map[string]string {
+	out := make(map[string]string)
+	var walk func(prefix string, v reflect.Value)
+	walk = func(prefix string, v reflect.Value) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			key := yamlKey(t.Field(i))
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			f := v.Field(i)
+			if f.Kind() == reflect.Struct && f.Type() != timeType {
+				walk(key, f)
+				continue
+			}
+			out[key] = fmt.Sprint(f.Interface())
+		}
+	}
+	walk("", reflect.ValueOf(c))
+	return out
+}
+
+func copyKey(dst *AppConfig, src AppConfig, key string) error {
+	d, err := fieldByPath(reflect.ValueOf(dst).Elem(), key)
+	if err != nil {
+		return err
+	}
+	s, err := fieldByPath(reflect.ValueOf(&src).Elem(), key)
+	if err != nil {
+		return err
+	}
+	d.Set(s)
+	return nil
+}
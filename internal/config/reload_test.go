@@ -0,0 +1,39 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestReloaderAppliesOnlyReloadableKeys(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "sf.yaml")
+	write := func(body string) {
+		require.NoError(t, os.WriteFile(file, []byte(body), 0o600))
+	}
+	write("router:\n  drop_policy: drop\n")
+	opts := Options{File: file, Service: ServiceTicksProcessor}
+	cfg, err := Load(opts)
+	require.NoError(t, err)
+
+	rl := NewReloader(cfg, opts, zap.NewNop())
+	var got []AppConfig
+	rl.OnChange(func(c AppConfig) { got = append(got, c) })
+
+	write("router:\n  drop_policy: block\nprocessor:\n  slow_threshold: 1s\n  num_workers: 9\n")
+	require.NoError(t, rl.Reload())
+	require.Len(t, got, 1)
+	assert.Equal(t, "block", got[0].Router.DropPolicy)
+	assert.Equal(t, time.Second, got[0].Processor.SlowThreshold)
+	assert.Equal(t, cfg.Processor.NumWorkers, got[0].Processor.NumWorkers, "not reloadable")
+
+	write("router:\n  drop_policy: sideways\n")
+	assert.Error(t, rl.Reload())
+	assert.Equal(t, "block", rl.Current().Router.DropPolicy, "invalid config is not applied")
+	assert.Len(t, got, 1)
+}
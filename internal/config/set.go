@@ -0,0 +1,152 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/secrets"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+	offsetsType  = reflect.TypeOf(map[int]int64(nil))
+)
+
+// Set assigns value to the field addressed by a dotted path of YAML keys,
+// e.g. "kafka.commit_interval" or "provider.symbols". Lists are comma-separated,
+// durations use Go syntax, times RFC3339 and offsets "partition:offset" pairs.
+func Set(cfg *AppConfig, path, value string) error {
+	v, err := fieldByPath(reflect.ValueOf(cfg).Elem(), path)
+	if err != nil {
+		return err
+	}
+	if err := setValue(v, value); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func fieldByPath(v reflect.Value, path string) (reflect.Value, error) {
+	for _, key := range strings.Split(path, ".") {
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("unknown config key %q", path)
+		}
+		f, ok := fieldByKey(v, key)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("unknown config key %q", path)
+		}
+		v = f
+	}
+	return v, nil
+}
+
+func fieldByKey(v reflect.Value, key string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if yamlKey(t.Field(i)) == key {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func yamlKey(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "" {
+		return strings.ToLower(f.Name)
+	}
+	return name
+}
+
+func setValue(v reflect.Value, s string) error {
+	switch v.Type() {
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	case timeType:
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case offsetsType:
+		offs, err := ParseOffsets(s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(offs))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || v.OverflowInt(n) {
+			return fmt.Errorf("invalid int %q", s)
+		}
+		v.SetInt(n)
+	case reflect.Uint32:
+		n, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid unsigned int %q", s)
+		}
+		v.SetUint(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list type %s", v.Type())
+		}
+		v.Set(reflect.ValueOf(splitAndTrim(s)))
+	default:
+		return fmt.Errorf("cannot set a %s from a string", v.Type())
+	}
+	return nil
+}
+
+// redactedValue replaces non-empty secrets in Redacted output.
+const redactedValue = "[REDACTED]"
+
+// Redacted returns a copy of c with every field tagged `secret:"true"` masked,
+// for logging and `streamforge config print`. Secret references such as
+// "file:/run/secrets/x" are kept since they reveal only where the secret lives.
+func (c AppConfig) Redacted() AppConfig {
+	redact(reflect.ValueOf(&c).Elem())
+	return c
+}
+
+func redact(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := v.Field(i)
+		switch {
+		case t.Field(i).Tag.Get("secret") == "true":
+			if f.Kind() == reflect.String && f.String() != "" && !secrets.IsReference(f.String()) {
+				f.SetString(redactedValue)
+			}
+		case f.Kind() == reflect.Struct && f.Type() != timeType:
+			redact(f)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Validate checks the settings needed by service (ServiceIngestor,
+// ServiceTicksProcessor, or "" for all) and returns every problem found,
+// joined into one error.
+func (c AppConfig) Validate(service string) error {
+	var v validator
+
+	v.oneOf("obs.log_level", c.Obs.LogLevel, "debug", "info", "warn", "error")
+	v.oneOf("obs.trace_sampler", c.Obs.TraceSampler, "", "always", "never", "ratio")
+	v.check(c.Obs.TraceRatio >= 0 && c.Obs.TraceRatio <= 1, "obs.trace_ratio must be within [0,1], got %v", c.Obs.TraceRatio)
+	v.check(c.Obs.HealthInterval > 0 && c.Obs.HealthTimeout > 0 && c.Obs.HealthTimeout <= c.Obs.HealthInterval,
+		"obs.health_timeout must be > 0 and <= obs.health_interval")
+	v.check(len(c.Kafka.Brokers) > 0, "kafka.brokers is required")
+	v.required("kafka.ticks_topic", c.Kafka.TicksTopic)
+	v.check((c.Kafka.TLS.CertFile == "") == (c.Kafka.TLS.KeyFile == ""),
+		"kafka.tls.cert_file and kafka.tls.key_file must be set together")
+	v.oneOf("kafka.sasl.mechanism", c.Kafka.SASL.Mechanism, "", "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512")
+	if c.Kafka.SASL.Mechanism != "" {
+		v.required("kafka.sasl.username", c.Kafka.SASL.Username)
+		v.required("kafka.sasl.password", c.Kafka.SASL.Password)
+	}
+	if c.Secrets.EncryptedFile != "" {
+		v.required("secrets.key", c.Secrets.Key)
+	}
+	v.check(!strings.HasPrefix(c.Admin.Token, "enc:") || c.Secrets.EncryptedFile != "",
+		"admin.token uses enc: but secrets.encrypted_file is not set")
+
+	c.validateQuality(&v)
+	v.oneOf("instruments.source", c.Instruments.Source, "", "file", "table")
+	switch c.Instruments.Source {
+	case "file":
+		v.required("instruments.file", c.Instruments.File)
+	case "table":
+		v.check(c.Database.URL != "", "instruments.source table requires database.url")
+	}
+	if c.Dedup.Ingestor || c.Dedup.Processor {
+		v.check(len(c.Dedup.Fields) > 0, "dedup.fields is required")
+		for _, f := range c.Dedup.Fields {
+			v.oneOf("dedup.fields", f, "symbol", "ts", "price", "size", "exchange", "src_id")
+		}
+		v.check(c.Dedup.Window > 0, "dedup.window must be > 0")
+		v.check(c.Dedup.MaxPerSymbol > 0, "dedup.max_per_symbol must be > 0")
+	}
+
+	switch service {
+	case ServiceIngestor:
+		c.validateIngestor(&v)
+	case ServiceTicksProcessor:
+		c.validateProcessor(&v)
+	case "":
+		c.validateIngestor(&v)
+		c.validateProcessor(&v)
+	default:
+		v.check(false, "unknown service %q", service)
+	}
+	return errors.Join(v.errs...)
+}
+
+func (c AppConfig) validateIngestor(v *validator) {
+	v.required("ingestor.http_addr", c.Ingestor.HTTPAddr)
+	v.required("provider.token", c.DataProvider.Token)
+	v.check(!strings.HasPrefix(c.DataProvider.Token, "enc:") || c.Secrets.EncryptedFile != "",
+		"provider.token uses enc: but secrets.encrypted_file is not set")
+	v.required("provider.ws_url", c.DataProvider.WsURL)
+	v.check(len(c.DataProvider.Symbols) > 0, "provider.symbols is required")
+	v.check(c.DataProvider.ReconnectBase > 0 && c.DataProvider.ReconnectMax >= c.DataProvider.ReconnectBase,
+		"provider.reconnect_base must be > 0 and <= provider.reconnect_max")
+	v.check(c.DataProvider.StaleAfter >= 0, "provider.stale_after must be >= 0")
+	v.oneOf("producer.acks", fmt.Sprint(c.Producer.Acks), "-1", "0", "1")
+	v.oneOf("producer.compression", c.Producer.Compression, "none", "gzip", "snappy", "lz4", "zstd")
+}
+
+func (c AppConfig) validateProcessor(v *validator) {
+	v.required("ticks_processor.http_addr", c.TicksProcessor.HTTPAddr)
+	v.required("kafka.group_id", c.Kafka.GroupID)
+	v.check(c.Kafka.MinBytes > 0 && c.Kafka.MaxBytes >= c.Kafka.MinBytes,
+		"kafka.min_bytes must be > 0 and <= kafka.max_bytes")
+	v.oneOf("kafka.consumer_mode", c.Kafka.ConsumerMode, "single", "partitioned")
+	v.check(c.Kafka.BatchSize > 0, "kafka.batch_size must be > 0")
+	v.check(c.Kafka.CommitInterval > 0, "kafka.commit_interval must be > 0")
+	v.oneOf("kafka.start_position", c.Kafka.StartPosition, "earliest", "latest", "timestamp", "offsets")
+	v.check(c.Kafka.StartPosition != "timestamp" || !c.Kafka.StartTime.IsZero(),
+		"kafka.start_time is required when kafka.start_position is timestamp")
+	v.check(c.Kafka.StartPosition != "offsets" || len(c.Kafka.StartOffsets) > 0,
+		"kafka.start_offsets is required when kafka.start_position is offsets")
+	v.check(c.Router.QueueCapacity >= 0, "router.queue_capacity must be >= 0")
+	v.oneOf("router.drop_policy", c.Router.DropPolicy, "drop", "block")
+	v.check(c.Processor.NumWorkers > 0, "processor.num_workers must be > 0")
+	v.check(c.Processor.SlowThreshold >= 0, "processor.slow_threshold must be >= 0")
+	v.check(c.Processor.StuckAfter >= 0, "processor.stuck_after must be >= 0")
+	v.check(c.Processor.FlushInterval >= 0, "processor.flush_interval must be >= 0")
+	v.check(c.Processor.ShutdownTimeout > 0, "processor.shutdown_timeout must be > 0")
+	if c.GRPC.Addr != "" {
+		v.check(c.GRPC.StreamBuffer > 0, "grpc.stream_buffer must be > 0")
+		v.check(c.GRPC.RequestTimeout > 0, "grpc.request_timeout must be > 0")
+		v.check(c.GRPC.MaxBars > 0, "grpc.max_bars must be > 0")
+	}
+	names := make(map[string]bool)
+	validateStages(v, "pipeline.stages", c.Pipeline.Stages, names)
+	v.check(len(c.Pipeline.Stages) == 0 || !c.Dedup.Processor && !c.Quality.Processor && !c.Indicators.Processor && !c.Alerts.Processor && !c.Anomaly.Processor,
+		"dedup.processor, quality.processor, indicators.processor, alerts.processor and anomaly.processor cannot be combined with pipeline.stages; add the stages instead")
+	c.validateIndicators(v)
+	c.validateAlerts(v)
+	anomalyStage := c.Anomaly.Processor || hasStage(c.Pipeline.Stages, "anomaly")
+	if anomalyStage && !c.qualityDetectsAnomalies() {
+		c.validateAnomaly(v)
+	}
+	v.oneOf("state.backend", c.State.Backend, "", "disk", "kafka")
+	if c.State.Backend != "" {
+		v.check(c.State.CheckpointInterval > 0, "state.checkpoint_interval must be > 0")
+	}
+	if c.State.Backend == "disk" {
+		v.required("state.dir", c.State.Dir)
+	}
+	if c.State.Backend == "kafka" {
+		v.required("state.changelog_topic", c.State.ChangelogTopic)
+	}
+	v.check(c.Database.MaxConns > 0, "database.max_conns must be > 0")
+	v.check(!strings.HasPrefix(c.Database.URL, "enc:") || c.Secrets.EncryptedFile != "",
+		"database.url uses enc: but secrets.encrypted_file is not set")
+}
+
+func (c AppConfig) validateIndicators(v *validator) {
+	ind := c.Indicators
+	periods := map[string][]int{
+		"sma": ind.SMA, "ema": ind.EMA, "vwap": ind.VWAP, "volatility": ind.Volatility, "rsi": ind.RSI,
+	}
+	for _, name := range []string{"sma", "ema", "vwap", "volatility", "rsi"} {
+		for _, p := range periods[name] {
+			v.check(p > 1, "indicators.%s periods must be > 1, got %d", name, p)
+		}
+	}
+	for _, b := range ind.Bollinger {
+		v.check(b.Period > 1 && b.K > 0, "indicators.bollinger needs period > 1 and k > 0")
+	}
+	v.check(ind.BatchSize > 0, "indicators.batch_size must be > 0")
+	v.check(ind.RedisTTL >= 0, "indicators.redis_ttl must be >= 0")
+	v.check(!strings.HasPrefix(c.Redis.Password, "enc:") || c.Secrets.EncryptedFile != "",
+		"redis.password uses enc: but secrets.encrypted_file is not set")
+}
+
+func (c AppConfig) validateAlerts(v *validator) {
+	a := c.Alerts
+	names := make(map[string]bool)
+	for i, r := range a.Rules {
+		field := fmt.Sprintf("alerts.rules[%d]", i)
+		v.required(field+".name", r.Name)
+		v.check(!names[r.Name], "%s: duplicate rule name %q", field, r.Name)
+		names[r.Name] = true
+		v.check(r.Cooldown >= 0, "%s.cooldown must be >= 0", field)
+		switch r.Kind {
+		case "cross":
+			v.check(r.Level > 0, "%s.level must be > 0", field)
+			v.oneOf(field+".direction", r.Direction, "", "up", "down", "any")
+		case "move":
+			v.check(r.Percent > 0, "%s.percent must be > 0", field)
+			v.check(r.Window > 0, "%s.window must be > 0", field)
+		case "stale":
+			v.check(len(r.Symbols) > 0, "%s.symbols is required for stale rules", field)
+			v.check(r.After > 0, "%s.after must be > 0", field)
+			if r.Hours != "" {
+				_, _, err := ParseHours(r.Hours)
+				v.check(err == nil, "%s.hours: %v", field, err)
+			}
+			_, err := time.LoadLocation(r.Timezone)
+			v.check(err == nil, "%s.timezone: %v", field, err)
+		default:
+			v.oneOf(field+".kind", r.Kind, "cross", "move", "stale")
+		}
+	}
+	v.check(a.Cooldown >= 0, "alerts.cooldown must be >= 0")
+	v.check(a.QueueSize > 0, "alerts.queue_size must be > 0")
+	stage := a.Processor || hasStage(c.Pipeline.Stages, "alerts")
+	v.check(!stage || !a.Table || c.Database.URL != "",
+		"alerts.table requires database.url; set alerts.table to false to run without the alerts hypertable")
+	v.check(a.Webhook.URL == "" || a.Webhook.Timeout > 0, "alerts.webhook.timeout must be > 0")
+	v.check(!strings.HasPrefix(a.Webhook.Secret, "enc:") || c.Secrets.EncryptedFile != "",
+		"alerts.webhook.secret uses enc: but secrets.encrypted_file is not set")
+}
+
+// ParseHours parses "HH:MM-HH:MM" into offsets from midnight.
+func ParseHours(s string) (start, end time.Duration, err error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("want HH:MM-HH:MM, got %q", s)
+	}
+	parse := func(hm string) (time.Duration, error) {
+		t, err := time.Parse("15:04", strings.TrimSpace(hm))
+		if err != nil {
+			return 0, fmt.Errorf("want HH:MM-HH:MM, got %q", s)
+		}
+		return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+	}
+	if start, err = parse(from); err != nil {
+		return 0, 0, err
+	}
+	if end, err = parse(to); err != nil {
+		return 0, 0, err
+	}
+	if end <= start {
+		return 0, 0, fmt.Errorf("%q ends before it starts", s)
+	}
+	return start, end, nil
+}
+
+func (c AppConfig) validateQuality(v *validator) {
+	q := c.Quality
+	if !q.Ingestor && !q.Processor {
+		return
+	}
+	actions := map[string]string{
+		"invalid":        q.Actions.Invalid,
+		"zero_price":     q.Actions.ZeroPrice,
+		"future":         q.Actions.Future,
+		"out_of_order":   q.Actions.OutOfOrder,
+		"spike":          q.Actions.Spike,
+		"unknown_symbol": q.Actions.UnknownSymbol,
+		"anomaly":        q.Actions.Anomaly,
+	}
+	for _, rule := range slices.Sorted(maps.Keys(actions)) {
+		v.oneOf("quality.actions."+rule, actions[rule], "off", "flag", "drop", "quarantine")
+	}
+	v.check(!q.Actions.Quarantines() || q.QuarantineTopic != "", "quality.quarantine_topic is required when a rule quarantines")
+	v.check(q.SpikeWindow > 0, "quality.spike_window must be > 0")
+	v.check(q.SpikeThreshold > 0, "quality.spike_threshold must be > 0")
+	v.check(q.MaxFuture >= 0, "quality.max_future must be >= 0")
+	v.check(q.MaxLateness >= 0, "quality.max_lateness must be >= 0")
+	if c.qualityDetectsAnomalies() {
+		v.oneOf("quality.anomaly_severity", q.AnomalySeverity, "warning", "critical")
+		c.validateAnomaly(v)
+	}
+}
+
+// qualityDetectsAnomalies reports whether the quality anomaly rule is on.
+func (c AppConfig) qualityDetectsAnomalies() bool {
+	return (c.Quality.Ingestor || c.Quality.Processor) && c.Quality.Actions.Anomaly != "off"
+}
+
+func (c AppConfig) validateAnomaly(v *validator) {
+	a := c.Anomaly
+	v.check(len(a.Kinds) > 0, "anomaly.kinds must not be empty")
+	for _, k := range a.Kinds {
+		v.oneOf("anomaly.kinds", k, "price_jump", "volume_surge", "arrival_gap")
+	}
+	v.check(a.Alpha > 0 && a.Alpha < 1, "anomaly.alpha must be within (0,1), got %v", a.Alpha)
+	v.check(a.MinSamples > 1, "anomaly.min_samples must be > 1")
+	v.check(a.Warning > 0 && a.Critical >= a.Warning, "anomaly needs 0 < warning <= critical")
+	for i, cl := range a.Classes {
+		v.required(fmt.Sprintf("anomaly.classes[%d].name", i), cl.Name)
+		v.check(len(cl.Symbols)+len(cl.AssetClasses) > 0, "anomaly.classes[%d]: symbols or asset_classes is required", i)
+		for _, g := range cl.Symbols {
+			_, err := path.Match(g, "")
+			v.check(err == nil, "anomaly.classes[%d]: bad glob %q", i, g)
+		}
+	}
+}
+
+func validateStages(v *validator, path string, stages []Stage, names map[string]bool) {
+	for i, st := range stages {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		v.required(p+".name", st.Name)
+		v.check(!names[st.Name], "%s.name %q is not unique", p, st.Name)
+		names[st.Name] = true
+		v.required(p+".type", st.Type)
+		v.oneOf(p+".on_error", st.OnError, "", "skip", "retry", "dlq", "halt")
+		v.check(st.Timeout >= 0, "%s.timeout must be >= 0", p)
+		v.check(st.Retries >= 0 && st.RetryBackoff >= 0, "%s.retries and retry_backoff must be >= 0", p)
+		v.check(st.OnError != "retry" || st.Retries > 0, "%s.retries must be > 0 when on_error is retry", p)
+		composite := st.Type == "chain" || st.Type == "fanout"
+		v.check(composite == (len(st.Stages) > 0), "%s.stages is required for chain and fanout stages and not allowed for others", p)
+		validateStages(v, p+".stages", st.Stages, names)
+	}
+}
+
+// hasStage reports whether stages, or any chain or fanout among them,
+// contain a stage of type typ.
+func hasStage(stages []Stage, typ string) bool {
+	return slices.ContainsFunc(stages, func(s Stage) bool { return s.Type == typ || hasStage(s.Stages, typ) })
+}
+
+type validator struct {
+	errs []error
+}
+
+func (v *validator) check(ok bool, format string, args ...any) {
+	if !ok {
+		v.errs = append(v.errs, fmt.Errorf(format, args...))
+	}
+}
+
+func (v *validator) required(path, value string) {
+	v.check(value != "", "%s is required", path)
+}
+
+func (v *validator) oneOf(path, value string, allowed ...string) {
+	v.check(slices.Contains(allowed, value), "%s must be one of %q, got %q", path, allowed, value)
+}
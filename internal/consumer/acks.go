@@ -0,0 +1,161 @@
+package consumer
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ackTracker computes, per partition, the offset that is safe to commit: the
+// first delivered offset that has not been acknowledged yet. Messages from one
+// partition fan out to several workers and complete out of order, so a later
+// offset finishing first must not advance the commit point past an earlier one.
+type ackTracker struct {
+	mu    sync.Mutex
+	parts map[int]*partitionAcks
+}
+
+type partitionAcks struct {
+	pending []int64 // delivered offsets in delivery order, oldest first
+	acked   map[int64]bool
+	next    int64 // next offset to commit; -1 until something completes
+	dirty   bool  // next changed since the last committable call
+}
+
+func newAckTracker() *ackTracker {
+	return &ackTracker{parts: make(map[int]*partitionAcks)}
+}
+
+func (t *ackTracker) partition(p int) *partitionAcks {
+	pa, ok := t.parts[p]
+	if !ok {
+		pa = &partitionAcks{acked: make(map[int64]bool), next: -1}
+		t.parts[p] = pa
+	}
+	return pa
+}
+
+// deliver records that offset is being handed to the pipeline. Offsets are
+// normally delivered in order; an out-of-order one is inserted in place.
+func (t *ackTracker) deliver(p int, offset int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pa := t.partition(p)
+	i := len(pa.pending)
+	for i > 0 && pa.pending[i-1] > offset {
+		i--
+	}
+	pa.pending = append(pa.pending, 0)
+	copy(pa.pending[i+1:], pa.pending[i:])
+	pa.pending[i] = offset
+}
+
+// cancel forgets a delivery that never reached the pipeline.
+func (t *ackTracker) cancel(p int, offset int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pa := t.partition(p)
+	for i, off := range pa.pending {
+		if off == offset {
+			pa.pending = append(pa.pending[:i], pa.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// ack marks offset as fully processed.
+func (t *ackTracker) ack(p int, offset int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pa, ok := t.parts[p]
+	if !ok {
+		return // partition was revoked and forgotten
+	}
+	pa.acked[offset] = true
+	for len(pa.pending) > 0 && pa.acked[pa.pending[0]] {
+		delete(pa.acked, pa.pending[0])
+		pa.next = pa.pending[0] + 1
+		pa.dirty = true
+		pa.pending = pa.pending[1:]
+	}
+}
+
+// skip records an offset that never enters the pipeline (e.g. undecodable).
+func (t *ackTracker) skip(p int, offset int64) {
+	t.deliver(p, offset)
+	t.ack(p, offset)
+}
+
+// onDone returns the acknowledgement callback for a delivered message.
+func (t *ackTracker) onDone(p int, offset int64) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() { t.ack(p, offset) })
+	}
+}
+
+// committable returns the commit points that moved since the previous call.
+func (t *ackTracker) committable() map[int]int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make(map[int]int64)
+	for p, pa := range t.parts {
+		if pa.dirty {
+			out[p] = pa.next
+			pa.dirty = false
+		}
+	}
+	return out
+}
+
+// committableFor is committable restricted to a single partition.
+func (t *ackTracker) committableFor(p int) map[int]int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pa, ok := t.parts[p]
+	if !ok || !pa.dirty {
+		return nil
+	}
+	pa.dirty = false
+	return map[int]int64{p: pa.next}
+}
+
+// forget drops all state for a partition that is no longer assigned.
+func (t *ackTracker) forget(p int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.parts, p)
+}
+
+// inflight reports delivered but unacknowledged messages for partition p, or
+// for all partitions when p is allPartitions.
+func (t *ackTracker) inflight(p int) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if p != allPartitions {
+		if pa, ok := t.parts[p]; ok {
+			return len(pa.pending)
+		}
+		return 0
+	}
+	n := 0
+	for _, pa := range t.parts {
+		n += len(pa.pending)
+	}
+	return n
+}
+
+// waitIdle blocks until partition p (or all partitions) has nothing in flight
+// or ctx is done. It reports whether the partition drained.
+func (t *ackTracker) waitIdle(ctx context.Context, p int) bool {
+	tick := time.NewTicker(20 * time.Millisecond)
+	defer tick.Stop()
+	for t.inflight(p) > 0 {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-tick.C:
+		}
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package consumer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAckTrackerCommitsContiguousPrefix(t *testing.T) {
+	acks := newAckTracker()
+	for off := int64(10); off < 14; off++ {
+		acks.deliver(0, off)
+	}
+
+	acks.ack(0, 11)
+	acks.ack(0, 12)
+	assert.Empty(t, acks.committable(), "offset 10 still in flight")
+
+	acks.ack(0, 10)
+	assert.Equal(t, map[int]int64{0: 13}, acks.committable())
+	assert.Empty(t, acks.committable(), "unchanged commit point is not reported twice")
+	assert.Equal(t, 1, acks.inflight(0))
+
+	acks.skip(0, 14)
+	acks.ack(0, 13)
+	assert.Equal(t, map[int]int64{0: 15}, acks.committable())
+	assert.Equal(t, 0, acks.inflight(allPartitions))
+}
+
+func TestAckTrackerIgnoresForgottenPartition(t *testing.T) {
+	acks := newAckTracker()
+	acks.deliver(3, 7)
+	done := acks.onDone(3, 7)
+	acks.forget(3)
+
+	done()
+	done()
+	assert.Empty(t, acks.committable())
+}
@@ -0,0 +1,67 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// errNotMember is reported while a partitioned consumer holds no generation.
+var errNotMember = errors.New("not a member of the consumer group")
+
+// groupState tracks consumer group membership and the latest broker error
+// for health checks.
+type groupState struct {
+	mu      sync.Mutex
+	gen     int32 // current generation ID; -1 when not a member
+	lastErr error
+}
+
+func newGroupState() *groupState { return &groupState{gen: -1} }
+
+func (s *groupState) joined(gen int32) {
+	s.mu.Lock()
+	s.gen, s.lastErr = gen, nil
+	s.mu.Unlock()
+}
+
+// left clears membership if gen is still the current generation.
+func (s *groupState) left(gen int32) {
+	s.mu.Lock()
+	if s.gen == gen {
+		s.gen = -1
+	}
+	s.mu.Unlock()
+}
+
+// fail records err; ok clears it.
+func (s *groupState) fail(err error) {
+	s.mu.Lock()
+	s.lastErr = err
+	s.mu.Unlock()
+}
+
+func (s *groupState) ok() { s.fail(nil) }
+
+// check returns the last error, and errNotMember if requireMember is set and
+// no generation is held.
+func (s *groupState) check(requireMember bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lastErr != nil {
+		return fmt.Errorf("kafka consumer: %w", s.lastErr)
+	}
+	if requireMember && s.gen < 0 {
+		return errNotMember
+	}
+	return nil
+}
+
+// Check reports the last fetch error. kafka.Reader does not expose group
+// membership, so an idle but healthy reader always passes.
+func (c *TickConsumer) Check(context.Context) error { return c.state.check(false) }
+
+// Check fails while the consumer holds no group generation (joining,
+// rebalancing or after a join error).
+func (c *PartitionedConsumer) Check(context.Context) error { return c.state.check(true) }
@@ -0,0 +1,320 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/broker"
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// Start positions understood by config.Kafka.StartPosition.
+const (
+	StartEarliest  = "earliest"
+	StartLatest    = "latest"
+	StartTimestamp = "timestamp"
+	StartOffsets   = "offsets"
+)
+
+// PartitionOffset describes a consumer group's position on one partition.
+type PartitionOffset struct {
+	Partition int
+	Committed int64 // -1 when the group has no committed offset
+	Earliest  int64
+	Latest    int64
+}
+
+// Lag returns how many messages the group is behind the partition end.
+func (p PartitionOffset) Lag() int64 {
+	if p.Committed < 0 {
+		return p.Latest - p.Earliest
+	}
+	return p.Latest - p.Committed
+}
+
+// OffsetAdmin inspects and rewrites committed offsets of a consumer group.
+// Commits are issued outside of any group generation, so they are only
+// accepted by the broker while the group has no active members.
+type OffsetAdmin struct {
+	client *kafka.Client
+	group  string
+	topic  string
+}
+
+// NewOffsetAdmin creates an OffsetAdmin for group on topic.
+func NewOffsetAdmin(brokers []string, group, topic string, sec *broker.Security) (*OffsetAdmin, error) {
+	if len(brokers) == 0 || brokers[0] == "" {
+		return nil, errors.New("kafka brokers missing")
+	}
+	if group == "" || topic == "" {
+		return nil, errors.New("kafka group or topic missing")
+	}
+	return &OffsetAdmin{
+		client: &kafka.Client{Addr: kafka.TCP(brokers...), Timeout: 10 * time.Second, Transport: sec.Transport()},
+		group:  group,
+		topic:  topic,
+	}, nil
+}
+
+// Partitions returns the partition IDs of the topic in ascending order.
+func (a *OffsetAdmin) Partitions(ctx context.Context) ([]int, error) {
+	md, err := a.client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{a.topic}})
+	if err != nil {
+		return nil, fmt.Errorf("metadata: %w", err)
+	}
+	for _, t := range md.Topics {
+		if t.Name != a.topic {
+			continue
+		}
+		if t.Error != nil {
+			return nil, fmt.Errorf("metadata %s: %w", a.topic, t.Error)
+		}
+		ids := make([]int, 0, len(t.Partitions))
+		for _, p := range t.Partitions {
+			ids = append(ids, p.ID)
+		}
+		sort.Ints(ids)
+		return ids, nil
+	}
+	return nil, fmt.Errorf("topic %s not found", a.topic)
+}
+
+// Describe returns committed, earliest and latest offsets for every partition.
+func (a *OffsetAdmin) Describe(ctx context.Context) ([]PartitionOffset, error) {
+	parts, err := a.Partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	committed, err := a.committed(ctx, parts)
+	if err != nil {
+		return nil, err
+	}
+	earliest, err := a.listOffsets(ctx, parts, kafka.FirstOffset)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := a.listOffsets(ctx, parts, kafka.LastOffset)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]PartitionOffset, 0, len(parts))
+	for _, p := range parts {
+		c, ok := committed[p]
+		if !ok {
+			c = -1
+		}
+		out = append(out, PartitionOffset{
+			Partition: p,
+			Committed: c,
+			Earliest:  earliest[p],
+			Latest:    latest[p],
+		})
+	}
+	return out, nil
+}
+
+// Earliest returns the first available offset of every partition.
+func (a *OffsetAdmin) Earliest(ctx context.Context) (map[int]int64, error) {
+	parts, err := a.Partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return a.listOffsets(ctx, parts, kafka.FirstOffset)
+}
+
+// Latest returns the end offset of every partition.
+func (a *OffsetAdmin) Latest(ctx context.Context) (map[int]int64, error) {
+	parts, err := a.Partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return a.listOffsets(ctx, parts, kafka.LastOffset)
+}
+
+// AtTime returns, for every partition, the first offset whose message
+// timestamp is at or after t. Partitions with no such message resolve to
+// their end offset.
+func (a *OffsetAdmin) AtTime(ctx context.Context, t time.Time) (map[int]int64, error) {
+	parts, err := a.Partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reqs := make([]kafka.OffsetRequest, 0, len(parts))
+	for _, p := range parts {
+		reqs = append(reqs, kafka.TimeOffsetOf(p, t))
+	}
+	res, err := a.client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{a.topic: reqs},
+	})
+	if err != nil {
+		return nil, err
+	}
+	latest, err := a.listOffsets(ctx, parts, kafka.LastOffset)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[int]int64, len(parts))
+	for _, po := range res.Topics[a.topic] {
+		if po.Error != nil {
+			return nil, fmt.Errorf("list offsets partition %d: %w", po.Partition, po.Error)
+		}
+		out[po.Partition] = latest[po.Partition]
+		for off := range po.Offsets {
+			if off >= 0 {
+				out[po.Partition] = off
+			}
+		}
+	}
+	return out, nil
+}
+
+// Commit overwrites the group's committed offsets for the given partitions.
+func (a *OffsetAdmin) Commit(ctx context.Context, offsets map[int]int64) error {
+	if len(offsets) == 0 {
+		return nil
+	}
+	commits := make([]kafka.OffsetCommit, 0, len(offsets))
+	for p, off := range offsets {
+		commits = append(commits, kafka.OffsetCommit{Partition: p, Offset: off})
+	}
+	res, err := a.client.OffsetCommit(ctx, &kafka.OffsetCommitRequest{
+		GroupID:      a.group,
+		GenerationID: -1,
+		Topics:       map[string][]kafka.OffsetCommit{a.topic: commits},
+	})
+	if err != nil {
+		return fmt.Errorf("offset commit: %w", err)
+	}
+	var errs []error
+	for _, p := range res.Topics[a.topic] {
+		if p.Error != nil {
+			errs = append(errs, fmt.Errorf("partition %d: %w", p.Partition, p.Error))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (a *OffsetAdmin) committed(ctx context.Context, parts []int) (map[int]int64, error) {
+	res, err := a.client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{
+		GroupID: a.group,
+		Topics:  map[string][]int{a.topic: parts},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("offset fetch: %w", err)
+	}
+	if res.Error != nil {
+		return nil, fmt.Errorf("offset fetch: %w", res.Error)
+	}
+	out := make(map[int]int64, len(parts))
+	for _, p := range res.Topics[a.topic] {
+		if p.Error != nil {
+			return nil, fmt.Errorf("offset fetch partition %d: %w", p.Partition, p.Error)
+		}
+		if p.CommittedOffset >= 0 {
+			out[p.Partition] = p.CommittedOffset
+		}
+	}
+	return out, nil
+}
+
+// listOffsets resolves the first (kind kafka.FirstOffset) or last
+// (kafka.LastOffset) offsets of parts. Each kind needs its own request
+// because brokers reject duplicate partitions within one ListOffsets call.
+func (a *OffsetAdmin) listOffsets(ctx context.Context, parts []int, kind int64) (map[int]int64, error) {
+	reqs := make([]kafka.OffsetRequest, 0, len(parts))
+	for _, p := range parts {
+		reqs = append(reqs, kafka.OffsetRequest{Partition: p, Timestamp: kind})
+	}
+	res, err := a.client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{a.topic: reqs},
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[int]int64, len(parts))
+	for _, po := range res.Topics[a.topic] {
+		if po.Error != nil {
+			return nil, fmt.Errorf("list offsets partition %d: %w", po.Partition, po.Error)
+		}
+		out[po.Partition] = resolvedOffset(po, kind)
+	}
+	return out, nil
+}
+
+// resolvedOffset returns the offset of kind in po. kafka-go files each
+// answer by the timestamp the broker sends with it, which is -1
+// (kafka.LastOffset) for both kinds, so a first offset arrives in LastOffset
+// while FirstOffset keeps the placeholder 0.
+func resolvedOffset(po kafka.PartitionOffsets, kind int64) int64 {
+	if kind == kafka.FirstOffset && po.LastOffset < 0 {
+		return po.FirstOffset // filed under the requested kind
+	}
+	return po.LastOffset
+}
+
+// startOffset maps the configured start position onto the reader fallback
+// used for partitions without a committed offset.
+func startOffset(cfg config.Kafka) int64 {
+	if cfg.StartPosition == StartLatest {
+		return kafka.LastOffset
+	}
+	return kafka.FirstOffset
+}
+
+// ApplyStartPosition seeds committed offsets for partitions the group has never
+// committed, according to cfg.StartPosition. Partitions that already have a
+// committed offset are left alone; use the `streamforge offsets` command to
+// force a reset. earliest and latest need no seeding and are handled by the
+// reader's StartOffset.
+func ApplyStartPosition(ctx context.Context, cfg config.Kafka, sec *broker.Security, log *zap.Logger) error {
+	switch cfg.StartPosition {
+	case "", StartEarliest, StartLatest:
+		return nil
+	case StartTimestamp, StartOffsets:
+	default:
+		return fmt.Errorf("unknown kafka start position %q", cfg.StartPosition)
+	}
+
+	admin, err := NewOffsetAdmin(cfg.Brokers, cfg.GroupID, cfg.TicksTopic, sec)
+	if err != nil {
+		return err
+	}
+	current, err := admin.Describe(ctx)
+	if err != nil {
+		return err
+	}
+
+	var target map[int]int64
+	if cfg.StartPosition == StartTimestamp {
+		target, err = admin.AtTime(ctx, cfg.StartTime)
+		if err != nil {
+			return err
+		}
+	} else {
+		target = cfg.StartOffsets
+	}
+
+	seed := make(map[int]int64)
+	for _, po := range current {
+		off, ok := target[po.Partition]
+		if !ok || po.Committed >= 0 {
+			continue
+		}
+		seed[po.Partition] = off
+	}
+	if len(seed) == 0 {
+		return nil
+	}
+	log.Info("seeding start offsets",
+		zap.String("position", cfg.StartPosition),
+		zap.Any("offsets", seed),
+	)
+	return admin.Commit(ctx, seed)
+}
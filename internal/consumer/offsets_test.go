@@ -0,0 +1,82 @@
+package consumer
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	"github.com/segmentio/kafka-go/protocol/listoffsets"
+	"github.com/segmentio/kafka-go/protocol/metadata"
+	"github.com/segmentio/kafka-go/protocol/offsetfetch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBroker answers the requests of an OffsetAdmin for one topic the way a
+// broker does: earliest and latest lookups come back with timestamp -1.
+type fakeBroker struct {
+	topic       string
+	first, last map[int]int64
+	committed   map[int]int64
+}
+
+func (b *fakeBroker) RoundTrip(_ context.Context, _ net.Addr, req protocol.Message) (protocol.Message, error) {
+	switch req := req.(type) {
+	case *metadata.Request:
+		t := metadata.ResponseTopic{Name: b.topic}
+		for p := range b.first {
+			t.Partitions = append(t.Partitions, metadata.ResponsePartition{PartitionIndex: int32(p)})
+		}
+		return &metadata.Response{Topics: []metadata.ResponseTopic{t}}, nil
+	case *offsetfetch.Request:
+		t := offsetfetch.ResponseTopic{Name: b.topic}
+		for p := range b.first {
+			off, ok := b.committed[p]
+			if !ok {
+				off = -1
+			}
+			t.Partitions = append(t.Partitions, offsetfetch.ResponsePartition{PartitionIndex: int32(p), CommittedOffset: off})
+		}
+		return &offsetfetch.Response{Topics: []offsetfetch.ResponseTopic{t}}, nil
+	case *listoffsets.Request:
+		res := &listoffsets.Response{}
+		for _, rt := range req.Topics {
+			t := listoffsets.ResponseTopic{Topic: rt.Topic}
+			for _, rp := range rt.Partitions {
+				off := b.last[int(rp.Partition)]
+				if rp.Timestamp == kafka.FirstOffset {
+					off = b.first[int(rp.Partition)]
+				}
+				t.Partitions = append(t.Partitions, listoffsets.ResponsePartition{Partition: rp.Partition, Timestamp: -1, Offset: off})
+			}
+			res.Topics = append(res.Topics, t)
+		}
+		return res, nil
+	}
+	panic("unexpected request")
+}
+
+func TestDescribe(t *testing.T) {
+	b := &fakeBroker{
+		topic:     "ticks",
+		first:     map[int]int64{0: 100, 1: 5},
+		last:      map[int]int64{0: 250, 1: 40},
+		committed: map[int]int64{0: 200},
+	}
+	a := &OffsetAdmin{client: &kafka.Client{Addr: kafka.TCP("broker:9092"), Transport: b}, group: "g", topic: "ticks"}
+
+	got, err := a.Describe(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []PartitionOffset{
+		{Partition: 0, Committed: 200, Earliest: 100, Latest: 250},
+		{Partition: 1, Committed: -1, Earliest: 5, Latest: 40},
+	}, got)
+	assert.Equal(t, int64(50), got[0].Lag())
+	assert.Equal(t, int64(35), got[1].Lag(), "uncommitted: everything retained")
+
+	earliest, err := a.Earliest(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[int]int64{0: 100, 1: 5}, earliest)
+}
@@ -0,0 +1,379 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/broker"
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/events"
+	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
+	"github.com/jonandereg/streamforge/internal/model"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// PartitionedConsumer joins the consumer group and runs one fetch loop per
+// assigned partition. Messages are decoded in batches and offsets of
+// acknowledged messages are committed asynchronously every CommitInterval.
+type PartitionedConsumer struct {
+	gate
+
+	cfg    config.Kafka
+	group  *kafka.ConsumerGroup
+	dialer *kafka.Dialer
+	log    *zap.Logger
+	state  *groupState
+	ckpt   StateSync
+
+	fetching  sync.WaitGroup // partition loops still fetching or delivering
+	closing   chan struct{}  // closed by Close to release the final commits
+	closeOnce sync.Once
+}
+
+// NewPartitionedConsumer creates a consumer group member for cfg.TicksTopic.
+func NewPartitionedConsumer(cfg config.Kafka, sec *broker.Security, log *zap.Logger) (*PartitionedConsumer, error) {
+	if len(cfg.Brokers) == 0 || cfg.Brokers[0] == "" {
+		return nil, errors.New("kafka brokers missing")
+	}
+	if cfg.GroupID == "" || cfg.TicksTopic == "" {
+		return nil, errors.New("kafka group or topic missing")
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.CommitInterval <= 0 {
+		cfg.CommitInterval = time.Second
+	}
+	if cfg.DrainTimeout <= 0 {
+		cfg.DrainTimeout = 10 * time.Second
+	}
+	g, err := kafka.NewConsumerGroup(kafka.ConsumerGroupConfig{
+		ID:          cfg.GroupID,
+		Brokers:     cfg.Brokers,
+		Topics:      []string{cfg.TicksTopic},
+		StartOffset: startOffset(cfg),
+		Dialer:      sec.Dialer(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &PartitionedConsumer{
+		cfg:     cfg,
+		group:   g,
+		dialer:  sec.Dialer(),
+		log:     log.Named("partitioned-consumer"),
+		state:   newGroupState(),
+		ckpt:    noState{},
+		closing: make(chan struct{}),
+	}, nil
+}
+
+// Run consumes generations until ctx is cancelled. Each generation ends on
+// rebalance; a revoked partition waits (up to DrainTimeout) for its in-flight
+// messages to be acknowledged and commits before it is released.
+//
+// When ctx is cancelled Run returns once no partition loop will send on out
+// again. Group membership is kept so the final commit can happen in Close,
+// after the pipeline has drained.
+func (c *PartitionedConsumer) Run(ctx context.Context, out chan<- events.TickMsg) error {
+	c.log.Info("starting",
+		zap.Strings("brokers", c.cfg.Brokers),
+		zap.String("group", c.cfg.GroupID),
+		zap.String("topic", c.cfg.TicksTopic),
+		zap.Int("batch_size", c.cfg.BatchSize),
+		zap.Duration("commit_interval", c.cfg.CommitInterval),
+	)
+
+	backoff := 200 * time.Millisecond
+	for {
+		gen, err := c.group.Next(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, kafka.ErrGroupClosed) {
+				c.fetching.Wait()
+				c.log.Info("context closed, fetching stopped")
+				return nil
+			}
+			c.log.Warn("join group error", zap.Error(err))
+			c.state.fail(err)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+			}
+			continue
+		}
+		c.runGeneration(ctx, gen, out)
+	}
+}
+
+// SetStateSync implements Runner.
+func (c *PartitionedConsumer) SetStateSync(s StateSync) { c.ckpt = s }
+
+// Close commits the offsets acknowledged so far, leaves the group and closes
+// all partition readers. ctx bounds how long Close waits.
+func (c *PartitionedConsumer) Close(ctx context.Context) error {
+	c.closeOnce.Do(func() { close(c.closing) })
+	done := make(chan error, 1)
+	go func() { done <- c.group.Close() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *PartitionedConsumer) runGeneration(ctx context.Context, gen *kafka.Generation, out chan<- events.TickMsg) {
+	assignments := gen.Assignments[c.cfg.TicksTopic]
+	c.log.Info("partitions assigned",
+		zap.Int32("generation", gen.ID),
+		zap.Int("count", len(assignments)),
+	)
+	c.state.joined(gen.ID)
+
+	ids := make([]int, 0, len(assignments))
+	for _, a := range assignments {
+		ids = append(ids, a.ID)
+	}
+	if err := restoreState(ctx, c.ckpt, ids, c.log); err != nil {
+		return // shutting down; no partition loop was started
+	}
+
+	// Commits are serialized so an older interval commit can never land
+	// after a newer final one. A partition's final commit is preceded by a
+	// state checkpoint so it is not held back.
+	acks := newAckTracker()
+	var commitMu sync.Mutex
+	commit := func(partition int) {
+		commitMu.Lock()
+		defer commitMu.Unlock()
+		if partition == allPartitions {
+			c.commit(gen, acks.committable())
+			return
+		}
+		offsets := acks.committableFor(partition)
+		ctx, cancel := context.WithTimeout(context.Background(), c.cfg.DrainTimeout)
+		defer cancel()
+		if err := c.ckpt.Checkpoint(ctx, offsets); err != nil {
+			c.log.Warn("final state checkpoint failed; offsets since the last one are not committed",
+				zap.Int("partition", partition), zap.Error(err))
+		}
+		c.commit(gen, offsets)
+	}
+
+	for _, a := range assignments {
+		a := a
+		c.fetching.Add(1)
+		gen.Start(func(genCtx context.Context) {
+			c.consumePartition(ctx, genCtx, a, acks, commit, out)
+		})
+	}
+	gen.Start(func(genCtx context.Context) {
+		t := time.NewTicker(c.cfg.CommitInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-genCtx.Done():
+				c.state.left(gen.ID)
+				return
+			case <-t.C:
+				commit(allPartitions)
+			}
+		}
+	})
+}
+
+// consumePartition fetches from a single partition until the generation ends
+// or ctx is cancelled, then waits for in-flight messages and commits.
+func (c *PartitionedConsumer) consumePartition(
+	ctx, genCtx context.Context,
+	a kafka.PartitionAssignment,
+	acks *ackTracker,
+	commit func(partition int),
+	out chan<- events.TickMsg,
+) {
+	plog := c.log.With(zap.Int("partition", a.ID))
+
+	r := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   c.cfg.Brokers,
+		Topic:     c.cfg.TicksTopic,
+		Partition: a.ID,
+		MinBytes:  c.cfg.MinBytes,
+		MaxBytes:  c.cfg.MaxBytes,
+		MaxWait:   c.cfg.MaxWait,
+		Dialer:    c.dialer,
+	})
+	defer func() {
+		if err := r.Close(); err != nil {
+			plog.Warn("reader close error", zap.Error(err))
+		}
+		sfmetrics.ProcessorConsumerLag.DeleteLabelValues(strconv.Itoa(a.ID))
+		acks.forget(a.ID)
+	}()
+
+	c.fetchLoop(ctx, genCtx, r, a, acks, out, plog)
+	c.fetching.Done()
+
+	if ctx.Err() != nil {
+		// Shutting down: hold the partition until Close releases the final
+		// commit, i.e. after workers have finished and processors flushed.
+		select {
+		case <-c.closing:
+		case <-genCtx.Done():
+		}
+	} else {
+		// Revoked by a rebalance: give in-flight messages a bounded chance to
+		// complete so the next owner does not reprocess them.
+		drainCtx, cancel := context.WithTimeout(context.Background(), c.cfg.DrainTimeout)
+		if !acks.waitIdle(drainCtx, a.ID) {
+			plog.Warn("revoked with messages still in flight", zap.Int("inflight", acks.inflight(a.ID)))
+		}
+		cancel()
+	}
+	commit(a.ID)
+	c.ckpt.Release([]int{a.ID})
+	plog.Info("partition loop stopped")
+}
+
+func (c *PartitionedConsumer) fetchLoop(
+	ctx, genCtx context.Context,
+	r *kafka.Reader,
+	a kafka.PartitionAssignment,
+	acks *ackTracker,
+	out chan<- events.TickMsg,
+	plog *zap.Logger,
+) {
+	if err := r.SetOffset(a.Offset); err != nil {
+		plog.Warn("set offset failed", zap.Int64("offset", a.Offset), zap.Error(err))
+		return
+	}
+	plog.Info("partition loop started", zap.Int64("offset", a.Offset))
+
+	fetchCtx, cancel := mergeDone(ctx, genCtx)
+	defer cancel()
+
+	backoff := 200 * time.Millisecond
+	for fetchCtx.Err() == nil {
+		if c.gate.wait(fetchCtx) != nil {
+			return
+		}
+		batch, err := c.fetchBatch(fetchCtx, r)
+		if err != nil {
+			if fetchCtx.Err() != nil {
+				return
+			}
+			plog.Warn("fetch error", zap.Error(err))
+			select {
+			case <-time.After(backoff):
+			case <-fetchCtx.Done():
+			}
+			continue
+		}
+
+		ok := c.deliver(fetchCtx, decodeBatch(batch, acks, plog), acks, out)
+		sfmetrics.ProcessorConsumerLag.WithLabelValues(strconv.Itoa(a.ID)).Set(float64(r.Lag()))
+		if !ok {
+			return
+		}
+	}
+}
+
+// fetchBatch blocks for the first message, then collects whatever is already
+// buffered by the reader up to BatchSize without waiting further.
+func (c *PartitionedConsumer) fetchBatch(ctx context.Context, r *kafka.Reader) ([]kafka.Message, error) {
+	m, err := r.FetchMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	batch := make([]kafka.Message, 0, c.cfg.BatchSize)
+	batch = append(batch, m)
+
+	for len(batch) < c.cfg.BatchSize {
+		pollCtx, cancel := context.WithTimeout(ctx, time.Millisecond)
+		m, err := r.FetchMessage(pollCtx)
+		cancel()
+		if err != nil {
+			break
+		}
+		batch = append(batch, m)
+	}
+	sfmetrics.ProcessorConsumedTotal.Add(float64(len(batch)))
+	return batch, nil
+}
+
+// deliver hands decoded messages to out. It returns false if ctx ended
+// before the whole batch was handed off; the undelivered remainder is no
+// longer tracked and will be fetched again.
+func (c *PartitionedConsumer) deliver(ctx context.Context, msgs []events.TickMsg, acks *ackTracker, out chan<- events.TickMsg) bool {
+	for i, msg := range msgs {
+		select {
+		case out <- msg:
+		case <-ctx.Done():
+			for _, m := range msgs[i:] {
+				acks.cancel(m.Kafka.Partition, m.Kafka.Offset)
+			}
+			return false
+		}
+	}
+	return true
+}
+
+// allPartitions selects every partition in commit and ackTracker queries.
+const allPartitions = -1
+
+func (c *PartitionedConsumer) commit(gen *kafka.Generation, offsets map[int]int64) {
+	offsets = c.ckpt.Committable(offsets)
+	if len(offsets) == 0 {
+		return
+	}
+	start := time.Now()
+	err := gen.CommitOffsets(map[string]map[int]int64{c.cfg.TicksTopic: offsets})
+	sfmetrics.ProcessorCommitLatencySeconds.Observe(time.Since(start).Seconds())
+	if err != nil {
+		sfmetrics.ProcessorCommitTotal.WithLabelValues("failure").Inc()
+		sfmetrics.ProcessorFailedTotal.WithLabelValues("commit").Inc()
+		c.log.Warn("commit error", zap.Any("offsets", offsets), zap.Error(err))
+		return
+	}
+	sfmetrics.ProcessorCommitTotal.WithLabelValues("success").Inc()
+}
+
+// decodeBatch decodes a fetched batch, tracking each message as in flight
+// in offset order. Undecodable messages are logged and marked done
+// immediately so they do not hold back the commit point, nor move it past
+// the messages before them.
+func decodeBatch(batch []kafka.Message, acks *ackTracker, log *zap.Logger) []events.TickMsg {
+	msgs := make([]events.TickMsg, 0, len(batch))
+	for _, m := range batch {
+		var t model.Tick
+		if err := json.Unmarshal(m.Value, &t); err != nil {
+			sfmetrics.ProcessorFailedTotal.WithLabelValues("decode").Inc()
+			log.Warn("json decode error,skipping",
+				zap.Int64("offset", m.Offset),
+				zap.Error(err),
+			)
+			acks.skip(m.Partition, m.Offset)
+			continue
+		}
+		sfmetrics.ProcessorDecodedTotal.Inc()
+		acks.deliver(m.Partition, m.Offset)
+		msg := newTickMsg(m, t)
+		msg.OnDone = acks.onDone(m.Partition, m.Offset)
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+// mergeDone returns a context cancelled when either a or b is done.
+func mergeDone(a, b context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(a)
+	stop := context.AfterFunc(b, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
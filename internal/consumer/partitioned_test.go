@@ -0,0 +1,158 @@
+package consumer
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/events"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// releases records the partitions released by a consumer.
+type releases struct {
+	noState
+	mu    sync.Mutex
+	parts []int
+}
+
+func (r *releases) Release(partitions []int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.parts = append(r.parts, partitions...)
+}
+
+func (r *releases) get() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int(nil), r.parts...)
+}
+
+// newPartitioned returns a consumer whose partition loops can run without a
+// broker, and the commits they make.
+func newPartitioned(st StateSync) (*PartitionedConsumer, func(acks *ackTracker) (func(int), <-chan map[int]int64)) {
+	c := &PartitionedConsumer{
+		cfg:     config.Kafka{Brokers: []string{"127.0.0.1:1"}, TicksTopic: "ticks", DrainTimeout: time.Second},
+		log:     zap.NewNop(),
+		ckpt:    st,
+		closing: make(chan struct{}),
+	}
+	return c, func(acks *ackTracker) (func(int), <-chan map[int]int64) {
+		commits := make(chan map[int]int64, 1)
+		return func(p int) { commits <- acks.committableFor(p) }, commits
+	}
+}
+
+func TestDeliverKeepsPartitionOrder(t *testing.T) {
+	acks := newAckTracker()
+	var batch []kafka.Message
+	for off := int64(40); off < 45; off++ {
+		v := []byte(`{"symbol":"AAPL","price":1}`)
+		if off == 42 {
+			v = []byte("{")
+		}
+		batch = append(batch, kafka.Message{Partition: 2, Offset: off, Value: v})
+	}
+	msgs := decodeBatch(batch, acks, zap.NewNop())
+
+	out := make(chan events.TickMsg, len(msgs))
+	c, _ := newPartitioned(noState{})
+	require.True(t, c.deliver(context.Background(), msgs, acks, out))
+	close(out)
+	var got []events.TickMsg
+	for m := range out {
+		got = append(got, m)
+	}
+	require.Len(t, got, 4)
+	for i, off := range []int64{40, 41, 43, 44} {
+		assert.Equal(t, off, got[i].Kafka.Offset)
+	}
+
+	got[2].Done()
+	got[3].Done()
+	assert.Empty(t, acks.committable(), "offset 40 still in flight")
+	got[0].Done()
+	assert.Equal(t, map[int]int64{2: 41}, acks.committable())
+	got[1].Done()
+	assert.Equal(t, map[int]int64{2: 45}, acks.committable(), "undecodable 42 does not hold the commit back")
+}
+
+func TestDeliverStopsOnCancel(t *testing.T) {
+	acks := newAckTracker()
+	msgs := decodeBatch([]kafka.Message{
+		{Offset: 1, Value: []byte(`{"symbol":"AAPL","price":1}`)},
+		{Offset: 2, Value: []byte(`{"symbol":"AAPL","price":2}`)},
+	}, acks, zap.NewNop())
+	require.Equal(t, 2, acks.inflight(0))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c, _ := newPartitioned(noState{})
+	assert.False(t, c.deliver(ctx, msgs, acks, make(chan events.TickMsg)))
+	assert.Equal(t, 0, acks.inflight(0), "undelivered messages are fetched again, not tracked")
+}
+
+func TestRevokedPartitionDrainsBeforeCommit(t *testing.T) {
+	st := &releases{}
+	c, commits := newPartitioned(st)
+	acks := newAckTracker()
+	acks.deliver(0, 7)
+	done := acks.onDone(0, 7)
+	commit, got := commits(acks)
+
+	genCtx, cancel := context.WithCancel(context.Background())
+	cancel() // rebalanced
+	c.fetching.Add(1)
+	go c.consumePartition(context.Background(), genCtx, kafka.PartitionAssignment{ID: 0, Offset: 7}, acks, commit, nil)
+
+	c.fetching.Wait()
+	select {
+	case <-got:
+		t.Fatal("committed with a message in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+	done()
+	select {
+	case offs := <-got:
+		assert.Equal(t, map[int]int64{0: 8}, offs)
+	case <-time.After(time.Second):
+		t.Fatal("no commit after the partition drained")
+	}
+	assert.Eventually(t, func() bool { return len(st.get()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []int{0}, st.get())
+}
+
+func TestShutdownHoldsFinalCommitUntilClose(t *testing.T) {
+	st := &releases{}
+	c, commits := newPartitioned(st)
+	acks := newAckTracker()
+	acks.deliver(3, 11)
+	acks.ack(3, 11)
+	commit, got := commits(acks)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // shutting down
+	c.fetching.Add(1)
+	go c.consumePartition(ctx, context.Background(), kafka.PartitionAssignment{ID: 3, Offset: 11}, acks, commit, nil)
+
+	c.fetching.Wait()
+	select {
+	case <-got:
+		t.Fatal("committed before Close")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(c.closing)
+	select {
+	case offs := <-got:
+		assert.Equal(t, map[int]int64{3: 12}, offs)
+	case <-time.After(time.Second):
+		t.Fatal("no commit after Close")
+	}
+	assert.Eventually(t, func() bool { return len(st.get()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []int{3}, st.get())
+}
@@ -0,0 +1,56 @@
+package consumer
+
+import (
+	"context"
+	"sync"
+)
+
+// gate pauses fetching. Consumers embed it to get Pause, Resume and Paused;
+// their fetch loops call wait before every fetch. Group membership and
+// heartbeats continue while paused, so no rebalance is triggered.
+type gate struct {
+	mu      sync.Mutex
+	resumed chan struct{} // nil while running; closed by Resume
+}
+
+// Pause stops fetching after the messages already fetched are delivered.
+func (g *gate) Pause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resumed == nil {
+		g.resumed = make(chan struct{})
+	}
+}
+
+// Resume restarts fetching.
+func (g *gate) Resume() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resumed != nil {
+		close(g.resumed)
+		g.resumed = nil
+	}
+}
+
+// Paused reports whether fetching is paused.
+func (g *gate) Paused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.resumed != nil
+}
+
+// wait blocks while paused; it returns ctx.Err() if ctx ends first.
+func (g *gate) wait(ctx context.Context) error {
+	g.mu.Lock()
+	ch := g.resumed
+	g.mu.Unlock()
+	if ch == nil {
+		return nil
+	}
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,36 @@
+package consumer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGate(t *testing.T) {
+	var g gate
+	ctx := context.Background()
+	require.NoError(t, g.wait(ctx), "running")
+
+	g.Pause()
+	g.Pause()
+	assert.True(t, g.Paused())
+	waited := make(chan error)
+	go func() { waited <- g.wait(ctx) }()
+	select {
+	case <-waited:
+		t.Fatal("wait returned while paused")
+	case <-time.After(20 * time.Millisecond):
+	}
+	g.Resume()
+	require.NoError(t, <-waited)
+	assert.False(t, g.Paused())
+	g.Resume()
+
+	g.Pause()
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, g.wait(cctx), context.Canceled)
+}
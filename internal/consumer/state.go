@@ -0,0 +1,51 @@
+package consumer
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// StateSync ties processor state to the consumer's offsets. State is
+// restored before any message of a partition is delivered, checkpointed
+// before a partition's final commit, and offsets are only committed once
+// the state covering them is saved. state.Manager implements it.
+type StateSync interface {
+	// Restore loads the state of partitions; nil means every partition.
+	Restore(ctx context.Context, partitions []int) error
+	// Release drops the state of partitions that are no longer assigned.
+	Release(partitions []int)
+	// Checkpoint saves the state, reporting offsets as processed first.
+	Checkpoint(ctx context.Context, offsets map[int]int64) error
+	// Committable reports offsets as processed and returns the offsets
+	// that may be committed now.
+	Committable(offsets map[int]int64) map[int]int64
+}
+
+// noState commits offsets as soon as messages are done.
+type noState struct{}
+
+func (noState) Restore(context.Context, []int) error            { return nil }
+func (noState) Release([]int)                                   {}
+func (noState) Checkpoint(context.Context, map[int]int64) error { return nil }
+func (noState) Committable(offsets map[int]int64) map[int]int64 { return offsets }
+
+// restoreState retries s.Restore with backoff until it succeeds or ctx ends;
+// messages must not be processed against missing state.
+func restoreState(ctx context.Context, s StateSync, partitions []int, log *zap.Logger) error {
+	backoff := 200 * time.Millisecond
+	for {
+		err := s.Restore(ctx, partitions)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		log.Warn("state restore failed, retrying", zap.Ints("partitions", partitions), zap.Duration("retry_in", backoff), zap.Error(err))
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff = min(2*backoff, 30*time.Second)
+	}
+}
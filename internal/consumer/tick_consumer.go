@@ -4,28 +4,85 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/jonandereg/streamforge/internal/broker"
 	"github.com/jonandereg/streamforge/internal/config"
 	"github.com/jonandereg/streamforge/internal/events"
+	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
 	"github.com/jonandereg/streamforge/internal/model"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+const tracerName = "github.com/jonandereg/streamforge/internal/consumer"
+
+// Runner consumes ticks from Kafka and forwards them to out.
+//
+// Run stops fetching when ctx is cancelled and returns once it will no longer
+// send on out. Offsets are committed only for messages whose Done has been
+// called; Close makes the final commit and releases the connection, so it
+// should be called after the pipeline has drained.
+type Runner interface {
+	Run(ctx context.Context, out chan<- events.TickMsg) error
+	Close(ctx context.Context) error
+	// Check reports whether the consumer is connected and, where it can
+	// tell, a member of the group. It is registered as a readiness check.
+	Check(ctx context.Context) error
+	// Pause and Resume stop and restart fetching without leaving the group.
+	Pause()
+	Resume()
+	Paused() bool
+	// SetStateSync ties processor state to the committed offsets. It must
+	// be called before Run.
+	SetStateSync(s StateSync)
+}
+
+// New returns the consumer implementation selected by cfg.ConsumerMode.
+// sec enables TLS/SASL; nil connects in plaintext.
+func New(cfg config.Kafka, sec *broker.Security, log *zap.Logger) (Runner, error) {
+	switch cfg.ConsumerMode {
+	case "", "single":
+		return NewTickConsumer(cfg, sec, log)
+	case "partitioned":
+		return NewPartitionedConsumer(cfg, sec, log)
+	default:
+		return nil, fmt.Errorf("unknown kafka consumer mode %q", cfg.ConsumerMode)
+	}
+}
+
 type TickConsumer struct {
+	gate
+
 	cfg    config.Kafka
 	reader *kafka.Reader
 	log    *zap.Logger
+	acks   *ackTracker
+	state  *groupState
+	ckpt   StateSync
+
+	stopCommits chan struct{}
+	commitsDone chan struct{}
+	closeOnce   sync.Once
 }
 
-func NewTickConsumer(cfg config.Kafka, log *zap.Logger) (*TickConsumer, error) {
+func NewTickConsumer(cfg config.Kafka, sec *broker.Security, log *zap.Logger) (*TickConsumer, error) {
 	if len(cfg.Brokers) == 0 || cfg.Brokers[0] == "" {
 		return nil, errors.New("kafka brokers missing")
 	}
 	if cfg.GroupID == "" || cfg.TicksTopic == "" {
 		return nil, errors.New("kafka group or topic missing")
 	}
+	if cfg.CommitInterval <= 0 {
+		cfg.CommitInterval = time.Second
+	}
 	r := kafka.NewReader(kafka.ReaderConfig{
 		Brokers:     cfg.Brokers,
 		GroupID:     cfg.GroupID,
@@ -33,14 +90,22 @@ func NewTickConsumer(cfg config.Kafka, log *zap.Logger) (*TickConsumer, error) {
 		MinBytes:    cfg.MinBytes,
 		MaxBytes:    cfg.MaxBytes,
 		MaxWait:     cfg.MaxWait,
-		StartOffset: kafka.FirstOffset,
+		StartOffset: startOffset(cfg),
+		Dialer:      sec.Dialer(),
 	})
 
-	return &TickConsumer{
-		cfg:    cfg,
-		reader: r,
-		log:    log.Named("tick-consumer"),
-	}, nil
+	c := &TickConsumer{
+		cfg:         cfg,
+		reader:      r,
+		log:         log.Named("tick-consumer"),
+		acks:        newAckTracker(),
+		state:       newGroupState(),
+		ckpt:        noState{},
+		stopCommits: make(chan struct{}),
+		commitsDone: make(chan struct{}),
+	}
+	go c.commitLoop()
+	return c, nil
 }
 
 func (c *TickConsumer) Run(ctx context.Context, out chan<- events.TickMsg) error {
@@ -49,22 +114,28 @@ func (c *TickConsumer) Run(ctx context.Context, out chan<- events.TickMsg) error
 		zap.String("group", c.cfg.GroupID),
 		zap.String("topic", c.cfg.TicksTopic),
 	)
-	defer func() {
-		if err := c.reader.Close(); err != nil {
-			c.log.Warn("reader close error", zap.Error(err))
-		}
-	}()
+	// The reader does not expose its assignment, so every partition's state
+	// is restored.
+	if err := restoreState(ctx, c.ckpt, nil, c.log); err != nil {
+		c.log.Info("context closed before state was restored")
+		return nil
+	}
 
 	backoff := 200 * time.Millisecond
 
 	for {
+		if err := c.gate.wait(ctx); err != nil {
+			c.log.Info("context closed, fetching stopped")
+			return nil
+		}
 		m, err := c.reader.FetchMessage(ctx)
 		if err != nil {
 			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
-				c.log.Info("context closed, exiting")
+				c.log.Info("context closed, fetching stopped")
 				return nil
 			}
 			c.log.Warn("fetch error", zap.Error(err))
+			c.state.fail(err)
 			select {
 			case <-time.After(backoff):
 			case <-ctx.Done():
@@ -72,36 +143,128 @@ func (c *TickConsumer) Run(ctx context.Context, out chan<- events.TickMsg) error
 			}
 			continue
 		}
+		c.state.ok()
+		sfmetrics.ProcessorConsumedTotal.Inc()
+		sfmetrics.ProcessorConsumerLag.WithLabelValues(strconv.Itoa(m.Partition)).Set(float64(m.HighWaterMark - m.Offset - 1))
+
 		var t model.Tick
 		if err := json.Unmarshal(m.Value, &t); err != nil {
+			sfmetrics.ProcessorFailedTotal.WithLabelValues("decode").Inc()
 			c.log.Warn("json decode error,skipping",
 				zap.Int("partition", m.Partition),
 				zap.Int64("offset", m.Offset),
 				zap.Error(err),
 			)
-			_ = c.reader.CommitMessages(ctx, m)
+			c.acks.skip(m.Partition, m.Offset)
 			continue
 
 		}
+		sfmetrics.ProcessorDecodedTotal.Inc()
 
-		msg := events.TickMsg{
-			Tick: t,
-			Kafka: events.KafkaMeta{
-				Partition: m.Partition,
-				Offset:    m.Offset,
-				Key:       m.Key,
-				Time:      m.Time,
-			},
-		}
+		msg := newTickMsg(m, t)
+		msg.OnDone = c.acks.onDone(m.Partition, m.Offset)
+		c.acks.deliver(m.Partition, m.Offset)
 		select {
 		case out <- msg:
-			if err := c.reader.CommitMessages(ctx, m); err != nil {
-				c.log.Warn("commit error", zap.Error(err))
-			}
 		case <-ctx.Done():
+			c.acks.cancel(m.Partition, m.Offset)
 			return nil
 
 		}
 	}
 
 }
+
+// Close stops the periodic commits, commits acknowledged offsets and closes
+// the reader. It is safe to call more than once.
+func (c *TickConsumer) Close(ctx context.Context) error {
+	c.closeOnce.Do(func() { close(c.stopCommits) })
+	select {
+	case <-c.commitsDone:
+	case <-ctx.Done():
+	}
+	if n := c.acks.inflight(allPartitions); n > 0 {
+		c.log.Warn("closing with messages still in flight; they will be redelivered", zap.Int("inflight", n))
+	}
+	offsets := c.acks.committable()
+	if err := c.ckpt.Checkpoint(ctx, offsets); err != nil {
+		c.log.Warn("final state checkpoint failed; offsets since the last one are not committed", zap.Error(err))
+	}
+	c.commit(ctx, offsets)
+	return c.reader.Close()
+}
+
+// SetStateSync implements Runner.
+func (c *TickConsumer) SetStateSync(s StateSync) { c.ckpt = s }
+
+func (c *TickConsumer) commitLoop() {
+	defer close(c.commitsDone)
+	t := time.NewTicker(c.cfg.CommitInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-c.stopCommits:
+			return
+		case <-t.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			c.commit(ctx, c.acks.committable())
+			cancel()
+		}
+	}
+}
+
+// commit commits the given next-offset per partition. CommitMessages commits
+// m.Offset+1, hence the -1.
+func (c *TickConsumer) commit(ctx context.Context, offsets map[int]int64) {
+	offsets = c.ckpt.Committable(offsets)
+	if len(offsets) == 0 {
+		return
+	}
+	msgs := make([]kafka.Message, 0, len(offsets))
+	for p, next := range offsets {
+		msgs = append(msgs, kafka.Message{Topic: c.cfg.TicksTopic, Partition: p, Offset: next - 1})
+	}
+	start := time.Now()
+	err := c.reader.CommitMessages(ctx, msgs...)
+	sfmetrics.ProcessorCommitLatencySeconds.Observe(time.Since(start).Seconds())
+	if err != nil {
+		sfmetrics.ProcessorCommitTotal.WithLabelValues("failure").Inc()
+		sfmetrics.ProcessorFailedTotal.WithLabelValues("commit").Inc()
+		c.log.Warn("commit error", zap.Error(err))
+		return
+	}
+	sfmetrics.ProcessorCommitTotal.WithLabelValues("success").Inc()
+}
+
+// newTickMsg wraps a decoded tick with its Kafka metadata and records a consume
+// span parented by the trace context the producer injected into the headers.
+func newTickMsg(m kafka.Message, t model.Tick) events.TickMsg {
+	parent := otel.GetTextMapPropagator().Extract(context.Background(), broker.HeaderCarrier{Headers: &m.Headers})
+	_, span := otel.Tracer(tracerName).Start(parent, "kafka.consume",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.source.name", m.Topic),
+			attribute.Int("messaging.kafka.partition", m.Partition),
+			attribute.Int64("messaging.kafka.offset", m.Offset),
+			attribute.String("symbol", t.Symbol),
+		),
+	)
+	span.End()
+
+	var flags []string
+	if f := (broker.HeaderCarrier{Headers: &m.Headers}).Get(broker.HeaderQualityFlags); f != "" {
+		flags = strings.Split(f, ",")
+	}
+	return events.TickMsg{
+		Tick: t,
+		Kafka: events.KafkaMeta{
+			Partition: m.Partition,
+			Offset:    m.Offset,
+			Key:       m.Key,
+			Time:      m.Time,
+		},
+		SpanContext: span.SpanContext(),
+		Flags:       flags,
+	}
+}
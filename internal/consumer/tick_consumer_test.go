@@ -0,0 +1,21 @@
+package consumer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTickConsumerCloseTwice(t *testing.T) {
+	c, err := NewTickConsumer(config.Kafka{Brokers: []string{"127.0.0.1:1"}, GroupID: "g", TicksTopic: "ticks"}, nil, zap.NewNop())
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = c.Close(ctx)
+	assert.NotPanics(t, func() { _ = c.Close(ctx) })
+}
@@ -0,0 +1,175 @@
+// Package corpactions holds corporate actions — stock splits and cash
+// dividends — and the factors that adjust historical prices and volumes for
+// them. Actions live in the corporate_actions table and are loaded with
+// `streamforge corpactions load`. Stored ticks are never rewritten; the
+// adjustment is applied to query results.
+package corpactions
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the type of a corporate action.
+type Kind string
+
+// Supported kinds.
+const (
+	KindSplit    Kind = "split"
+	KindDividend Kind = "dividend"
+)
+
+// Action is one corporate action of a symbol.
+type Action struct {
+	Symbol string
+	// ExDate is the first day the symbol trades without the action's
+	// entitlement, as a date at UTC midnight.
+	ExDate time.Time
+	Kind   Kind
+	// Ratio is the number of new shares per old share of a split, e.g. 4
+	// for a 4-for-1 split and 0.1 for a 1-for-10 reverse split.
+	Ratio float64
+	// Amount is the cash dividend per share, in the trading currency.
+	Amount float64
+}
+
+// Validate checks the fields used by Kind.
+func (a Action) Validate() error {
+	if a.Symbol == "" {
+		return errors.New("symbol is required")
+	}
+	if a.ExDate.IsZero() {
+		return errors.New("ex_date is required")
+	}
+	switch a.Kind {
+	case KindSplit:
+		if !(a.Ratio > 0) || a.Ratio == 1 {
+			return fmt.Errorf("split ratio must be > 0 and not 1, got %v", a.Ratio)
+		}
+	case KindDividend:
+		if !(a.Amount > 0) {
+			return fmt.Errorf("dividend amount must be > 0, got %v", a.Amount)
+		}
+	default:
+		return fmt.Errorf("unknown kind %q", a.Kind)
+	}
+	return nil
+}
+
+// ParseCSV reads actions from CSV with the header symbol,ex_date,kind,value:
+// value is the split ratio or the dividend amount, ex_date is YYYY-MM-DD.
+// Symbols are upper-cased. Every invalid row is reported.
+func ParseCSV(r io.Reader) ([]Action, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 || strings.Join(rows[0], ",") != "symbol,ex_date,kind,value" {
+		return nil, errors.New("header must be symbol,ex_date,kind,value")
+	}
+
+	var out []Action
+	var errs []error
+	for i, row := range rows[1:] {
+		line := i + 2
+		a := Action{Symbol: strings.ToUpper(strings.TrimSpace(row[0])), Kind: Kind(strings.TrimSpace(row[2]))}
+		a.ExDate, err = time.Parse(time.DateOnly, strings.TrimSpace(row[1]))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: ex_date: %w", line, err))
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(row[3]), 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: value: %w", line, err))
+			continue
+		}
+		if a.Kind == KindSplit {
+			a.Ratio = v
+		} else {
+			a.Ratio, a.Amount = 1, v
+		}
+		if err := a.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", line, err))
+			continue
+		}
+		out = append(out, a)
+	}
+	return out, errors.Join(errs...)
+}
+
+// Mode selects which actions are adjusted for.
+type Mode int
+
+// Modes, from raw prices to fully adjusted.
+const (
+	None Mode = iota
+	Splits
+	SplitsAndDividends
+)
+
+// Adjuster holds the adjustment factors of one symbol's actions. Prices
+// before a split are divided by its ratio and volumes multiplied by it;
+// prices before a dividend are multiplied by 1 - amount/close, where close
+// is the last raw price before the ex-date. Factors compound, so prices
+// become comparable with today's.
+type Adjuster struct {
+	steps []step // by effective time; factors are cumulative from the end
+}
+
+type step struct {
+	at            time.Time
+	price, volume float64
+}
+
+// NewAdjuster computes the factors of actions for mode. An action takes
+// effect at midnight of its ex-date in loc, the time zone of the symbol's
+// market. closeBefore returns the last raw price before a time; dividends
+// without one, or not below it, are skipped.
+func NewAdjuster(actions []Action, mode Mode, loc *time.Location, closeBefore func(time.Time) (float64, bool, error)) (*Adjuster, error) {
+	adj := &Adjuster{}
+	for _, a := range actions {
+		at := time.Date(a.ExDate.Year(), a.ExDate.Month(), a.ExDate.Day(), 0, 0, 0, 0, loc)
+		s := step{at: at, price: 1, volume: 1}
+		switch {
+		case a.Kind == KindSplit && mode >= Splits:
+			s.price, s.volume = 1/a.Ratio, a.Ratio
+		case a.Kind == KindDividend && mode >= SplitsAndDividends:
+			c, ok, err := closeBefore(at)
+			if err != nil {
+				return nil, fmt.Errorf("close before %s dividend of %s: %w", a.ExDate.Format(time.DateOnly), a.Symbol, err)
+			}
+			if !ok || c <= a.Amount {
+				continue
+			}
+			s.price = 1 - a.Amount/c
+		default:
+			continue
+		}
+		adj.steps = append(adj.steps, s)
+	}
+	sort.SliceStable(adj.steps, func(i, j int) bool { return adj.steps[i].at.Before(adj.steps[j].at) })
+	for i := len(adj.steps) - 2; i >= 0; i-- {
+		adj.steps[i].price *= adj.steps[i+1].price
+		adj.steps[i].volume *= adj.steps[i+1].volume
+	}
+	return adj, nil
+}
+
+// Factors returns the price and volume factors for a bar starting at t: the
+// product of the factors of every action effective after t.
+func (a *Adjuster) Factors(t time.Time) (price, volume float64) {
+	i := sort.Search(len(a.steps), func(i int) bool { return a.steps[i].at.After(t) })
+	if i == len(a.steps) {
+		return 1, 1
+	}
+	return a.steps[i].price, a.steps[i].volume
+}
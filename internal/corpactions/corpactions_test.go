@@ -0,0 +1,60 @@
+package corpactions
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExample(t *testing.T) {
+	f, err := os.Open("../../configs/corporate_actions.example.csv")
+	require.NoError(t, err)
+	defer f.Close()
+	actions, err := ParseCSV(f)
+	require.NoError(t, err)
+	require.Len(t, actions, 4)
+	assert.Equal(t, Action{Symbol: "AAPL", ExDate: time.Date(2020, 8, 31, 0, 0, 0, 0, time.UTC), Kind: KindSplit, Ratio: 4}, actions[0])
+	assert.Equal(t, 0.25, actions[2].Amount)
+}
+
+func TestParseReportsEveryBadRow(t *testing.T) {
+	_, err := ParseCSV(strings.NewReader("symbol,ex_date,kind,value\nAAPL,2020-31-08,split,4\nAAPL,2020-08-31,split,1\nAAPL,2020-08-31,merger,1\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2: ex_date")
+	assert.Contains(t, err.Error(), "line 3: split ratio")
+	assert.Contains(t, err.Error(), `line 4: unknown kind "merger"`)
+}
+
+func TestAdjuster(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	date := func(m time.Month, d int) time.Time { return time.Date(2025, m, d, 0, 0, 0, 0, time.UTC) }
+	actions := []Action{
+		{Symbol: "X", ExDate: date(6, 2), Kind: KindDividend, Amount: 2},
+		{Symbol: "X", ExDate: date(3, 3), Kind: KindSplit, Ratio: 2},
+	}
+	closeBefore := func(at time.Time) (float64, bool, error) {
+		assert.Equal(t, time.Date(2025, 6, 2, 4, 0, 0, 0, time.UTC), at.UTC(), "midnight in New York")
+		return 50, true, nil
+	}
+
+	adj, err := NewAdjuster(actions, SplitsAndDividends, ny, closeBefore)
+	require.NoError(t, err)
+	p, v := adj.Factors(time.Date(2025, 2, 28, 15, 0, 0, 0, time.UTC))
+	assert.InDelta(t, 0.5*0.96, p, 1e-12)
+	assert.Equal(t, 2.0, v)
+	p, v = adj.Factors(time.Date(2025, 6, 2, 3, 0, 0, 0, time.UTC)) // still June 1st in New York
+	assert.InDelta(t, 0.96, p, 1e-12)
+	assert.Equal(t, 1.0, v)
+	p, _ = adj.Factors(time.Date(2025, 6, 2, 13, 30, 0, 0, time.UTC))
+	assert.Equal(t, 1.0, p)
+
+	adj, err = NewAdjuster(actions, Splits, ny, nil)
+	require.NoError(t, err)
+	p, _ = adj.Factors(date(1, 1))
+	assert.Equal(t, 0.5, p, "dividends ignored")
+}
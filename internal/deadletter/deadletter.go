@@ -0,0 +1,92 @@
+// Package deadletter records messages the pipeline could not process so they
+// can be inspected and replayed later.
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/broker"
+	"github.com/jonandereg/streamforge/internal/events"
+	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+)
+
+// Sink receives messages that failed processing together with the reason.
+type Sink interface {
+	Send(ctx context.Context, msg events.TickMsg, reason string, cause error) error
+}
+
+// LogSink writes dead letters to the log only. It is the fallback when no
+// dead-letter topic is configured.
+type LogSink struct {
+	Log *zap.Logger
+}
+
+// Send logs msg at error level.
+func (s *LogSink) Send(_ context.Context, msg events.TickMsg, reason string, cause error) error {
+	sfmetrics.ProcessorDeadLetterTotal.WithLabelValues(reason).Inc()
+	if s.Log != nil {
+		s.Log.Error("dead letter",
+			zap.String("reason", reason),
+			zap.String("symbol", msg.Tick.Symbol),
+			zap.Int("partition", msg.Kafka.Partition),
+			zap.Int64("offset", msg.Kafka.Offset),
+			zap.Error(cause),
+		)
+	}
+	return nil
+}
+
+// KafkaSink publishes dead letters to a Kafka topic, keeping the original tick
+// as the value and the failure details in headers.
+type KafkaSink struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaSink creates a sink writing to topic.
+func NewKafkaSink(brokers []string, topic string, sec *broker.Security) *KafkaSink {
+	return &KafkaSink{writer: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Transport:    sec.Transport(),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: 10 * time.Millisecond,
+	}}
+}
+
+// Send publishes msg to the dead-letter topic.
+func (s *KafkaSink) Send(ctx context.Context, msg events.TickMsg, reason string, cause error) error {
+	sfmetrics.ProcessorDeadLetterTotal.WithLabelValues(reason).Inc()
+	val, err := json.Marshal(msg.Tick)
+	if err != nil {
+		return err
+	}
+	errText := ""
+	if cause != nil {
+		errText = cause.Error()
+	}
+	m := kafka.Message{
+		Key:   msg.Kafka.Key,
+		Value: val,
+		Headers: []kafka.Header{
+			{Key: "content-type", Value: []byte("application/json")},
+			{Key: "dlq_reason", Value: []byte(reason)},
+			{Key: "dlq_error", Value: []byte(errText)},
+			{Key: "src_partition", Value: []byte(strconv.Itoa(msg.Kafka.Partition))},
+			{Key: "src_offset", Value: []byte(strconv.FormatInt(msg.Kafka.Offset, 10))},
+		},
+	}
+	otel.GetTextMapPropagator().Inject(msg.TraceContext(ctx), broker.HeaderCarrier{Headers: &m.Headers})
+	return s.writer.WriteMessages(ctx, m)
+}
+
+// Close flushes and closes the underlying writer.
+func (s *KafkaSink) Close() error {
+	return s.writer.Close()
+}
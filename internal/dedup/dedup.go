@@ -0,0 +1,151 @@
+// Package dedup suppresses duplicate ticks, such as trades a provider
+// replays after a reconnect or the same trade reported by overlapping
+// sources. Ticks are compared by a fingerprint of configurable fields within
+// a bounded window per symbol.
+package dedup
+
+import (
+	"container/list"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/events"
+	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
+	"github.com/jonandereg/streamforge/internal/model"
+	"github.com/jonandereg/streamforge/internal/worker"
+)
+
+// Fields that may be part of a fingerprint.
+var Fields = []string{"symbol", "ts", "price", "size", "exchange", "src_id"}
+
+// Deduper remembers the fingerprints of recent ticks per symbol. An entry
+// is forgotten once its tick is more than the window older than the newest
+// tick of the symbol, or when the symbol holds more than the per-symbol
+// maximum (oldest first). It is safe for concurrent use.
+type Deduper struct {
+	stage  string // metric label: "ingestor" or "processor"
+	fields []string
+	window time.Duration
+	max    int
+
+	mu      sync.Mutex
+	symbols map[string]*symbolSeen
+}
+
+type symbolSeen struct {
+	newest time.Time
+	keys   map[uint64]*list.Element
+	order  *list.List // of entry, oldest first
+}
+
+type entry struct {
+	key uint64
+	ts  time.Time
+}
+
+// New creates a Deduper for stage configured by cfg.
+func New(stage string, cfg config.Dedup) (*Deduper, error) {
+	for _, f := range cfg.Fields {
+		if !slices.Contains(Fields, f) {
+			return nil, fmt.Errorf("dedup.fields: unknown field %q", f)
+		}
+	}
+	sfmetrics.DedupCheckedTotal.WithLabelValues(stage).Add(0)
+	return &Deduper{
+		stage:   stage,
+		fields:  slices.Clone(cfg.Fields),
+		window:  cfg.Window,
+		max:     cfg.MaxPerSymbol,
+		symbols: make(map[string]*symbolSeen),
+	}, nil
+}
+
+// Duplicate records t and reports whether a tick with the same fingerprint
+// was seen within the window. Ticks older than the window cannot be judged
+// and are never reported as duplicates.
+func (d *Deduper) Duplicate(t model.Tick) bool {
+	sfmetrics.DedupCheckedTotal.WithLabelValues(d.stage).Inc()
+	key := d.fingerprint(t)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := d.symbols[t.Symbol]
+	if s == nil {
+		s = &symbolSeen{keys: make(map[uint64]*list.Element), order: list.New()}
+		d.symbols[t.Symbol] = s
+	}
+	if _, ok := s.keys[key]; ok {
+		sfmetrics.DedupSuppressedTotal.WithLabelValues(d.stage, t.SrcID).Inc()
+		return true
+	}
+	if t.Ts.After(s.newest) {
+		s.newest = t.Ts
+	}
+	if t.Ts.Before(s.newest.Add(-d.window)) {
+		return false
+	}
+	s.keys[key] = s.order.PushBack(entry{key: key, ts: t.Ts})
+	s.evict(s.newest.Add(-d.window), d.max)
+	return false
+}
+
+// evict drops entries older than cutoff and, beyond max, the oldest ones.
+// Insertion order approximates timestamp order, so a late entry may
+// outlive the window slightly until the ones ahead of it expire.
+func (s *symbolSeen) evict(cutoff time.Time, max int) {
+	for e := s.order.Front(); e != nil; e = s.order.Front() {
+		en := e.Value.(entry)
+		if !en.ts.Before(cutoff) && s.order.Len() <= max {
+			return
+		}
+		s.order.Remove(e)
+		delete(s.keys, en.key)
+	}
+}
+
+// Process implements worker.Processor: duplicates end the chain with
+// worker.ErrSkip.
+func (d *Deduper) Process(_ context.Context, msg events.TickMsg) error {
+	if d.Duplicate(msg.Tick) {
+		return fmt.Errorf("%w: duplicate tick", worker.ErrSkip)
+	}
+	return nil
+}
+
+// fingerprint hashes the configured fields of t with FNV-1a.
+func (d *Deduper) fingerprint(t model.Tick) uint64 {
+	h := fnv.New64a()
+	var b [8]byte
+	num := func(v uint64) {
+		binary.LittleEndian.PutUint64(b[:], v)
+		_, _ = h.Write(b[:])
+	}
+	str := func(s string) {
+		_, _ = h.Write([]byte(s))
+		_, _ = h.Write([]byte{0})
+	}
+	for _, f := range d.fields {
+		switch f {
+		case "symbol":
+			str(t.Symbol)
+		case "ts":
+			num(uint64(t.Ts.UnixNano())) //nolint:gosec // bit pattern only
+		case "price":
+			num(math.Float64bits(t.Price))
+		case "size":
+			num(math.Float64bits(t.Size))
+		case "exchange":
+			str(t.Exchange)
+		case "src_id":
+			str(t.SrcID)
+		}
+	}
+	return h.Sum64()
+}
@@ -0,0 +1,69 @@
+package dedup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/events"
+	"github.com/jonandereg/streamforge/internal/model"
+	"github.com/jonandereg/streamforge/internal/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var t0 = time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+
+func tick(sec int, price float64, src string) model.Tick {
+	return model.Tick{Symbol: "AAPL", Ts: t0.Add(time.Duration(sec) * time.Second), Price: price, Size: 1, SrcID: src}
+}
+
+func newDeduper(t *testing.T, mutate func(*config.Dedup)) *Deduper {
+	t.Helper()
+	cfg := config.Defaults().Dedup
+	if mutate != nil {
+		mutate(&cfg)
+	}
+	d, err := New("test", cfg)
+	require.NoError(t, err)
+	return d
+}
+
+func TestDuplicateAcrossSources(t *testing.T) {
+	d := newDeduper(t, nil)
+	assert.False(t, d.Duplicate(tick(0, 100, "finnhub")))
+	assert.True(t, d.Duplicate(tick(0, 100, "finnhub")), "replayed after reconnect")
+	assert.True(t, d.Duplicate(tick(0, 100, "other")), "src_id is not part of the default fingerprint")
+	assert.False(t, d.Duplicate(tick(0, 100.5, "finnhub")), "different price")
+
+	d = newDeduper(t, func(c *config.Dedup) { c.Fields = append(c.Fields, "src_id") })
+	assert.False(t, d.Duplicate(tick(0, 100, "finnhub")))
+	assert.False(t, d.Duplicate(tick(0, 100, "other")))
+}
+
+func TestWindowAndCapacity(t *testing.T) {
+	d := newDeduper(t, func(c *config.Dedup) { c.Window = 10 * time.Second })
+	assert.False(t, d.Duplicate(tick(0, 100, "a")))
+	assert.False(t, d.Duplicate(tick(30, 101, "a")))
+	assert.False(t, d.Duplicate(tick(0, 100, "a")), "outside the window")
+
+	d = newDeduper(t, func(c *config.Dedup) { c.MaxPerSymbol = 2 })
+	for i := range 3 {
+		assert.False(t, d.Duplicate(tick(i, 100, "a")))
+	}
+	assert.True(t, d.Duplicate(tick(2, 100, "a")))
+	assert.False(t, d.Duplicate(tick(0, 100, "a")), "evicted as oldest")
+}
+
+func TestProcessSkipsDuplicates(t *testing.T) {
+	d := newDeduper(t, nil)
+	msg := events.TickMsg{Tick: tick(0, 100, "a")}
+	require.NoError(t, d.Process(context.Background(), msg))
+	assert.ErrorIs(t, d.Process(context.Background(), msg), worker.ErrSkip)
+}
+
+func TestNewRejectsUnknownField(t *testing.T) {
+	_, err := New("test", config.Dedup{Fields: []string{"symbol", "venue"}})
+	assert.ErrorContains(t, err, `"venue"`)
+}
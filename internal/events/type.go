@@ -1,9 +1,11 @@
 package events
 
 import (
+	"context"
 	"time"
 
 	"github.com/jonandereg/streamforge/internal/model"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type KafkaMeta struct {
@@ -16,4 +18,30 @@ type KafkaMeta struct {
 type TickMsg struct {
 	Tick  model.Tick
 	Kafka KafkaMeta
+	// SpanContext is the most recent pipeline span for this message; each
+	// stage starts its span as a child of it and replaces it before handing off.
+	SpanContext trace.SpanContext
+	// Flags lists the data-quality rules the tick violated with action
+	// "flag", as carried in the dq_flags header.
+	Flags []string
+	// OnDone is set by the consumer and must be called exactly once when the
+	// message is finished with (processed, dropped or dead-lettered). Offsets
+	// are only committed up to the oldest message not yet done.
+	OnDone func()
+}
+
+// Done acknowledges the message to its consumer. It is safe to call on
+// messages without an OnDone callback.
+func (m TickMsg) Done() {
+	if m.OnDone != nil {
+		m.OnDone()
+	}
+}
+
+// TraceContext returns ctx with the message's span context set as parent for new spans.
+func (m TickMsg) TraceContext(ctx context.Context) context.Context {
+	if !m.SpanContext.IsValid() {
+		return ctx
+	}
+	return trace.ContextWithSpanContext(ctx, m.SpanContext)
 }
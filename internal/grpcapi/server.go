@@ -0,0 +1,81 @@
+package grpcapi
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	marketdatav1 "github.com/jonandereg/streamforge/api/marketdata/v1"
+	"github.com/jonandereg/streamforge/internal/config"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+)
+
+// NewServer returns a gRPC server serving svc. Spans are recorded with tp
+// through the otelgrpc stats handler; unary calls without a deadline get
+// cfg.RequestTimeout, and handler panics become INTERNAL errors.
+func NewServer(svc *Service, cfg config.GRPC, tp trace.TracerProvider, log *zap.Logger) *grpc.Server {
+	log = log.Named("grpc")
+	srv := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithTracerProvider(tp))),
+		grpc.ChainUnaryInterceptor(recoverUnary(log), deadlineUnary(cfg.RequestTimeout)),
+		grpc.ChainStreamInterceptor(recoverStream(log)),
+		grpc.MaxConcurrentStreams(cfg.MaxStreams),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    30 * time.Second,
+			Timeout: 10 * time.Second,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             10 * time.Second,
+			PermitWithoutStream: true,
+		}),
+	)
+	marketdatav1.RegisterMarketDataServer(srv, svc)
+	return srv
+}
+
+// deadlineUnary bounds unary calls that arrive without a client deadline.
+func deadlineUnary(d time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if _, ok := ctx.Deadline(); !ok && d > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
+		return handler(ctx, req)
+	}
+}
+
+func recoverUnary(log *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer recovered(log, info.FullMethod, &err)
+		return handler(ctx, req)
+	}
+}
+
+func recoverStream(log *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer recovered(log, info.FullMethod, &err)
+		return handler(srv, ss)
+	}
+}
+
+// recovered converts a panic into an INTERNAL status and logs it. It must
+// be deferred directly.
+func recovered(log *zap.Logger, method string, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	log.Error("grpc handler panicked",
+		zap.String("method", method),
+		zap.Any("panic", r),
+		zap.ByteString("stack", debug.Stack()),
+	)
+	*err = status.Error(codes.Internal, "internal error")
+}
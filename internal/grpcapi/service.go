@@ -0,0 +1,413 @@
+// Package grpcapi implements the MarketData gRPC service on top of the
+// TimescaleDB store (history) and the stream hub (live ticks).
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	marketdatav1 "github.com/jonandereg/streamforge/api/marketdata/v1"
+	"github.com/jonandereg/streamforge/internal/calendar"
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/corpactions"
+	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
+	"github.com/jonandereg/streamforge/internal/model"
+	"github.com/jonandereg/streamforge/internal/processing/window"
+	"github.com/jonandereg/streamforge/internal/store"
+	"github.com/jonandereg/streamforge/internal/stream"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// History is the subset of *store.TickStore the service needs.
+type History interface {
+	Ticks(ctx context.Context, symbols []string, from, to time.Time, fn func(model.Tick) error) error
+	Latest(ctx context.Context, symbols []string) ([]model.Tick, error)
+	Bars(ctx context.Context, symbol string, interval time.Duration, origin, from, to time.Time, limit int) ([]store.Bar, error)
+	CorporateActions(ctx context.Context, symbol string, since time.Time) ([]corpactions.Action, error)
+	PriceBefore(ctx context.Context, symbol string, t time.Time) (float64, bool, error)
+}
+
+// Service implements marketdatav1.MarketDataServer.
+type Service struct {
+	marketdatav1.UnimplementedMarketDataServer
+
+	history   History // nil without a database
+	hub       *stream.Hub
+	calendars *calendar.Registry
+	cfg       config.GRPC
+	log       *zap.Logger
+}
+
+// NewService creates the service. history may be nil, in which case only
+// live streaming and in-memory latest ticks are available.
+func NewService(history History, hub *stream.Hub, cfg config.GRPC, log *zap.Logger) *Service {
+	return &Service{history: history, hub: hub, calendars: calendar.Default(), cfg: cfg, log: log.Named("grpc")}
+}
+
+// SetCalendars sets the market hours bars are aligned to.
+func (s *Service) SetCalendars(r *calendar.Registry) { s.calendars = r }
+
+// StreamTicks implements marketdatav1.MarketDataServer.
+//
+// The live subscription is opened before history is queried so nothing
+// published meanwhile is missed; live ticks are held, without limit, until
+// the replay is done, so a long replay cannot trip the slow-consumer cutoff.
+// History is read up to the moment of subscribing. Live ticks that were
+// also replayed are skipped so the seam has no duplicates.
+func (s *Service) StreamTicks(req *marketdatav1.StreamTicksRequest, srv marketdatav1.MarketData_StreamTicksServer) error {
+	symbols, err := normalize(req.GetSymbols())
+	if err != nil {
+		return err
+	}
+	ctx := srv.Context()
+
+	sub := s.hub.SubscribeHeld(symbols, s.cfg.StreamBuffer)
+	defer sub.Close()
+	sfmetrics.GRPCActiveStreams.Inc()
+	defer sfmetrics.GRPCActiveStreams.Dec()
+	now := time.Now()
+
+	seam := newSeam()
+	if req.GetFrom() != nil {
+		if s.history == nil {
+			return status.Error(codes.FailedPrecondition, "history is not available: no database configured")
+		}
+		err := s.history.Ticks(ctx, symbols, req.GetFrom().AsTime(), now, func(t model.Tick) error {
+			seam.add(t)
+			return srv.Send(toProto(t, false))
+		})
+		if err != nil {
+			return toStatus(ctx, err)
+		}
+	}
+	for _, t := range sub.Release() {
+		if seam.replayed(t) {
+			continue
+		}
+		if err := srv.Send(toProto(t, true)); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return toStatus(ctx, ctx.Err())
+		case t, ok := <-sub.C:
+			if !ok {
+				if errors.Is(sub.Err(), stream.ErrSlowConsumer) {
+					sfmetrics.GRPCSlowConsumerTotal.Inc()
+					s.log.Warn("stream client too slow, disconnecting", zap.Strings("symbols", symbols))
+					return status.Error(codes.ResourceExhausted, "client too slow: live buffer overflowed")
+				}
+				return status.Error(codes.Unavailable, "server shutting down")
+			}
+			if seam.replayed(t) {
+				continue
+			}
+			if err := srv.Send(toProto(t, true)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// GetLatest implements marketdatav1.MarketDataServer. Ticks seen live since
+// start take precedence over stored ones.
+func (s *Service) GetLatest(ctx context.Context, req *marketdatav1.GetLatestRequest) (*marketdatav1.GetLatestResponse, error) {
+	symbols, err := normalize(req.GetSymbols())
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]*marketdatav1.Tick, len(symbols))
+	var missing []string
+	for _, sym := range symbols {
+		if t, ok := s.hub.Latest(sym); ok {
+			latest[sym] = toProto(t, true)
+		} else {
+			missing = append(missing, sym)
+		}
+	}
+	if len(missing) > 0 && s.history != nil {
+		stored, err := s.history.Latest(ctx, missing)
+		if err != nil {
+			return nil, toStatus(ctx, err)
+		}
+		for _, t := range stored {
+			latest[t.Symbol] = toProto(t, false)
+		}
+	}
+
+	resp := &marketdatav1.GetLatestResponse{}
+	for _, sym := range symbols {
+		if t, ok := latest[sym]; ok {
+			resp.Ticks = append(resp.Ticks, t)
+		}
+	}
+	return resp, nil
+}
+
+// GetBars implements marketdatav1.MarketDataServer.
+func (s *Service) GetBars(ctx context.Context, req *marketdatav1.GetBarsRequest) (*marketdatav1.GetBarsResponse, error) {
+	if s.history == nil {
+		return nil, status.Error(codes.FailedPrecondition, "bars are not available: no database configured")
+	}
+	symbol := strings.ToUpper(strings.TrimSpace(req.GetSymbol()))
+	if symbol == "" {
+		return nil, status.Error(codes.InvalidArgument, "symbol is required")
+	}
+	interval := req.GetInterval().AsDuration()
+	if interval < time.Second || interval%time.Second != 0 {
+		return nil, status.Error(codes.InvalidArgument, "interval must be a whole number of seconds, at least 1s")
+	}
+	if req.GetFrom() == nil {
+		return nil, status.Error(codes.InvalidArgument, "from is required")
+	}
+	from, to := req.GetFrom().AsTime(), time.Now()
+	if req.GetTo() != nil {
+		to = req.GetTo().AsTime()
+	}
+	if !to.After(from) {
+		return nil, status.Error(codes.InvalidArgument, "to must be after from")
+	}
+	limit := s.cfg.MaxBars
+	if l := int(req.GetLimit()); l > 0 && l < limit {
+		limit = l
+	}
+
+	bars, err := s.bars(ctx, symbol, interval, from, to, limit)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	if err := s.adjust(ctx, symbol, bars, req.GetAdjustment()); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	resp := &marketdatav1.GetBarsResponse{Symbol: symbol, Bars: make([]*marketdatav1.Bar, 0, len(bars))}
+	for _, b := range bars {
+		resp.Bars = append(resp.Bars, &marketdatav1.Bar{
+			Start:  timestamppb.New(b.Start),
+			Open:   b.Open,
+			High:   b.High,
+			Low:    b.Low,
+			Close:  b.Close,
+			Volume: b.Volume,
+			Count:  uint64(max(b.Count, 0)), //nolint:gosec // non-negative by construction
+		})
+	}
+	return resp, nil
+}
+
+// bars aggregates per trading session of symbol's calendar: bars start at
+// the session open and the last one of a session ends at its close, so
+// nothing traded outside the sessions (or while closed) becomes a bar.
+// Bars of a day or longer instead hold the sessions opening within one
+// interval from local midnight (weeks from Monday), and start at the first
+// one's open. Markets that are always open use the default alignment.
+//
+// The ticks are read in one query, as bars of a step that divides the
+// interval and the offsets of every session open and close, which are then
+// merged into the windows above.
+func (s *Service) bars(ctx context.Context, symbol string, interval time.Duration, from, to time.Time, limit int) ([]store.Bar, error) {
+	cal := s.calendars.For(symbol)
+	if cal.IsAlwaysOpen() {
+		return s.history.Bars(ctx, symbol, interval, store.DefaultOrigin, from, to, limit)
+	}
+	sessions := cal.Sessions(from, to)
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+	// Sessions are clipped to [from, to); align to the real ones.
+	full := make([]calendar.Session, len(sessions))
+	for i, sess := range sessions {
+		full[i] = sess
+		if f, ok := cal.SessionAt(sess.Open); ok {
+			full[i] = f
+		}
+	}
+	origin := full[0].Open
+	daily := interval >= 24*time.Hour
+	days := window.Spec{Kind: window.Tumbling, Size: interval, Origin: time.Date(2000, 1, 3, 0, 0, 0, 0, cal.Location())}
+	step, trading := interval, time.Duration(0)
+	if daily {
+		step = 0 // whole sessions
+	}
+	for _, f := range full {
+		step = gcd(gcd(step, f.Open.Sub(origin)), f.Close.Sub(origin))
+		trading += f.Close.Sub(f.Open)
+	}
+	first, last := sessions[0].Open, sessions[len(sessions)-1].Close
+	// Enough steps for limit bars, plus those while closed.
+	steps := min(limit*int(interval/step)+int((last.Sub(first)-trading)/step), int(last.Sub(first)/step)) + 1
+	base, err := s.history.Bars(ctx, symbol, step, origin, first, last, steps)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []store.Bar
+	var key time.Time // window of the last bar
+	i := 0
+	for _, b := range base {
+		for i < len(full) && !b.Start.Before(full[i].Close) {
+			i++
+		}
+		if i == len(full) {
+			break
+		}
+		if b.Start.Before(full[i].Open) {
+			continue // while closed
+		}
+		var k, start time.Time
+		if daily {
+			k, start = days.Assign(full[i].Open)[0].Start, full[i].Open
+		} else {
+			k = window.Spec{Kind: window.Tumbling, Size: interval, Origin: full[i].Open}.Assign(b.Start)[0].Start
+			start = k
+		}
+		if len(out) > 0 && key.Equal(k) {
+			merge(&out[len(out)-1], b)
+			continue
+		}
+		if len(out) == limit {
+			break
+		}
+		key, b.Start = k, start.UTC()
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+// merge folds the later bar b into bar.
+func merge(bar *store.Bar, b store.Bar) {
+	bar.High = max(bar.High, b.High)
+	bar.Low = min(bar.Low, b.Low)
+	bar.Close = b.Close
+	bar.Volume += b.Volume
+	bar.Count += b.Count
+}
+
+func gcd(a, b time.Duration) time.Duration {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// adjust applies the corporate actions effective after each bar, as
+// selected by mode, to bars in place.
+func (s *Service) adjust(ctx context.Context, symbol string, bars []store.Bar, mode marketdatav1.Adjustment) error {
+	if mode == marketdatav1.Adjustment_ADJUSTMENT_NONE || len(bars) == 0 {
+		return nil
+	}
+	// A day early covers markets east of UTC, whose ex-dates start the
+	// previous UTC day.
+	actions, err := s.history.CorporateActions(ctx, symbol, bars[0].Start.AddDate(0, 0, -1))
+	if err != nil {
+		return err
+	}
+	m := corpactions.Splits
+	if mode == marketdatav1.Adjustment_ADJUSTMENT_SPLITS_AND_DIVIDENDS {
+		m = corpactions.SplitsAndDividends
+	}
+	adj, err := corpactions.NewAdjuster(actions, m, s.calendars.For(symbol).Location(), func(t time.Time) (float64, bool, error) {
+		return s.history.PriceBefore(ctx, symbol, t)
+	})
+	if err != nil {
+		return err
+	}
+	for i := range bars {
+		b := &bars[i]
+		p, v := adj.Factors(b.Start)
+		b.Open, b.High, b.Low, b.Close = b.Open*p, b.High*p, b.Low*p, b.Close*p
+		b.Volume *= v
+	}
+	return nil
+}
+
+// seam remembers the replayed ticks by their identity in the ticks table,
+// so live ticks that were also replayed can be skipped. The seam of a symbol
+// closes at its first live tick newer than anything replayed; memory is
+// released once every symbol's seam is closed.
+type seam struct {
+	last map[string]time.Time // newest replayed timestamp of the open seams
+	keys map[tickKey]struct{}
+}
+
+type tickKey struct {
+	symbol string
+	ts     int64
+	srcID  string
+}
+
+func keyOf(t model.Tick) tickKey { return tickKey{t.Symbol, t.Ts.UnixNano(), t.SrcID} }
+
+func newSeam() *seam {
+	return &seam{last: make(map[string]time.Time), keys: make(map[tickKey]struct{})}
+}
+
+func (s *seam) add(t model.Tick) {
+	if last, ok := s.last[t.Symbol]; !ok || t.Ts.After(last) {
+		s.last[t.Symbol] = t.Ts
+	}
+	s.keys[keyOf(t)] = struct{}{}
+}
+
+// replayed reports whether live tick t was already sent from history.
+func (s *seam) replayed(t model.Tick) bool {
+	last, ok := s.last[t.Symbol]
+	if !ok {
+		return false
+	}
+	if t.Ts.After(last) {
+		delete(s.last, t.Symbol)
+		if len(s.last) == 0 {
+			s.keys = nil
+		}
+		return false
+	}
+	_, ok = s.keys[keyOf(t)]
+	return ok
+}
+
+func normalize(symbols []string) ([]string, error) {
+	out := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		s = strings.ToUpper(strings.TrimSpace(s))
+		if s != "" && !slices.Contains(out, s) {
+			out = append(out, s)
+		}
+	}
+	if len(out) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one symbol is required")
+	}
+	return out, nil
+}
+
+func toProto(t model.Tick, live bool) *marketdatav1.Tick {
+	return &marketdatav1.Tick{
+		Symbol:   t.Symbol,
+		Ts:       timestamppb.New(t.Ts),
+		Price:    t.Price,
+		Size:     t.Size,
+		Exchange: t.Exchange,
+		SrcId:    t.SrcID,
+		Live:     live,
+	}
+}
+
+// toStatus maps store and context errors onto gRPC status codes; errors
+// that already carry a status (e.g. from Send) are passed through.
+func toStatus(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.Unavailable, "history query failed")
+}
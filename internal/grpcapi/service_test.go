@@ -0,0 +1,333 @@
+package grpcapi
+
+import (
+	"context"
+	"io"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	marketdatav1 "github.com/jonandereg/streamforge/api/marketdata/v1"
+	"github.com/jonandereg/streamforge/internal/calendar"
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/corpactions"
+	"github.com/jonandereg/streamforge/internal/model"
+	"github.com/jonandereg/streamforge/internal/store"
+	"github.com/jonandereg/streamforge/internal/stream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func tick(sym string, sec int64, src string) model.Tick {
+	return model.Tick{Symbol: sym, Ts: time.Unix(sec, 0).UTC(), Price: float64(sec), SrcID: src}
+}
+
+// fakeHistory replays ticks and runs duringReplay after the first one, to
+// simulate live ticks arriving while history is being sent.
+type fakeHistory struct {
+	ticks        []model.Tick
+	duringReplay func()
+	bars         []store.Bar
+	barsLimit    int
+	barsCalls    [][3]time.Time // origin, from, to
+	actions      []corpactions.Action
+	closeBefore  float64
+}
+
+func (f *fakeHistory) Ticks(_ context.Context, _ []string, _, _ time.Time, fn func(model.Tick) error) error {
+	for i, t := range f.ticks {
+		if err := fn(t); err != nil {
+			return err
+		}
+		if i == 0 && f.duringReplay != nil {
+			f.duringReplay()
+		}
+	}
+	return nil
+}
+
+func (f *fakeHistory) Latest(_ context.Context, symbols []string) ([]model.Tick, error) {
+	var out []model.Tick
+	for _, t := range f.ticks {
+		for _, s := range symbols {
+			if t.Symbol == s {
+				out = append(out, t)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeHistory) Bars(_ context.Context, _ string, _ time.Duration, origin, from, to time.Time, limit int) ([]store.Bar, error) {
+	f.barsLimit = limit
+	f.barsCalls = append(f.barsCalls, [3]time.Time{origin.UTC(), from.UTC(), to.UTC()})
+	return slices.Clone(f.bars), nil
+}
+
+func (f *fakeHistory) CorporateActions(context.Context, string, time.Time) ([]corpactions.Action, error) {
+	return f.actions, nil
+}
+
+func (f *fakeHistory) PriceBefore(context.Context, string, time.Time) (float64, bool, error) {
+	return f.closeBefore, f.closeBefore > 0, nil
+}
+
+func dial(t *testing.T, history History, hub *stream.Hub, cfg config.GRPC) marketdatav1.MarketDataClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(NewService(history, hub, cfg, zap.NewNop()), cfg, noop.NewTracerProvider(), zap.NewNop())
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return marketdatav1.NewMarketDataClient(conn)
+}
+
+var testCfg = config.GRPC{StreamBuffer: 16, RequestTimeout: time.Second, MaxBars: 100, MaxStreams: 10}
+
+func TestStreamTicksReplaysThenGoesLive(t *testing.T) {
+	hub := stream.NewHub()
+	h := &fakeHistory{ticks: []model.Tick{tick("AAPL", 1, "a"), tick("AAPL", 2, "a")}}
+	h.duringReplay = func() {
+		hub.Publish(tick("AAPL", 2, "a")) // also in history
+		hub.Publish(tick("AAPL", 2, "b")) // same ts, different source
+		hub.Publish(tick("AAPL", 3, "a"))
+	}
+	client := dial(t, h, hub, testCfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	st, err := client.StreamTicks(ctx, &marketdatav1.StreamTicksRequest{
+		Symbols: []string{"aapl"},
+		From:    timestamppb.New(time.Unix(0, 0)),
+	})
+	require.NoError(t, err)
+
+	type got struct {
+		sec  int64
+		src  string
+		live bool
+	}
+	var recv []got
+	for range 4 {
+		m, err := st.Recv()
+		require.NoError(t, err)
+		recv = append(recv, got{m.GetTs().AsTime().Unix(), m.GetSrcId(), m.GetLive()})
+	}
+	assert.Equal(t, []got{{1, "a", false}, {2, "a", false}, {2, "b", true}, {3, "a", true}}, recv)
+
+	hub.Close()
+	_, err = st.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestStreamTicksLongReplayIsNotCutOff(t *testing.T) {
+	hub := stream.NewHub()
+	cfg := testCfg
+	cfg.StreamBuffer = 1
+	h := &fakeHistory{ticks: []model.Tick{tick("AAPL", 5, "a")}}
+	h.duringReplay = func() {
+		hub.Publish(tick("AAPL", 4, "late")) // older than the replay, never stored
+		hub.Publish(tick("AAPL", 5, "a"))    // replayed
+		for sec := int64(6); sec < 20; sec++ {
+			hub.Publish(tick("AAPL", sec, "a"))
+		}
+	}
+	client := dial(t, h, hub, cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	st, err := client.StreamTicks(ctx, &marketdatav1.StreamTicksRequest{
+		Symbols: []string{"AAPL"},
+		From:    timestamppb.New(time.Unix(0, 0)),
+	})
+	require.NoError(t, err)
+	var secs []int64
+	for range 16 {
+		m, err := st.Recv()
+		require.NoError(t, err)
+		secs = append(secs, m.GetTs().AsTime().Unix())
+	}
+	assert.Equal(t, []int64{5, 4, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19}, secs)
+}
+
+func TestStreamTicksWithoutHistory(t *testing.T) {
+	client := dial(t, nil, stream.NewHub(), testCfg)
+	st, err := client.StreamTicks(context.Background(), &marketdatav1.StreamTicksRequest{
+		Symbols: []string{"AAPL"},
+		From:    timestamppb.Now(),
+	})
+	require.NoError(t, err)
+	_, err = st.Recv()
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.NotErrorIs(t, err, io.EOF)
+}
+
+func TestGetLatestPrefersLive(t *testing.T) {
+	hub := stream.NewHub()
+	hub.Publish(tick("AAPL", 9, "live"))
+	h := &fakeHistory{ticks: []model.Tick{tick("AAPL", 1, "db"), tick("MSFT", 2, "db")}}
+	client := dial(t, h, hub, testCfg)
+
+	resp, err := client.GetLatest(context.Background(), &marketdatav1.GetLatestRequest{Symbols: []string{"AAPL", "MSFT", "TSLA"}})
+	require.NoError(t, err)
+	require.Len(t, resp.GetTicks(), 2)
+	assert.Equal(t, "live", resp.GetTicks()[0].GetSrcId())
+	assert.True(t, resp.GetTicks()[0].GetLive())
+	assert.Equal(t, "MSFT", resp.GetTicks()[1].GetSymbol())
+	assert.False(t, resp.GetTicks()[1].GetLive())
+}
+
+func TestGetBars(t *testing.T) {
+	h := &fakeHistory{bars: []store.Bar{{Start: time.Unix(60, 0), Open: 1, High: 3, Low: 1, Close: 2, Volume: 10, Count: 4}}}
+	client := dial(t, h, stream.NewHub(), testCfg)
+	ctx := context.Background()
+
+	resp, err := client.GetBars(ctx, &marketdatav1.GetBarsRequest{
+		Symbol:   "aapl",
+		Interval: durationpb.New(time.Minute),
+		From:     timestamppb.New(time.Unix(0, 0)),
+		Limit:    1000,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "AAPL", resp.GetSymbol())
+	require.Len(t, resp.GetBars(), 1)
+	assert.Equal(t, uint64(4), resp.GetBars()[0].GetCount())
+	assert.Equal(t, testCfg.MaxBars, h.barsLimit, "limit is capped")
+
+	_, err = client.GetBars(ctx, &marketdatav1.GetBarsRequest{Symbol: "AAPL", Interval: durationpb.New(time.Millisecond), From: timestamppb.Now()})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGetBarsAdjusted(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	h := &fakeHistory{
+		bars: []store.Bar{
+			{Start: day(2), Open: 400, High: 400, Low: 400, Close: 400, Volume: 10},
+			{Start: day(6), Open: 100, High: 100, Low: 100, Close: 100, Volume: 40},
+			{Start: day(8), Open: 99, High: 99, Low: 99, Close: 99, Volume: 40},
+		},
+		actions: []corpactions.Action{
+			{Symbol: "AAPL", ExDate: day(3), Kind: corpactions.KindSplit, Ratio: 4},
+			{Symbol: "AAPL", ExDate: day(7), Kind: corpactions.KindDividend, Ratio: 1, Amount: 1},
+		},
+		closeBefore: 100,
+	}
+	client := dial(t, h, stream.NewHub(), testCfg)
+	get := func(a marketdatav1.Adjustment) (closes, volumes []float64) {
+		resp, err := client.GetBars(context.Background(), &marketdatav1.GetBarsRequest{
+			Symbol: "AAPL", Interval: durationpb.New(24 * time.Hour), From: timestamppb.New(day(1)), Adjustment: a,
+		})
+		require.NoError(t, err)
+		for _, b := range resp.GetBars() {
+			closes = append(closes, b.GetClose())
+			volumes = append(volumes, b.GetVolume())
+		}
+		return closes, volumes
+	}
+
+	closes, volumes := get(marketdatav1.Adjustment_ADJUSTMENT_NONE)
+	assert.Equal(t, []float64{400, 100, 99}, closes)
+	assert.Equal(t, []float64{10, 40, 40}, volumes)
+	closes, volumes = get(marketdatav1.Adjustment_ADJUSTMENT_SPLITS)
+	assert.Equal(t, []float64{100, 100, 99}, closes)
+	assert.Equal(t, []float64{40, 40, 40}, volumes)
+	closes, _ = get(marketdatav1.Adjustment_ADJUSTMENT_SPLITS_AND_DIVIDENDS)
+	assert.InDeltaSlice(t, []float64{99, 99, 99}, closes, 1e-9)
+}
+
+func TestBarsFollowSessions(t *testing.T) {
+	cals, err := calendar.Parse([]byte(`
+calendars:
+  - {name: XNYS, timezone: America/New_York, sessions: [{days: [mon, tue, wed, thu, fri], open: "09:30", close: "16:00"}]}
+symbols:
+  - {calendar: XNYS, symbols: [MSFT]}
+`))
+	require.NoError(t, err)
+	utc := func(day, hour, min int) time.Time { return time.Date(2025, 1, day, hour, min, 0, 0, time.UTC) }
+	bar := func(start time.Time, p float64) store.Bar {
+		return store.Bar{Start: start, Open: p, High: p, Low: p, Close: p, Volume: p, Count: 1}
+	}
+	h := &fakeHistory{bars: []store.Bar{ // 30m steps
+		bar(utc(3, 15, 0), 1),
+		bar(utc(3, 15, 30), 2),
+		bar(utc(3, 16, 0), 3),
+		bar(utc(3, 20, 30), 4),
+		bar(utc(3, 21, 0), 5), // after the close
+		bar(utc(6, 14, 30), 6),
+		bar(utc(6, 15, 0), 7),
+	}}
+	svc := NewService(h, stream.NewHub(), testCfg, zap.NewNop())
+	svc.SetCalendars(cals)
+
+	// Friday 15:00 UTC (10:00 in New York) to Monday 15:30 UTC.
+	from, to := utc(3, 15, 0), utc(6, 15, 30)
+	got, err := svc.bars(context.Background(), "MSFT", time.Hour, from, to, 100)
+	require.NoError(t, err)
+	assert.Equal(t, [][3]time.Time{{utc(3, 14, 30), from, to}}, h.barsCalls, "one query, aligned to the open")
+	assert.Equal(t, []store.Bar{
+		bar(utc(3, 14, 30), 1),
+		{Start: utc(3, 15, 30), Open: 2, High: 3, Low: 2, Close: 3, Volume: 5, Count: 2},
+		bar(utc(3, 20, 30), 4),
+		{Start: utc(6, 14, 30), Open: 6, High: 7, Low: 6, Close: 7, Volume: 13, Count: 2},
+	}, got, "nothing over the weekend")
+
+	got, err = svc.bars(context.Background(), "MSFT", time.Hour, from, to, 2)
+	require.NoError(t, err)
+	assert.Len(t, got, 2)
+
+	h.barsCalls = nil
+	_, err = svc.bars(context.Background(), "BINANCE:BTCUSDT", time.Hour, from, from.AddDate(0, 0, 3), 100)
+	require.NoError(t, err)
+	assert.Equal(t, store.DefaultOrigin, h.barsCalls[0][0])
+}
+
+func TestDailyBarsSpanSessions(t *testing.T) {
+	cals, err := calendar.Parse([]byte(`
+calendars:
+  - {name: XNYS, timezone: America/New_York, sessions: [{days: [mon, tue, wed, thu, fri], open: "09:30", close: "16:00"}]}
+symbols:
+  - {calendar: XNYS, symbols: [MSFT]}
+`))
+	require.NoError(t, err)
+	utc := func(day, hour, min int) time.Time { return time.Date(2025, 1, day, hour, min, 0, 0, time.UTC) }
+	bar := func(start time.Time, p float64) store.Bar {
+		return store.Bar{Start: start, Open: p, High: p, Low: p, Close: p, Volume: p, Count: 1}
+	}
+	h := &fakeHistory{bars: []store.Bar{ // whole sessions: 14:30-21:00 UTC
+		bar(utc(3, 14, 30), 1),
+		bar(utc(6, 14, 30), 2),
+		bar(utc(7, 14, 30), 3),
+	}}
+	svc := NewService(h, stream.NewHub(), testCfg, zap.NewNop())
+	svc.SetCalendars(cals)
+	from, to := utc(1, 0, 0), utc(8, 0, 0)
+
+	got, err := svc.bars(context.Background(), "MSFT", 24*time.Hour, from, to, 100)
+	require.NoError(t, err)
+	assert.Len(t, h.barsCalls, 1, "one query for all sessions")
+	assert.Equal(t, []store.Bar{bar(utc(3, 14, 30), 1), bar(utc(6, 14, 30), 2), bar(utc(7, 14, 30), 3)}, got,
+		"one bar per trading day, starting at the open")
+
+	got, err = svc.bars(context.Background(), "MSFT", 7*24*time.Hour, from, to, 100)
+	require.NoError(t, err)
+	assert.Equal(t, []store.Bar{
+		bar(utc(3, 14, 30), 1),
+		{Start: utc(6, 14, 30), Open: 2, High: 3, Low: 2, Close: 3, Volume: 5, Count: 2},
+	}, got, "weeks start on Monday")
+}
@@ -0,0 +1,303 @@
+// Package health aggregates dependency checks for readiness and detects
+// stuck components for liveness.
+//
+// Components register named checks (Kafka, provider connection, DB pool, ...)
+// with a criticality. Checks run periodically in the background; /readyz
+// reports the cached results and fails only if a critical check fails.
+// Components doing unit work (e.g. workers) register a Watchdog and mark each
+// unit; /healthz fails if one has been busy longer than its limit.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Criticality decides whether a failing check makes the service unready.
+type Criticality int
+
+const (
+	// Critical checks must pass for the service to be ready.
+	Critical Criticality = iota
+	// NonCritical checks only mark the service as degraded.
+	NonCritical
+)
+
+// Check reports whether a dependency is usable. It should honour ctx.
+type Check func(ctx context.Context) error
+
+// Check states reported by CheckStatus.
+const (
+	StatusUnknown = "unknown"
+	StatusOK      = "ok"
+	StatusFailing = "failing"
+)
+
+// Overall states reported by Report.
+const (
+	StateReady    = "ready"
+	StateDegraded = "degraded"
+	StateNotReady = "not_ready"
+)
+
+// CheckStatus is the latest result of a named check.
+type CheckStatus struct {
+	Name        string    `json:"name"`
+	Critical    bool      `json:"critical"`
+	Status      string    `json:"status"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastChecked time.Time `json:"last_checked,omitempty"`
+	LastOK      time.Time `json:"last_ok,omitempty"`
+}
+
+// Report is the /readyz response body.
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckStatus `json:"checks"`
+}
+
+type entry struct {
+	check Check
+	st    CheckStatus
+}
+
+// Registry holds checks and watchdogs.
+type Registry struct {
+	interval time.Duration
+	timeout  time.Duration
+
+	mu        sync.RWMutex
+	checks    map[string]*entry
+	watchdogs map[string]*Watchdog
+}
+
+// NewRegistry creates a Registry that runs checks every interval, each
+// bounded by timeout.
+func NewRegistry(interval, timeout time.Duration) *Registry {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	if timeout <= 0 || timeout > interval {
+		timeout = interval
+	}
+	return &Registry{
+		interval:  interval,
+		timeout:   timeout,
+		checks:    make(map[string]*entry),
+		watchdogs: make(map[string]*Watchdog),
+	}
+}
+
+// Register adds or replaces a named check. It is first evaluated on the next
+// round; until then it reports StatusUnknown, which counts as failing.
+func (r *Registry) Register(name string, c Criticality, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = &entry{
+		check: check,
+		st:    CheckStatus{Name: name, Critical: c == Critical, Status: StatusUnknown},
+	}
+}
+
+// Run evaluates all checks immediately and then every interval until ctx is done.
+func (r *Registry) Run(ctx context.Context) error {
+	t := time.NewTicker(r.interval)
+	defer t.Stop()
+	for {
+		r.CheckNow(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// CheckNow evaluates every check concurrently and records the results.
+func (r *Registry) CheckNow(ctx context.Context) {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.checks))
+	fns := make([]Check, 0, len(r.checks))
+	for name, e := range r.checks {
+		names = append(names, name)
+		fns = append(fns, e.check)
+	}
+	r.mu.RUnlock()
+
+	errs := make([]error, len(fns))
+	var wg sync.WaitGroup
+	for i, fn := range fns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cctx, cancel := context.WithTimeout(ctx, r.timeout)
+			defer cancel()
+			errs[i] = runCheck(cctx, fn)
+		}()
+	}
+	wg.Wait()
+
+	now := time.Now().UTC()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, name := range names {
+		e, ok := r.checks[name]
+		if !ok || ctx.Err() != nil {
+			continue
+		}
+		e.st.LastChecked = now
+		if errs[i] != nil {
+			e.st.Status = StatusFailing
+			e.st.LastError = errs[i].Error()
+			continue
+		}
+		e.st.Status = StatusOK
+		e.st.LastError = ""
+		e.st.LastOK = now
+	}
+}
+
+// runCheck converts a panicking check into a failure.
+func runCheck(ctx context.Context, fn Check) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = errors.New("check panicked")
+		}
+	}()
+	return fn(ctx)
+}
+
+// Report returns the cached check results sorted by name.
+func (r *Registry) Report() Report {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rep := Report{Status: StateReady, Checks: make([]CheckStatus, 0, len(r.checks))}
+	for _, e := range r.checks {
+		rep.Checks = append(rep.Checks, e.st)
+		if e.st.Status == StatusOK {
+			continue
+		}
+		if e.st.Critical {
+			rep.Status = StateNotReady
+		} else if rep.Status == StateReady {
+			rep.Status = StateDegraded
+		}
+	}
+	sort.Slice(rep.Checks, func(i, j int) bool { return rep.Checks[i].Name < rep.Checks[j].Name })
+	return rep
+}
+
+// ReadyHandler serves Report as JSON: 200 when ready or degraded, 503 otherwise.
+func (r *Registry) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		rep := r.Report()
+		code := http.StatusOK
+		if rep.Status == StateNotReady {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, rep)
+	})
+}
+
+// Watchdog tracks a component that processes units of work. A unit that
+// stays open longer than the limit marks the component as stuck.
+// A nil *Watchdog is valid and does nothing.
+type Watchdog struct {
+	name  string
+	limit atomic.Int64 // time.Duration; 0 disables detection
+	busy  atomic.Int64 // unix nanos when the open unit began; 0 when idle
+}
+
+// Watchdog registers and returns a watchdog for name. Waiting for work is
+// not progress to be measured, so only time spent inside Begin/End counts.
+func (r *Registry) Watchdog(name string, limit time.Duration) *Watchdog {
+	w := &Watchdog{name: name}
+	w.SetLimit(limit)
+	r.mu.Lock()
+	r.watchdogs[name] = w
+	r.mu.Unlock()
+	return w
+}
+
+// SetLimit changes how long a unit may stay open; 0 disables detection.
+func (w *Watchdog) SetLimit(d time.Duration) {
+	if w != nil {
+		w.limit.Store(int64(d))
+	}
+}
+
+// Begin marks the start of a unit of work.
+func (w *Watchdog) Begin() {
+	if w != nil {
+		w.busy.Store(time.Now().UnixNano())
+	}
+}
+
+// End marks the current unit of work as finished.
+func (w *Watchdog) End() {
+	if w != nil {
+		w.busy.Store(0)
+	}
+}
+
+// stuckFor returns how long the open unit has been running and whether that
+// is over the limit.
+func (w *Watchdog) stuckFor(now time.Time) (time.Duration, time.Duration, bool) {
+	began, limit := w.busy.Load(), time.Duration(w.limit.Load())
+	if began == 0 || limit <= 0 {
+		return 0, limit, false
+	}
+	d := now.Sub(time.Unix(0, began))
+	return d, limit, d > limit
+}
+
+// StuckComponent describes a watchdog over its limit.
+type StuckComponent struct {
+	Name  string `json:"name"`
+	Busy  string `json:"busy_for"`
+	Limit string `json:"limit"`
+}
+
+// Stuck lists the watchdogs currently over their limit, sorted by name.
+func (r *Registry) Stuck() []StuckComponent {
+	now := time.Now()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []StuckComponent
+	for _, w := range r.watchdogs {
+		if d, limit, stuck := w.stuckFor(now); stuck {
+			out = append(out, StuckComponent{
+				Name:  w.name,
+				Busy:  d.Truncate(time.Millisecond).String(),
+				Limit: limit.String(),
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// LiveHandler serves liveness: 200 unless a watchdog is stuck, 503 with the
+// stuck components otherwise.
+func (r *Registry) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		stuck := r.Stuck()
+		if len(stuck) == 0 {
+			writeJSON(w, http.StatusOK, map[string]any{"status": "ok"})
+			return
+		}
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"status": "stuck", "stuck": stuck})
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,97 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readyz(t *testing.T, r *Registry) (int, Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	r.ReadyHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var rep Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rep))
+	return rec.Code, rep
+}
+
+func TestReadinessByCriticality(t *testing.T) {
+	r := NewRegistry(time.Second, time.Second)
+	var kafkaErr, redisErr error
+	r.Register("kafka", Critical, func(context.Context) error { return kafkaErr })
+	r.Register("redis", NonCritical, func(context.Context) error { return redisErr })
+
+	code, rep := readyz(t, r)
+	assert.Equal(t, http.StatusServiceUnavailable, code, "unevaluated checks are not ready")
+	assert.Equal(t, StatusUnknown, rep.Checks[0].Status)
+
+	r.CheckNow(context.Background())
+	code, rep = readyz(t, r)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StateReady, rep.Status)
+
+	redisErr = errors.New("connection refused")
+	r.CheckNow(context.Background())
+	code, rep = readyz(t, r)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StateDegraded, rep.Status)
+	require.Len(t, rep.Checks, 2)
+	assert.Equal(t, "redis", rep.Checks[1].Name)
+	assert.Equal(t, "connection refused", rep.Checks[1].LastError)
+	assert.False(t, rep.Checks[1].LastOK.IsZero(), "last success is kept")
+
+	kafkaErr = errors.New("no brokers")
+	r.CheckNow(context.Background())
+	code, rep = readyz(t, r)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StateNotReady, rep.Status)
+}
+
+func TestCheckTimeoutAndPanic(t *testing.T) {
+	r := NewRegistry(time.Second, 10*time.Millisecond)
+	r.Register("slow", Critical, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	r.Register("broken", NonCritical, func(context.Context) error { panic("boom") })
+
+	r.CheckNow(context.Background())
+	rep := r.Report()
+	assert.Equal(t, "check panicked", rep.Checks[0].LastError)
+	assert.Equal(t, context.DeadlineExceeded.Error(), rep.Checks[1].LastError)
+}
+
+func TestWatchdogStuck(t *testing.T) {
+	r := NewRegistry(time.Second, time.Second)
+	w := r.Watchdog("worker-0", 10*time.Millisecond)
+
+	live := func() int {
+		rec := httptest.NewRecorder()
+		r.LiveHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		return rec.Code
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, http.StatusOK, live(), "idle is not stuck")
+
+	w.Begin()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, http.StatusServiceUnavailable, live())
+	stuck := r.Stuck()
+	require.Len(t, stuck, 1)
+	assert.Equal(t, "worker-0", stuck[0].Name)
+
+	w.End()
+	assert.Equal(t, http.StatusOK, live())
+
+	var nilWatchdog *Watchdog
+	nilWatchdog.Begin()
+	nilWatchdog.End()
+}
@@ -0,0 +1,265 @@
+// Package indicators computes technical indicators per symbol as ticks
+// arrive: SMA, EMA, VWAP, rolling volatility, RSI and Bollinger bands. Each
+// tick updates every indicator in O(1); the per-symbol Series is plain data
+// so it can be checkpointed in a state.Store.
+package indicators
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/model"
+)
+
+// Update is the set of indicator values that are warmed up after a tick.
+type Update struct {
+	Symbol string             `json:"symbol"`
+	Ts     time.Time          `json:"ts"`
+	Price  float64            `json:"price"`
+	Values map[string]float64 `json:"values"`
+}
+
+// Series is the indicator state of one symbol.
+type Series struct {
+	// Spec identifies the configuration the series was built for; a series
+	// restored after the periods changed is started over.
+	Spec  string    `json:"spec"`
+	Ticks int64     `json:"ticks"`
+	Last  float64   `json:"last"`
+	SMA   []Rolling `json:"sma"`
+	EMA   []EMA     `json:"ema"`
+	VWAP  []VWAP    `json:"vwap"`
+	Vol   []Rolling `json:"vol"` // of log returns
+	RSI   []RSI     `json:"rsi"`
+	Bands []Rolling `json:"bands"`
+}
+
+// Calculator updates Series for one configuration.
+type Calculator struct {
+	cfg   config.Indicators
+	spec  string
+	names struct {
+		sma, ema, vwap, vol, rsi []string
+		bands                    [][3]string // upper, middle, lower
+	}
+}
+
+// NewCalculator creates a Calculator for cfg.
+func NewCalculator(cfg config.Indicators) *Calculator {
+	c := &Calculator{cfg: cfg}
+	var spec strings.Builder
+	named := func(prefix string, periods []int) []string {
+		out := make([]string, len(periods))
+		for i, p := range periods {
+			out[i] = fmt.Sprintf("%s_%d", prefix, p)
+		}
+		fmt.Fprintf(&spec, "%s%v;", prefix, periods)
+		return out
+	}
+	c.names.sma = named("sma", cfg.SMA)
+	c.names.ema = named("ema", cfg.EMA)
+	c.names.vwap = named("vwap", cfg.VWAP)
+	c.names.vol = named("volatility", cfg.Volatility)
+	c.names.rsi = named("rsi", cfg.RSI)
+	for _, b := range cfg.Bollinger {
+		base := fmt.Sprintf("bb_%d_%g", b.Period, b.K)
+		c.names.bands = append(c.names.bands, [3]string{base + "_upper", base + "_middle", base + "_lower"})
+		fmt.Fprintf(&spec, "%s;", base)
+	}
+	c.spec = spec.String()
+	return c
+}
+
+// Init returns an empty series for the configured periods.
+func (c *Calculator) Init() Series {
+	s := Series{Spec: c.spec}
+	for _, p := range c.cfg.SMA {
+		s.SMA = append(s.SMA, newRolling(p))
+	}
+	for _, p := range c.cfg.EMA {
+		s.EMA = append(s.EMA, EMA{Period: p})
+	}
+	for _, p := range c.cfg.VWAP {
+		s.VWAP = append(s.VWAP, VWAP{PV: newRolling(p), V: newRolling(p)})
+	}
+	for _, p := range c.cfg.Volatility {
+		s.Vol = append(s.Vol, newRolling(p))
+	}
+	for _, p := range c.cfg.RSI {
+		s.RSI = append(s.RSI, RSI{Period: p})
+	}
+	for _, b := range c.cfg.Bollinger {
+		s.Bands = append(s.Bands, newRolling(b.Period))
+	}
+	return s
+}
+
+// Matches reports whether s was built for this configuration.
+func (c *Calculator) Matches(s Series) bool { return s.Spec == c.spec }
+
+// Update applies t to s and returns the values of the indicators that have
+// seen enough ticks.
+func (c *Calculator) Update(s *Series, t model.Tick) map[string]float64 {
+	out := make(map[string]float64)
+	p := t.Price
+	for i := range s.SMA {
+		r := &s.SMA[i]
+		r.Push(p)
+		if r.Full() {
+			out[c.names.sma[i]] = r.Mean()
+		}
+	}
+	for i := range s.EMA {
+		if v, ok := s.EMA[i].Push(p); ok {
+			out[c.names.ema[i]] = v
+		}
+	}
+	for i := range s.VWAP {
+		if v, ok := s.VWAP[i].Push(p, t.Size); ok {
+			out[c.names.vwap[i]] = v
+		}
+	}
+	if s.Ticks > 0 {
+		if s.Last > 0 && p > 0 {
+			ret := math.Log(p / s.Last)
+			for i := range s.Vol {
+				r := &s.Vol[i]
+				r.Push(ret)
+				if r.Full() {
+					out[c.names.vol[i]] = r.StdDev()
+				}
+			}
+		}
+		for i := range s.RSI {
+			if v, ok := s.RSI[i].Push(p - s.Last); ok {
+				out[c.names.rsi[i]] = v
+			}
+		}
+	}
+	for i := range s.Bands {
+		r := &s.Bands[i]
+		r.Push(p)
+		if r.Full() {
+			mid, sd := r.Mean(), r.StdDev()
+			k := c.cfg.Bollinger[i].K
+			n := c.names.bands[i]
+			out[n[0]], out[n[1]], out[n[2]] = mid+k*sd, mid, mid-k*sd
+		}
+	}
+	s.Ticks++
+	s.Last = p
+	return out
+}
+
+// Rolling keeps the sum and sum of squares of the last len(Buf) values.
+type Rolling struct {
+	Buf   []float64 `json:"buf"`
+	Pos   int       `json:"pos"`
+	Count int       `json:"count"`
+	Sum   float64   `json:"sum"`
+	SumSq float64   `json:"sum_sq"`
+}
+
+func newRolling(period int) Rolling { return Rolling{Buf: make([]float64, period)} }
+
+// Push adds v, evicting the oldest value once full. The sums are recomputed
+// exactly once per period, which keeps float drift bounded at amortized O(1).
+func (r *Rolling) Push(v float64) {
+	if r.Count == len(r.Buf) {
+		old := r.Buf[r.Pos]
+		r.Sum -= old
+		r.SumSq -= old * old
+	} else {
+		r.Count++
+	}
+	r.Buf[r.Pos] = v
+	r.Sum += v
+	r.SumSq += v * v
+	r.Pos = (r.Pos + 1) % len(r.Buf)
+	if r.Pos == 0 {
+		r.Sum, r.SumSq = 0, 0
+		for _, x := range r.Buf[:r.Count] {
+			r.Sum += x
+			r.SumSq += x * x
+		}
+	}
+}
+
+// Full reports whether the window holds a whole period.
+func (r *Rolling) Full() bool { return r.Count == len(r.Buf) }
+
+// Mean returns the mean of the window.
+func (r *Rolling) Mean() float64 { return r.Sum / float64(r.Count) }
+
+// StdDev returns the population standard deviation of the window.
+func (r *Rolling) StdDev() float64 {
+	m := r.Mean()
+	return math.Sqrt(max(r.SumSq/float64(r.Count)-m*m, 0))
+}
+
+// EMA is an exponential moving average seeded with the first price.
+type EMA struct {
+	Period int     `json:"period"`
+	Value  float64 `json:"value"`
+	N      int     `json:"n"`
+}
+
+// Push adds a price and returns the average once Period prices were seen.
+func (e *EMA) Push(p float64) (float64, bool) {
+	if e.N == 0 {
+		e.Value = p
+	} else {
+		e.Value += 2 / float64(e.Period+1) * (p - e.Value)
+	}
+	e.N++
+	return e.Value, e.N >= e.Period
+}
+
+// VWAP is the volume-weighted average price of the last period ticks.
+type VWAP struct {
+	PV Rolling `json:"pv"`
+	V  Rolling `json:"v"`
+}
+
+// Push adds a trade and returns the VWAP once the window is full.
+func (w *VWAP) Push(price, size float64) (float64, bool) {
+	w.PV.Push(price * size)
+	w.V.Push(size)
+	if !w.V.Full() || w.V.Sum <= 0 {
+		return 0, false
+	}
+	return w.PV.Sum / w.V.Sum, true
+}
+
+// RSI is Wilder's relative strength index.
+type RSI struct {
+	Period  int     `json:"period"`
+	AvgGain float64 `json:"avg_gain"`
+	AvgLoss float64 `json:"avg_loss"`
+	N       int     `json:"n"`
+}
+
+// Push adds a price change and returns the index once Period changes were
+// seen. The first Period changes seed the averages, later ones smooth them.
+func (r *RSI) Push(change float64) (float64, bool) {
+	gain, loss := max(change, 0), max(-change, 0)
+	p := float64(r.Period)
+	r.N++
+	if r.N <= r.Period {
+		r.AvgGain += gain / p
+		r.AvgLoss += loss / p
+	} else {
+		r.AvgGain = (r.AvgGain*(p-1) + gain) / p
+		r.AvgLoss = (r.AvgLoss*(p-1) + loss) / p
+	}
+	if r.N < r.Period {
+		return 0, false
+	}
+	if r.AvgLoss == 0 {
+		return 100, true
+	}
+	return 100 - 100/(1+r.AvgGain/r.AvgLoss), true
+}
@@ -0,0 +1,168 @@
+package indicators
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/events"
+	"github.com/jonandereg/streamforge/internal/model"
+	"github.com/jonandereg/streamforge/internal/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var t0 = time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+
+func tick(price, size float64) model.Tick {
+	return model.Tick{Symbol: "AAPL", Ts: t0, Price: price, Size: size}
+}
+
+func TestRollingMatchesNaive(t *testing.T) {
+	r := newRolling(3)
+	prices := []float64{10, 11, 12, 13, 9, 8, 15}
+	for i, p := range prices {
+		r.Push(p)
+		if i < 2 {
+			assert.False(t, r.Full())
+			continue
+		}
+		w := prices[i-2 : i+1]
+		mean := (w[0] + w[1] + w[2]) / 3
+		var ss float64
+		for _, x := range w {
+			ss += (x - mean) * (x - mean)
+		}
+		assert.InDelta(t, mean, r.Mean(), 1e-9)
+		assert.InDelta(t, math.Sqrt(ss/3), r.StdDev(), 1e-9)
+	}
+}
+
+func TestCalculatorWarmsUp(t *testing.T) {
+	c := NewCalculator(config.Indicators{
+		SMA: []int{2}, EMA: []int{3}, VWAP: []int{2}, RSI: []int{2},
+		Bollinger: []config.Bollinger{{Period: 2, K: 2}},
+	})
+	s := c.Init()
+	assert.Empty(t, c.Update(&s, tick(10, 1)))
+
+	out := c.Update(&s, tick(12, 3))
+	assert.Equal(t, 11.0, out["sma_2"])
+	assert.Equal(t, 11.5, out["vwap_2"], "(10*1 + 12*3) / 4")
+	assert.Equal(t, 13.0, out["bb_2_2_upper"])
+	assert.Equal(t, 9.0, out["bb_2_2_lower"])
+	assert.NotContains(t, out, "ema_3")
+	assert.NotContains(t, out, "rsi_2", "one change so far")
+
+	out = c.Update(&s, tick(11, 1))
+	assert.InDelta(t, 11, out["ema_3"], 1e-9, "10 -> 11 -> 11")
+	assert.InDelta(t, 100-100/(1+1.0/0.5), out["rsi_2"], 1e-9, "avg gain 1, avg loss 0.5")
+}
+
+type memSink struct {
+	got []Update
+	err error
+}
+
+func (m *memSink) Name() string { return "mem" }
+func (m *memSink) Write(_ context.Context, u []Update) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.got = append(m.got, u...)
+	return nil
+}
+func (m *memSink) Close() error { return nil }
+
+func TestProcessorSkipsReplayedTicks(t *testing.T) {
+	mgr := state.NewManager(nil, zap.NewNop())
+	series, err := state.Register[Series](mgr, "indicators/test")
+	require.NoError(t, err)
+	sink := &memSink{}
+	p := NewProcessor("test", config.Indicators{SMA: []int{2}, BatchSize: 100}, series, []Sink{sink}, zap.NewNop())
+
+	ctx := context.Background()
+	for i, price := range []float64{10, 12, 14} {
+		require.NoError(t, p.Process(ctx, events.TickMsg{Tick: tick(price, 1), Kafka: events.KafkaMeta{Offset: int64(i)}}))
+	}
+	// A replay of offset 1 after a restore leaves the series untouched.
+	require.NoError(t, p.Process(ctx, events.TickMsg{Tick: tick(100, 1), Kafka: events.KafkaMeta{Offset: 1}}))
+	assert.Empty(t, sink.got, "buffered until Flush")
+	require.NoError(t, p.Flush(ctx))
+	require.Len(t, sink.got, 2)
+	assert.Equal(t, 11.0, sink.got[0].Values["sma_2"])
+	assert.Equal(t, 13.0, sink.got[1].Values["sma_2"])
+}
+
+func TestProcessorRetriesFailedWrites(t *testing.T) {
+	mgr := state.NewManager(nil, zap.NewNop())
+	series, err := state.Register[Series](mgr, "indicators/test")
+	require.NoError(t, err)
+	down, up := &memSink{err: errors.New("sink down")}, &memSink{}
+	p := NewProcessor("test", config.Indicators{SMA: []int{1}, BatchSize: 100}, series, []Sink{down, up}, zap.NewNop())
+	mgr.SetFlush(p.Flush)
+
+	ctx := context.Background()
+	require.NoError(t, p.Process(ctx, events.TickMsg{Tick: tick(10, 1), Kafka: events.KafkaMeta{Offset: 0}}))
+	require.Error(t, mgr.Checkpoint(ctx, map[int]int64{0: 1}))
+	assert.Empty(t, mgr.Committable(nil), "the failed update holds the offset back")
+	require.Len(t, up.got, 1)
+
+	down.err = nil
+	require.NoError(t, p.Process(ctx, events.TickMsg{Tick: tick(11, 1), Kafka: events.KafkaMeta{Offset: 1}}))
+	require.NoError(t, mgr.Checkpoint(ctx, map[int]int64{0: 2}))
+	assert.Equal(t, map[int]int64{0: 2}, mgr.Committable(nil))
+	require.Len(t, down.got, 2, "retried ahead of the new update")
+	assert.Equal(t, 10.0, down.got[0].Price)
+	assert.Len(t, up.got, 2, "not written twice to the healthy sink")
+}
+
+func TestProcessorCapsRetryBacklog(t *testing.T) {
+	mgr := state.NewManager(nil, zap.NewNop())
+	series, err := state.Register[Series](mgr, "indicators/test")
+	require.NoError(t, err)
+	down := &memSink{err: errors.New("sink down")}
+	p := NewProcessor("test", config.Indicators{SMA: []int{1}, BatchSize: 1}, series, []Sink{down}, zap.NewNop())
+
+	ctx := context.Background()
+	for i := range 15 {
+		require.NoError(t, p.Process(ctx, events.TickMsg{Tick: tick(float64(i), 1), Kafka: events.KafkaMeta{Offset: int64(i)}}))
+	}
+	down.err = nil
+	require.NoError(t, p.Flush(ctx))
+	require.Len(t, down.got, retryBatches, "the oldest updates are dropped")
+	assert.Equal(t, 5.0, down.got[0].Price)
+}
+
+// memCache is a memSink keeping only the latest update of each symbol.
+type memCache struct{ memSink }
+
+func (*memCache) LatestOnly() {}
+
+func TestCacheFailureDoesNotHoldCommits(t *testing.T) {
+	mgr := state.NewManager(nil, zap.NewNop())
+	series, err := state.Register[Series](mgr, "indicators/test")
+	require.NoError(t, err)
+	cache := &memCache{memSink{err: errors.New("redis down")}}
+	p := NewProcessor("test", config.Indicators{SMA: []int{1}, BatchSize: 100}, series, []Sink{cache}, zap.NewNop())
+	mgr.SetFlush(p.Flush)
+
+	ctx := context.Background()
+	for i, sym := range []string{"AAPL", "MSFT", "AAPL"} {
+		tk := tick(float64(10+i), 1)
+		tk.Symbol = sym
+		require.NoError(t, p.Process(ctx, events.TickMsg{Tick: tk, Kafka: events.KafkaMeta{Offset: int64(i)}}))
+	}
+	require.NoError(t, mgr.Checkpoint(ctx, map[int]int64{0: 3}))
+	assert.Equal(t, map[int]int64{0: 3}, mgr.Committable(nil))
+
+	cache.err = nil
+	require.NoError(t, p.Flush(ctx))
+	require.Len(t, cache.got, 2, "latest per symbol")
+	assert.Equal(t, "MSFT", cache.got[0].Symbol)
+	assert.Equal(t, 12.0, cache.got[1].Price)
+}
@@ -0,0 +1,166 @@
+package indicators
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/events"
+	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
+	"github.com/jonandereg/streamforge/internal/state"
+	"go.uber.org/zap"
+)
+
+// Sink receives batches of updates in the order they were computed.
+type Sink interface {
+	// Name labels the sink in metrics and logs.
+	Name() string
+	Write(ctx context.Context, updates []Update) error
+	Close() error
+}
+
+// LatestOnly is implemented by sinks that only keep the latest update of
+// each symbol, such as a cache. Updates they failed to write are retried
+// as the latest per symbol, and their failures do not fail Flush, so a
+// cache that is down never holds back checkpoints and offset commits.
+type LatestOnly interface {
+	LatestOnly()
+}
+
+// retryBatches bounds the updates kept for a failing sink, in batches; the
+// oldest are dropped beyond it.
+const retryBatches = 10
+
+// Processor is a worker.Processor computing indicators for every tick. The
+// per-symbol series live in a state.Store, so a restart resumes from the
+// last checkpoint and replayed ticks are not counted twice. Updates are
+// buffered and written to the sinks on Flush or once BatchSize are pending;
+// updates a sink fails to write are kept, up to retryBatches batches, and
+// written to it again, ahead of newer ones, on the next Flush.
+type Processor struct {
+	name   string
+	calc   *Calculator
+	series *state.Store[Series]
+	sinks  []Sink
+	batch  int
+	log    *zap.Logger
+
+	mu      sync.Mutex
+	pending []Update
+	writeMu sync.Mutex // keeps batches in order
+	retry   [][]Update // by sink; guarded by writeMu
+}
+
+// NewProcessor creates the stage name keeping its series in store.
+func NewProcessor(name string, cfg config.Indicators, store *state.Store[Series], sinks []Sink, log *zap.Logger) *Processor {
+	return &Processor{
+		name:   name,
+		calc:   NewCalculator(cfg),
+		series: store,
+		sinks:  sinks,
+		batch:  cfg.BatchSize,
+		log:    log,
+		retry:  make([][]Update, len(sinks)),
+	}
+}
+
+// Process implements worker.Processor. Ticks always pass to the next stage.
+func (p *Processor) Process(ctx context.Context, msg events.TickMsg) error {
+	t := msg.Tick
+	var values map[string]float64
+	p.series.Update(t.Symbol, msg.Kafka, func(s *Series, exists bool) {
+		if !exists || !p.calc.Matches(*s) {
+			*s = p.calc.Init()
+		}
+		values = p.calc.Update(s, t)
+	})
+	if len(values) == 0 {
+		return nil
+	}
+	sfmetrics.IndicatorUpdatesTotal.WithLabelValues(p.name).Inc()
+	p.mu.Lock()
+	p.pending = append(p.pending, Update{Symbol: t.Symbol, Ts: t.Ts, Price: t.Price, Values: values})
+	full := len(p.pending) >= p.batch
+	p.mu.Unlock()
+	if full {
+		if err := p.Flush(ctx); err != nil {
+			p.log.Warn("indicators write failed", zap.String("stage", p.name), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// Flush implements worker.Flusher, writing the pending updates to every sink.
+func (p *Processor) Flush(ctx context.Context) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	p.mu.Lock()
+	batch := p.pending
+	p.pending = nil
+	p.mu.Unlock()
+	var errs []error
+	for i, s := range p.sinks {
+		_, cache := s.(LatestOnly)
+		updates := batch
+		if len(p.retry[i]) > 0 {
+			updates = append(p.retry[i], batch...)
+		}
+		if len(updates) == 0 {
+			continue
+		}
+		start := time.Now()
+		err := s.Write(ctx, updates)
+		sfmetrics.IndicatorSinkLatencySeconds.WithLabelValues(s.Name()).Observe(time.Since(start).Seconds())
+		if err != nil {
+			sfmetrics.IndicatorSinkWritesTotal.WithLabelValues(s.Name(), "failure").Inc()
+			if cache {
+				p.log.Warn("indicators cache write failed", zap.String("stage", p.name), zap.String("sink", s.Name()), zap.Error(err))
+				p.retry[i] = latest(updates)
+				continue
+			}
+			errs = append(errs, fmt.Errorf("%s sink: %w", s.Name(), err))
+			if limit := retryBatches * max(p.batch, 1); len(updates) > limit {
+				dropped := len(updates) - limit
+				sfmetrics.IndicatorSinkDroppedTotal.WithLabelValues(s.Name()).Add(float64(dropped))
+				p.log.Warn("indicators retry backlog full, dropping the oldest updates",
+					zap.String("stage", p.name), zap.String("sink", s.Name()), zap.Int("dropped", dropped))
+				updates = updates[dropped:]
+			}
+			p.retry[i] = slices.Clone(updates)
+			continue
+		}
+		sfmetrics.IndicatorSinkWritesTotal.WithLabelValues(s.Name(), "success").Inc()
+		p.retry[i] = nil
+	}
+	return errors.Join(errs...)
+}
+
+// latest returns the last update of each symbol in updates, in order.
+func latest(updates []Update) []Update {
+	last := make(map[string]int, len(updates))
+	for i, u := range updates {
+		last[u.Symbol] = i
+	}
+	out := make([]Update, 0, len(last))
+	for i, u := range updates {
+		if last[u.Symbol] == i {
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+// Close implements worker.Closer.
+func (p *Processor) Close(context.Context) error {
+	var errs []error
+	for _, s := range p.sinks {
+		if err := s.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s sink: %w", s.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,124 @@
+package indicators
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/broker"
+	"github.com/jonandereg/streamforge/internal/store"
+	"github.com/redis/go-redis/v9"
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaSink publishes each update as JSON keyed by symbol.
+type KafkaSink struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaSink creates a sink writing to topic.
+func NewKafkaSink(brokers []string, topic string, sec *broker.Security) *KafkaSink {
+	return &KafkaSink{writer: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Transport:    sec.Transport(),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: 10 * time.Millisecond,
+	}}
+}
+
+// Name implements Sink.
+func (s *KafkaSink) Name() string { return "kafka" }
+
+// Write implements Sink.
+func (s *KafkaSink) Write(ctx context.Context, updates []Update) error {
+	msgs := make([]kafka.Message, 0, len(updates))
+	for _, u := range updates {
+		val, err := json.Marshal(u)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, kafka.Message{
+			Key:     []byte(u.Symbol),
+			Value:   val,
+			Headers: []kafka.Header{{Key: "content-type", Value: []byte("application/json")}},
+			Time:    u.Ts,
+		})
+	}
+	return s.writer.WriteMessages(ctx, msgs...)
+}
+
+// Close flushes and closes the underlying writer.
+func (s *KafkaSink) Close() error { return s.writer.Close() }
+
+// Table is the part of store.TickStore the TableSink writes through.
+type Table interface {
+	InsertIndicators(ctx context.Context, rows []store.IndicatorValue) error
+}
+
+// TableSink writes one row per indicator value to the indicators hypertable.
+type TableSink struct {
+	Table Table
+}
+
+// Name implements Sink.
+func (s *TableSink) Name() string { return "table" }
+
+// Write implements Sink.
+func (s *TableSink) Write(ctx context.Context, updates []Update) error {
+	var rows []store.IndicatorValue
+	for _, u := range updates {
+		for name, v := range u.Values {
+			rows = append(rows, store.IndicatorValue{Ts: u.Ts, Symbol: u.Symbol, Name: name, Value: v})
+		}
+	}
+	return s.Table.InsertIndicators(ctx, rows)
+}
+
+// Close implements Sink; the store is owned by the caller.
+func (s *TableSink) Close() error { return nil }
+
+// RedisSink caches the latest values of each symbol in a hash at
+// Prefix+symbol, with the tick's ts and price alongside the indicators.
+type RedisSink struct {
+	Client *redis.Client
+	Prefix string
+	// TTL expires the hash of a symbol that stopped trading; 0 keeps it.
+	TTL time.Duration
+}
+
+// Name implements Sink.
+func (s *RedisSink) Name() string { return "redis" }
+
+// Write implements Sink. Only the last update of each symbol in the batch
+// is written.
+func (s *RedisSink) Write(ctx context.Context, updates []Update) error {
+	latest := make(map[string]Update)
+	for _, u := range updates {
+		latest[u.Symbol] = u
+	}
+	pipe := s.Client.Pipeline()
+	for sym, u := range latest {
+		fields := make(map[string]any, len(u.Values)+2)
+		fields["ts"] = u.Ts.UTC().Format(time.RFC3339Nano)
+		fields["price"] = strconv.FormatFloat(u.Price, 'f', -1, 64)
+		for name, v := range u.Values {
+			fields[name] = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		key := s.Prefix + sym
+		pipe.HSet(ctx, key, fields)
+		if s.TTL > 0 {
+			pipe.Expire(ctx, key, s.TTL)
+		}
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// LatestOnly implements LatestOnly.
+func (s *RedisSink) LatestOnly() {}
+
+// Close implements Sink; the client is owned by the caller.
+func (s *RedisSink) Close() error { return nil }
@@ -0,0 +1,82 @@
+package ingestor
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/jonandereg/streamforge/internal/admin"
+	"github.com/jonandereg/streamforge/internal/providers/finnhub"
+	"github.com/jonandereg/streamforge/internal/refdata"
+)
+
+// registerAdmin adds the ingestor routes: symbol management, pausing
+// ingestion, the provider connection state and the symbol master. Symbol
+// changes last until the next restart or a config reload that changes
+// provider.symbols. Symbols are matched like provider.symbols: trimmed,
+// upper-cased and mapped from Finnhub aliases to canonical IDs.
+func registerAdmin(adm *admin.Server, prov *finnhub.Provider, instruments *refdata.Master, paused *atomic.Bool) {
+	canonical := func(s string) string {
+		return instruments.Canonical(finnhub.SrcID, strings.ToUpper(strings.TrimSpace(s)))
+	}
+	adm.Handle("GET /admin/symbols", func(w http.ResponseWriter, _ *http.Request) {
+		admin.JSON(w, http.StatusOK, map[string][]string{"symbols": prov.Symbols()})
+	})
+	adm.Handle("POST /admin/symbols", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Symbols []string `json:"symbols"`
+		}
+		if err := admin.Decode(r, &body); err != nil {
+			admin.Error(w, http.StatusBadRequest, err)
+			return
+		}
+		if len(body.Symbols) == 0 {
+			admin.Error(w, http.StatusBadRequest, errors.New("symbols is required"))
+			return
+		}
+		ids := make([]string, len(body.Symbols))
+		for i, s := range body.Symbols {
+			ids[i] = canonical(s)
+			if ids[i] == "" || !instruments.Known(ids[i]) {
+				admin.Error(w, http.StatusBadRequest, fmt.Errorf("%q is not in the symbol master", s))
+				return
+			}
+		}
+		prov.SetSymbols(append(prov.Symbols(), ids...))
+		admin.JSON(w, http.StatusOK, map[string][]string{"symbols": prov.Symbols()})
+	})
+	adm.Handle("DELETE /admin/symbols/{symbol}", func(w http.ResponseWriter, r *http.Request) {
+		sym := canonical(r.PathValue("symbol"))
+		current := prov.Symbols()
+		i := slices.Index(current, sym)
+		if i < 0 {
+			admin.Error(w, http.StatusNotFound, errors.New("symbol not subscribed"))
+			return
+		}
+		prov.SetSymbols(slices.Delete(current, i, i+1))
+		admin.JSON(w, http.StatusOK, map[string][]string{"symbols": prov.Symbols()})
+	})
+
+	setPaused := func(v bool) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			paused.Store(v)
+			admin.JSON(w, http.StatusOK, map[string]bool{"paused": v})
+		}
+	}
+	adm.Handle("POST /admin/pause", setPaused(true))
+	adm.Handle("POST /admin/resume", setPaused(false))
+
+	adm.Handle("GET /admin/instruments", func(w http.ResponseWriter, _ *http.Request) {
+		admin.JSON(w, http.StatusOK, map[string][]refdata.Instrument{"instruments": instruments.Instruments()})
+	})
+
+	adm.Handle("GET /admin/provider", func(w http.ResponseWriter, _ *http.Request) {
+		admin.JSON(w, http.StatusOK, struct {
+			finnhub.State
+			Paused bool `json:"paused"`
+		}{prov.State(), paused.Load()})
+	})
+}
@@ -0,0 +1,75 @@
+package ingestor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jonandereg/streamforge/internal/admin"
+	"github.com/jonandereg/streamforge/internal/providers/finnhub"
+	"github.com/jonandereg/streamforge/internal/refdata"
+	"github.com/jonandereg/streamforge/internal/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newAdmin(t *testing.T) (func(method, path, body string) *httptest.ResponseRecorder, *finnhub.Provider, *atomic.Bool) {
+	t.Helper()
+	m, err := refdata.New([]refdata.Instrument{
+		{ID: "AAPL"},
+		{ID: "MSFT"},
+		{ID: "BTC-USD", Aliases: map[string]string{finnhub.SrcID: "BINANCE:BTCUSDT"}},
+	})
+	require.NoError(t, err)
+	prov := finnhub.New(finnhub.WSConfig{Symbols: []string{"AAPL"}, Instruments: m}, zap.NewNop())
+	var paused atomic.Bool
+	adm := admin.New([]admin.Credential{{Identity: "ops", Token: secrets.New("tok")}}, zap.NewNop())
+	registerAdmin(adm, prov, m, &paused)
+
+	return func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer tok")
+		rec := httptest.NewRecorder()
+		adm.ServeHTTP(rec, req)
+		return rec
+	}, prov, &paused
+}
+
+func TestAdminSymbols(t *testing.T) {
+	do, prov, _ := newAdmin(t)
+
+	rec := do(http.MethodPost, "/admin/symbols", `{"symbols":[" msft ","binance:btcusdt"]}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.JSONEq(t, `{"symbols":["AAPL","MSFT","BTC-USD"]}`, rec.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/admin/symbols", `{"symbols":["nope"]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/admin/symbols", `{"symbols":[]}`).Code)
+
+	rec = do(http.MethodDelete, "/admin/symbols/msft", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, []string{"AAPL", "BTC-USD"}, prov.Symbols())
+	assert.Equal(t, http.StatusOK, do(http.MethodDelete, "/admin/symbols/BINANCE:BTCUSDT", "").Code, "by alias")
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/admin/symbols/MSFT", "").Code)
+
+	rec = do(http.MethodGet, "/admin/symbols", "")
+	assert.JSONEq(t, `{"symbols":["AAPL"]}`, rec.Body.String())
+}
+
+func TestAdminPause(t *testing.T) {
+	do, _, paused := newAdmin(t)
+
+	rec := do(http.MethodPost, "/admin/pause", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"paused":true}`, rec.Body.String())
+	assert.True(t, paused.Load())
+
+	rec = do(http.MethodGet, "/admin/provider", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"paused":true`)
+
+	do(http.MethodPost, "/admin/resume", "")
+	assert.False(t, paused.Load())
+}
@@ -3,30 +3,62 @@ package ingestor
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/jonandereg/streamforge/internal/admin"
 	"github.com/jonandereg/streamforge/internal/broker"
+	"github.com/jonandereg/streamforge/internal/calendar"
 	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/dedup"
+	"github.com/jonandereg/streamforge/internal/health"
 	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
+	"github.com/jonandereg/streamforge/internal/model"
 	"github.com/jonandereg/streamforge/internal/obs"
 	"github.com/jonandereg/streamforge/internal/providers/finnhub"
+	"github.com/jonandereg/streamforge/internal/refdata"
+	"github.com/jonandereg/streamforge/internal/store"
+	"github.com/jonandereg/streamforge/internal/supervisor"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+const tracerName = "github.com/jonandereg/streamforge/internal/ingestor"
+
 // Run starts the ingestor service, connecting to data providers and publishing ticks to Kafka.
-func Run(ctx context.Context, o *obs.Obs) error {
+// The provider and publisher loops run under sup so a panic restarts them instead of the process.
+// If rl is not nil, reloaded symbol lists are applied to the live provider.
+// If adm is not nil, the ingestor admin routes are registered on it.
+func Run(ctx context.Context, o *obs.Obs, sup *supervisor.Supervisor, cfg config.AppConfig, rl *config.Reloader, adm *admin.Server) error {
+	resolver, err := cfg.SecretsResolver()
+	if err != nil {
+		return err
+	}
+	token, err := resolver.Resolve(cfg.DataProvider.Token)
+	if err != nil {
+		return err
+	}
+	sec, err := broker.NewSecurity(cfg.Kafka, resolver)
+	if err != nil {
+		return err
+	}
 
 	bcfg := broker.Config{
-		Brokers:       []string{"localhost:29092"},
-		Topic:         "ticks",
-		ClientID:      "streamforge-ingestor",
-		Acks:          -1, // all
-		BatchTimeout:  5 * time.Millisecond,
-		BatchBytes:    1_048_576,
-		Compression:   kafka.Lz4.Codec(),
-		RetryAttempts: 5,
-		RetryBackoff:  100 * time.Millisecond,
+		Brokers:       cfg.Kafka.Brokers,
+		Topic:         cfg.Kafka.TicksTopic,
+		ClientID:      cfg.Producer.ClientID,
+		Acks:          kafka.RequiredAcks(cfg.Producer.Acks),
+		BatchTimeout:  cfg.Producer.BatchTimeout,
+		BatchBytes:    cfg.Producer.BatchBytes,
+		Compression:   compressionCodec(cfg.Producer.Compression),
+		RetryAttempts: cfg.Producer.RetryAttempts,
+		RetryBackoff:  cfg.Producer.RetryBackoff,
+		Security:      sec,
 	}
 
 	prod, err := broker.NewProducer(ctx, bcfg)
@@ -39,60 +71,78 @@ func Run(ctx context.Context, o *obs.Obs) error {
 			o.Logger.Error("failed to close producer during defer", zap.Error(err))
 		}
 	}()
+	o.Health.Register("kafka-producer", health.Critical, prod.Ping)
 	o.ReadyHandler.SetReady()
 	o.Logger.Info("broker connected; readiness set")
 
-	envConfig, _ := config.LoadConfig()
-
-	symbols := []string{
-		"APPL",
-		"MSFT",
-		"BINANCE:BTCUSDT",
+	instruments, err := openInstruments(ctx, cfg, o)
+	if err != nil {
+		return err
 	}
 	provCfg := finnhub.WSConfig{
-		BaseURL:       envConfig.DataProvider.WsURL,
-		APIKey:        envConfig.DataProvider.Token,
-		Symbols:       symbols,
-		ReconnectBase: 200 * time.Millisecond,
-		ReconnectMax:  5 * time.Second,
+		BaseURL:       cfg.DataProvider.WsURL,
+		APIKey:        token,
+		Symbols:       cfg.DataProvider.Symbols,
+		ReconnectBase: cfg.DataProvider.ReconnectBase,
+		ReconnectMax:  cfg.DataProvider.ReconnectMax,
+		StaleAfter:    cfg.DataProvider.StaleAfter,
+		Instruments:   instruments,
 	}
 
+	calendars, err := calendar.Load(cfg.Calendar.File)
+	if err != nil {
+		return err
+	}
+	calendars.SetInstruments(instruments)
 	prov := finnhub.New(provCfg, o.Logger)
+	prov.SetCalendars(calendars)
+	o.Health.Register("provider-finnhub", health.Critical, prov.Check)
+	if cfg.DataProvider.StaleAfter > 0 {
+		o.Health.Register("provider-feed", health.NonCritical, prov.CheckFeed)
+	}
+	sup.Go(ctx, "health-checks", o.Health.Run)
+	if rl != nil {
+		rl.OnChange(func(c config.AppConfig) {
+			prov.SetSymbols(c.DataProvider.Symbols)
+		})
+	}
 
-	ticksCh, errsCh := prov.Start(ctx)
+	var paused atomic.Bool
+	if adm != nil {
+		registerAdmin(adm, prov, instruments, &paused)
+	}
 
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case t, ok := <-ticksCh:
-				if !ok {
-					return
-				}
-				sfmetrics.IngestorFetchTotal.Inc()
-				if err := prod.Publish(ctx, t); err != nil {
-					o.Logger.Error("publish failed",
-						zap.String("symbol", t.Symbol),
-						zap.Time("ts", t.Ts),
-						zap.Error(err),
-					)
-					continue
-				}
-				o.Logger.Debug("published tick",
-					zap.String("symbol", t.Symbol),
-					zap.Time("ts", t.Ts),
-					zap.Float64("price", t.Price),
-				)
-			case err, ok := <-errsCh:
-				if !ok {
-					return
-				}
-				sfmetrics.IngestorFetchErrorsTotal.WithLabelValues("ws").Inc()
-				o.Logger.Warn("provider error", zap.Error(err))
-			}
+	var dd *dedup.Deduper
+	if cfg.Dedup.Ingestor {
+		dd, err = dedup.New("ingestor", cfg.Dedup)
+		if err != nil {
+			return err
 		}
-	}()
+	}
+	var dq *screen
+	if cfg.Quality.Ingestor {
+		dq, err = newScreen(cfg, prov, instruments, sec, o.Logger)
+		if err != nil {
+			return err
+		}
+		defer dq.close()
+		if rl != nil {
+			rl.OnChange(dq.reconfigure)
+		}
+	}
+
+	sup.Go(ctx, "secret-provider-token", func(ctx context.Context) error {
+		return resolver.Watch(ctx, cfg.DataProvider.Token, token, cfg.Secrets.Refresh, o.Logger, prov.SetAPIKey)
+	})
+
+	ticksCh := make(chan model.Tick, 1024)
+	errsCh := make(chan error, 16)
+	sup.Go(ctx, "provider-finnhub", func(ctx context.Context) error {
+		return prov.Run(ctx, ticksCh, errsCh)
+	})
+	sup.Go(ctx, "publisher", func(ctx context.Context) error {
+		return publishLoop(ctx, o, prod, dd, dq, ticksCh, errsCh, &paused)
+	})
 
 	<-ctx.Done()
 
@@ -119,3 +169,120 @@ func Run(ctx context.Context, o *obs.Obs) error {
 
 	return nil
 }
+
+// openInstruments loads the symbol master selected by instruments.source,
+// reading the instruments table through a short-lived database connection.
+// Configured symbols the master does not list are reported, as their
+// trades would be dropped.
+func openInstruments(ctx context.Context, cfg config.AppConfig, o *obs.Obs) (*refdata.Master, error) {
+	var table refdata.Table
+	if cfg.Instruments.Source == "table" {
+		r, err := cfg.SecretsResolver()
+		if err != nil {
+			return nil, err
+		}
+		ts, err := store.New(ctx, cfg.Database, r)
+		if err != nil {
+			return nil, err
+		}
+		defer ts.Close()
+		table = ts
+	}
+	m, err := refdata.Open(ctx, cfg.Instruments, table)
+	if err != nil || m == nil {
+		return m, err
+	}
+	for _, s := range cfg.DataProvider.Symbols {
+		if id := m.Canonical(finnhub.SrcID, strings.ToUpper(s)); !m.Known(id) {
+			o.Logger.Warn("subscribed symbol is not in the symbol master; its trades will be dropped", zap.String("symbol", s))
+		}
+	}
+	o.Logger.Info("symbol master loaded", zap.String("source", cfg.Instruments.Source), zap.Int("instruments", m.Len()))
+	return m, nil
+}
+
+// publishLoop forwards provider ticks to Kafka and records provider errors
+// until ctx is cancelled. Ticks received while paused are discarded; with dd
+// set, duplicates are suppressed, and with dq set, ticks are screened before
+// publishing.
+func publishLoop(ctx context.Context, o *obs.Obs, prod *broker.Producer, dd *dedup.Deduper, dq *screen, ticksCh <-chan model.Tick, errsCh <-chan error, paused *atomic.Bool) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case t := <-ticksCh:
+			sfmetrics.IngestorFetchTotal.Inc()
+			if paused.Load() {
+				continue
+			}
+			if dd != nil && dd.Duplicate(t) {
+				o.Logger.Debug("duplicate tick suppressed",
+					zap.String("symbol", t.Symbol),
+					zap.Time("ts", t.Ts),
+					zap.String("src_id", t.SrcID),
+				)
+				continue
+			}
+			err := publish(ctx, prod, dq, t)
+			if errors.Is(err, errScreened) {
+				o.Logger.Debug("tick withheld by data-quality screen",
+					zap.String("symbol", t.Symbol),
+					zap.Error(err),
+				)
+				continue
+			}
+			if err != nil {
+				o.Logger.Error("publish failed",
+					zap.String("symbol", t.Symbol),
+					zap.Time("ts", t.Ts),
+					zap.Error(err),
+				)
+				continue
+			}
+			o.Logger.Debug("published tick",
+				zap.String("symbol", t.Symbol),
+				zap.Time("ts", t.Ts),
+				zap.Float64("price", t.Price),
+			)
+		case err := <-errsCh:
+			sfmetrics.IngestorFetchErrorsTotal.WithLabelValues("ws").Inc()
+			o.Logger.Warn("provider error", zap.Error(err))
+		}
+	}
+}
+
+// publish wraps a single tick publish in a root ingest span; the producer's
+// span and every downstream pipeline span hang off it. Ticks dq withholds
+// are reported with errScreened.
+func publish(ctx context.Context, prod *broker.Producer, dq *screen, t model.Tick) error {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "ingest.tick",
+		trace.WithNewRoot(),
+		trace.WithAttributes(
+			attribute.String("symbol", t.Symbol),
+			attribute.String("src_id", t.SrcID),
+		),
+	)
+	defer span.End()
+	headers, err := dq.apply(ctx, t)
+	if err != nil {
+		return err
+	}
+	return prod.Publish(ctx, t, headers...)
+}
+
+// compressionCodec maps a config compression name to a kafka-go codec; "none"
+// and unknown names disable compression.
+func compressionCodec(name string) kafka.CompressionCodec {
+	switch name {
+	case "gzip":
+		return kafka.Gzip.Codec()
+	case "snappy":
+		return kafka.Snappy.Codec()
+	case "lz4":
+		return kafka.Lz4.Codec()
+	case "zstd":
+		return kafka.Zstd.Codec()
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,114 @@
+package ingestor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/anomaly"
+	"github.com/jonandereg/streamforge/internal/broker"
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/model"
+	"github.com/jonandereg/streamforge/internal/providers/finnhub"
+	"github.com/jonandereg/streamforge/internal/quality"
+	"github.com/jonandereg/streamforge/internal/refdata"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// errScreened is returned by publish for ticks the data-quality screen
+// dropped or quarantined.
+var errScreened = errors.New("withheld by data-quality screen")
+
+// screen runs the data-quality checker in front of the producer.
+type screen struct {
+	checker *quality.Checker
+	prov    *finnhub.Provider
+	sink    *quality.KafkaSink // nil without a quarantine topic
+	log     *zap.Logger
+}
+
+func newScreen(cfg config.AppConfig, prov *finnhub.Provider, instruments *refdata.Master, sec *broker.Security, log *zap.Logger) (*screen, error) {
+	s := &screen{prov: prov, log: log}
+	c, err := quality.NewChecker("ingestor", cfg.Quality, s.known(cfg.Quality))
+	if err != nil {
+		return nil, err
+	}
+	d, err := anomaly.NewDetector(cfg.Anomaly)
+	if err == nil {
+		d.SetInstruments(instruments)
+		c.SetAnomalies(anomaly.NewTracker(d))
+	} else if cfg.Quality.Actions.Anomaly != "off" {
+		return nil, err
+	}
+	s.checker = c
+	if cfg.Quality.QuarantineTopic != "" {
+		s.sink = quality.NewKafkaSink(cfg.Kafka.Brokers, cfg.Quality.QuarantineTopic, sec)
+	}
+	return s, nil
+}
+
+// known checks against quality.symbols when set, otherwise against the
+// live subscriptions, so symbols added through the admin API are accepted.
+func (s *screen) known(cfg config.Quality) func(string) bool {
+	if len(cfg.Symbols) > 0 {
+		return quality.Known(cfg.Symbols)
+	}
+	return s.prov.Subscribed
+}
+
+func (s *screen) reconfigure(c config.AppConfig) {
+	if s.sink == nil && c.Quality.Actions.Quarantines() {
+		s.log.Error("apply reloaded quality config", zap.Error(quality.ErrNoQuarantine))
+		return
+	}
+	if err := s.checker.Configure(c.Quality); err != nil {
+		s.log.Error("apply reloaded quality config", zap.Error(err))
+		return
+	}
+	s.checker.SetKnown(s.known(c.Quality))
+}
+
+// apply checks t. It returns the headers to publish a flagged tick with, or
+// errScreened when the tick must not be published. A nil screen passes
+// everything.
+func (s *screen) apply(ctx context.Context, t model.Tick) ([]kafka.Header, error) {
+	if s == nil {
+		return nil, nil
+	}
+	v := s.checker.Check(t, time.Now())
+	if v.Action == quality.Off {
+		return nil, nil
+	}
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.String("dq.action", v.Action.String()),
+		attribute.String("dq.reason", v.String()),
+	)
+	switch v.Action {
+	case quality.Flag:
+		return []kafka.Header{{
+			Key:   broker.HeaderQualityFlags,
+			Value: []byte(strings.Join(v.Rules(quality.Flag), ",")),
+		}}, nil
+	case quality.Quarantine:
+		if err := s.sink.Send(ctx, t, v); err != nil {
+			return nil, fmt.Errorf("quarantine: %w", err)
+		}
+		return nil, fmt.Errorf("%w: quarantined: %s", errScreened, v)
+	default:
+		return nil, fmt.Errorf("%w: %s", errScreened, v)
+	}
+}
+
+func (s *screen) close() {
+	if s.sink == nil {
+		return
+	}
+	if err := s.sink.Close(); err != nil {
+		s.log.Warn("quarantine sink close error", zap.Error(err))
+	}
+}
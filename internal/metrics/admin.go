@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"github.com/jonandereg/streamforge/internal/obs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// AdminRequestsTotal counts admin API requests by result (ok, error, denied).
+var AdminRequestsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "admin_requests_total",
+		Help: "Total admin API requests by result.",
+	},
+	[]string{"result"},
+)
+
+// RegisterAdmin registers admin API metrics with the provided Prometheus registry.
+func RegisterAdmin(reg *prometheus.Registry) {
+	obs.MustRegister(reg, AdminRequestsTotal)
+	for _, r := range []string{"ok", "error", "denied"} {
+		AdminRequestsTotal.WithLabelValues(r).Add(0)
+	}
+}
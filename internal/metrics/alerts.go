@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"github.com/jonandereg/streamforge/internal/obs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// AlertsFiredTotal counts alerts fired by rule and kind.
+	AlertsFiredTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "alerts_fired_total",
+			Help: "Total alerts fired, by rule and kind.",
+		},
+		[]string{"rule", "kind"},
+	)
+
+	// AlertsSuppressedTotal counts alerts withheld by a rule's cooldown.
+	AlertsSuppressedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "alerts_suppressed_total",
+			Help: "Total alerts suppressed by cooldown, by rule.",
+		},
+		[]string{"rule"},
+	)
+
+	// AlertsDeliveredTotal counts deliveries by sink and result.
+	AlertsDeliveredTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "alerts_delivered_total",
+			Help: "Total alert deliveries, by sink (webhook, kafka, table, log) and result (success|failure).",
+		},
+		[]string{"sink", "result"},
+	)
+
+	// AlertsDroppedTotal counts alerts dropped because the delivery queue was full.
+	AlertsDroppedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "alerts_dropped_total",
+			Help: "Total alerts dropped because the delivery queue was full.",
+		},
+	)
+)
+
+// RegisterAlerts registers the alerting metrics with reg.
+func RegisterAlerts(reg *prometheus.Registry) {
+	obs.MustRegister(reg,
+		AlertsFiredTotal,
+		AlertsSuppressedTotal,
+		AlertsDeliveredTotal,
+		AlertsDroppedTotal,
+	)
+	for _, sink := range []string{"webhook", "kafka", "table", "log"} {
+		AlertsDeliveredTotal.WithLabelValues(sink, "success").Add(0)
+		AlertsDeliveredTotal.WithLabelValues(sink, "failure").Add(0)
+	}
+}
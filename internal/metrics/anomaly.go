@@ -0,0 +1,20 @@
+package metrics
+
+import (
+	"github.com/jonandereg/streamforge/internal/obs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// AnomaliesTotal counts detected anomalies by symbol class, kind and severity.
+var AnomaliesTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "anomalies_total",
+		Help: "Total anomalies detected, by symbol class, kind and severity.",
+	},
+	[]string{"class", "kind", "severity"},
+)
+
+// RegisterAnomaly registers the anomaly metrics with reg.
+func RegisterAnomaly(reg *prometheus.Registry) {
+	obs.MustRegister(reg, AnomaliesTotal)
+}
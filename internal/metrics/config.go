@@ -0,0 +1,24 @@
+package metrics
+
+import (
+	"github.com/jonandereg/streamforge/internal/obs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ConfigReloadTotal counts configuration reload attempts by status
+// (success, unchanged, failure).
+var ConfigReloadTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "config_reload_total",
+		Help: "Total configuration reload attempts by status.",
+	},
+	[]string{"status"},
+)
+
+// RegisterConfig registers configuration metrics with the provided Prometheus registry.
+func RegisterConfig(reg *prometheus.Registry) {
+	obs.MustRegister(reg, ConfigReloadTotal)
+	for _, s := range []string{"success", "unchanged", "failure"} {
+		ConfigReloadTotal.WithLabelValues(s).Add(0)
+	}
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"github.com/jonandereg/streamforge/internal/obs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// DedupCheckedTotal counts ticks inspected by the dedup stage.
+	DedupCheckedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dedup_checked_total",
+			Help: "Total ticks inspected by the dedup stage, by stage.",
+		},
+		[]string{"stage"},
+	)
+
+	// DedupSuppressedTotal counts duplicate ticks suppressed, by stage and
+	// the source of the duplicate.
+	DedupSuppressedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dedup_suppressed_total",
+			Help: "Total duplicate ticks suppressed, by stage and source.",
+		},
+		[]string{"stage", "src_id"},
+	)
+)
+
+// RegisterDedup registers dedup metrics with the provided Prometheus registry.
+func RegisterDedup(reg *prometheus.Registry) {
+	obs.MustRegister(reg, DedupCheckedTotal, DedupSuppressedTotal)
+}
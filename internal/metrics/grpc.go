@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"github.com/jonandereg/streamforge/internal/obs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// GRPCActiveStreams tracks open StreamTicks streams.
+	GRPCActiveStreams = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "grpc_active_streams",
+			Help: "Open StreamTicks streams.",
+		},
+	)
+
+	// GRPCSlowConsumerTotal counts streams closed because the client fell
+	// more than grpc.stream_buffer ticks behind.
+	GRPCSlowConsumerTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "grpc_slow_consumer_total",
+			Help: "Total streams disconnected for falling behind.",
+		},
+	)
+)
+
+// RegisterGRPC registers gRPC API metrics with the provided Prometheus registry.
+func RegisterGRPC(reg *prometheus.Registry) {
+	obs.MustRegister(reg, GRPCActiveStreams, GRPCSlowConsumerTotal)
+}
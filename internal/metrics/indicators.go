@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"github.com/jonandereg/streamforge/internal/obs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// IndicatorUpdatesTotal counts ticks that produced indicator values.
+	IndicatorUpdatesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "indicators_updates_total",
+			Help: "Total indicator updates computed, by stage.",
+		},
+		[]string{"stage"},
+	)
+
+	// IndicatorSinkWritesTotal counts batch writes by sink and result.
+	IndicatorSinkWritesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "indicators_sink_writes_total",
+			Help: "Total indicator batch writes, by sink (kafka, table, redis) and result (success|failure).",
+		},
+		[]string{"sink", "result"},
+	)
+
+	// IndicatorSinkDroppedTotal counts failed updates dropped from a full
+	// retry backlog, by sink.
+	IndicatorSinkDroppedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "indicators_sink_dropped_total",
+			Help: "Total indicator updates dropped from a full retry backlog, by sink.",
+		},
+		[]string{"sink"},
+	)
+
+	// IndicatorSinkLatencySeconds observes batch write latency by sink.
+	IndicatorSinkLatencySeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "indicators_sink_latency_seconds",
+			Help:    "Latency of indicator batch writes, by sink.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"sink"},
+	)
+)
+
+// RegisterIndicators registers the indicator metrics with reg.
+func RegisterIndicators(reg *prometheus.Registry) {
+	obs.MustRegister(reg,
+		IndicatorUpdatesTotal,
+		IndicatorSinkWritesTotal,
+		IndicatorSinkDroppedTotal,
+		IndicatorSinkLatencySeconds,
+	)
+	for _, sink := range []string{"kafka", "table", "redis"} {
+		IndicatorSinkWritesTotal.WithLabelValues(sink, "success").Add(0)
+		IndicatorSinkWritesTotal.WithLabelValues(sink, "failure").Add(0)
+		IndicatorSinkDroppedTotal.WithLabelValues(sink).Add(0)
+	}
+}
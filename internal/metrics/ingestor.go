@@ -1,4 +1,4 @@
-// Package metrics defines Prometheus metrics for the StreamForge ingestor service.
+// Package metrics defines Prometheus metrics for the StreamForge ingestor and ticks-processor services.
 package metrics
 
 import (
@@ -65,6 +65,15 @@ var (
 		},
 	)
 
+	// IngestorStaleSymbols is the number of subscribed symbols without ticks
+	// for provider.stale_after while their market is open.
+	IngestorStaleSymbols = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "ingestor_stale_symbols",
+			Help: "Subscribed symbols without recent ticks while their market is open.",
+		},
+	)
+
 	// IngestorBackpressureTotal counts times the publisher queue was full and we had to block or drop.
 	IngestorBackpressureTotal = prometheus.NewCounter(
 		prometheus.CounterOpts{
@@ -86,6 +95,7 @@ func Register(reg *prometheus.Registry) {
 		IngestorProviderConnectTotal,
 		IngestorProviderReconnectTotal,
 		IngestorBackpressureTotal,
+		IngestorStaleSymbols,
 	)
 }
 
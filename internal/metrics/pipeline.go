@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"github.com/jonandereg/streamforge/internal/obs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// PipelineStageLatencySeconds measures one stage call, retries included.
+	PipelineStageLatencySeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "pipeline_stage_latency_seconds",
+			Help:    "Histogram of pipeline stage latency in seconds, by stage.",
+			Buckets: []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1},
+		},
+		[]string{"stage"},
+	)
+
+	// PipelineStageResultsTotal counts stage outcomes: ok, filtered, retried,
+	// skipped, dead_lettered and halted.
+	PipelineStageResultsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pipeline_stage_results_total",
+			Help: "Total pipeline stage outcomes, by stage and result.",
+		},
+		[]string{"stage", "result"},
+	)
+
+	// PipelineStageHalted is 1 while a halt policy holds a stage on a failing message.
+	PipelineStageHalted = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "pipeline_stage_halted",
+			Help: "Workers currently halted on a failing message, by stage.",
+		},
+		[]string{"stage"},
+	)
+)
+
+// RegisterPipeline registers pipeline metrics with the provided Prometheus registry.
+func RegisterPipeline(reg *prometheus.Registry) {
+	obs.MustRegister(reg, PipelineStageLatencySeconds, PipelineStageResultsTotal, PipelineStageHalted)
+}
@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/jonandereg/streamforge/internal/obs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// ProcessorConsumedTotal counts messages fetched from Kafka.
+	ProcessorConsumedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "processor_consumed_total",
+			Help: "Total number of messages fetched from Kafka.",
+		},
+	)
+
+	// ProcessorDecodedTotal counts messages successfully decoded into ticks.
+	ProcessorDecodedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "processor_decoded_total",
+			Help: "Total number of messages decoded into ticks.",
+		},
+	)
+
+	// ProcessorFailedTotal counts failures partitioned by stage (e.g., decode, process, commit).
+	ProcessorFailedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "processor_failed_total",
+			Help: "Total number of failures, labeled by stage.",
+		},
+		[]string{"stage"},
+	)
+
+	// ProcessorConsumerLag reports the consumer lag (messages behind the high watermark) per partition.
+	ProcessorConsumerLag = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "processor_consumer_lag",
+			Help: "Consumer lag in messages per partition.",
+		},
+		[]string{"partition"},
+	)
+
+	// ProcessorCommitTotal tracks offset commits by status.
+	ProcessorCommitTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "processor_consumer_commit_total",
+			Help: "Total offset commits by status.",
+		},
+		[]string{"status"},
+	)
+
+	// ProcessorCommitLatencySeconds measures latency of offset commits.
+	ProcessorCommitLatencySeconds = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "processor_commit_latency_seconds",
+			Help:    "Histogram of offset commit latency in seconds.",
+			Buckets: prometheus.DefBuckets,
+		},
+	)
+
+	// ProcessorRouterQueueDepth reports the number of messages queued per worker.
+	ProcessorRouterQueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "processor_router_queue_depth",
+			Help: "Messages waiting in each worker queue.",
+		},
+		[]string{"worker"},
+	)
+
+	// ProcessorRouterDroppedTotal counts messages dropped because a worker queue was full.
+	ProcessorRouterDroppedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "processor_router_dropped_total",
+			Help: "Messages dropped by the router because the worker queue was full.",
+		},
+		[]string{"worker"},
+	)
+
+	// ProcessorWorkerLatencySeconds measures Process latency per worker.
+	ProcessorWorkerLatencySeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "processor_worker_latency_seconds",
+			Help:    "Histogram of per-message processing latency in seconds, by worker.",
+			Buckets: []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1},
+		},
+		[]string{"worker"},
+	)
+
+	// ProcessorDeadLetterTotal counts messages sent to the dead-letter sink, labeled by reason.
+	ProcessorDeadLetterTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "processor_dead_letter_total",
+			Help: "Total messages sent to the dead-letter sink, labeled by reason.",
+		},
+		[]string{"reason"},
+	)
+
+	// ProcessorHookErrorsTotal counts failed processor lifecycle hooks (start, flush, close).
+	ProcessorHookErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "processor_hook_errors_total",
+			Help: "Total failed processor lifecycle hook calls, labeled by hook.",
+		},
+		[]string{"hook"},
+	)
+
+	// ProcessorEndToEndLatencySeconds measures time from a reference point to processing completion.
+	// from="event" uses Tick.Ts, from="kafka" uses the Kafka message timestamp.
+	ProcessorEndToEndLatencySeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "processor_end_to_end_latency_seconds",
+			Help:    "Histogram of end-to-end latency until processing completes, by reference timestamp.",
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		},
+		[]string{"from"},
+	)
+)
+
+// RegisterProcessor registers all ticks-processor metrics with the provided Prometheus registry.
+func RegisterProcessor(reg *prometheus.Registry) {
+	obs.MustRegister(reg,
+		ProcessorConsumedTotal,
+		ProcessorDecodedTotal,
+		ProcessorFailedTotal,
+		ProcessorConsumerLag,
+		ProcessorCommitTotal,
+		ProcessorCommitLatencySeconds,
+		ProcessorRouterQueueDepth,
+		ProcessorRouterDroppedTotal,
+		ProcessorWorkerLatencySeconds,
+		ProcessorDeadLetterTotal,
+		ProcessorHookErrorsTotal,
+		ProcessorEndToEndLatencySeconds,
+	)
+}
+
+// PrimeProcessor initializes label combinations known up front so they appear in /metrics output.
+func PrimeProcessor(numWorkers int) {
+	for _, stage := range []string{"decode", "process", "commit"} {
+		ProcessorFailedTotal.WithLabelValues(stage).Add(0)
+	}
+	ProcessorDeadLetterTotal.WithLabelValues("panic").Add(0)
+	for _, hook := range []string{"start", "flush", "close"} {
+		ProcessorHookErrorsTotal.WithLabelValues(hook).Add(0)
+	}
+	ProcessorCommitTotal.WithLabelValues("success").Add(0)
+	ProcessorCommitTotal.WithLabelValues("failure").Add(0)
+	for i := 0; i < numWorkers; i++ {
+		w := strconv.Itoa(i)
+		ProcessorRouterQueueDepth.WithLabelValues(w).Set(0)
+		ProcessorRouterDroppedTotal.WithLabelValues(w).Add(0)
+	}
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"github.com/jonandereg/streamforge/internal/obs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// QualityCheckedTotal counts ticks inspected by the data-quality stage.
+	QualityCheckedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dq_checked_total",
+			Help: "Total ticks inspected by the data-quality stage, by stage.",
+		},
+		[]string{"stage"},
+	)
+
+	// QualityViolationsTotal counts rule violations by stage, rule and the
+	// action the rule was configured with.
+	QualityViolationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dq_violations_total",
+			Help: "Total data-quality rule violations, by stage, rule and action.",
+		},
+		[]string{"stage", "rule", "action"},
+	)
+)
+
+// RegisterQuality registers data-quality metrics with the provided Prometheus registry.
+func RegisterQuality(reg *prometheus.Registry) {
+	obs.MustRegister(reg, QualityCheckedTotal, QualityViolationsTotal)
+}
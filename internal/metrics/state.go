@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"github.com/jonandereg/streamforge/internal/obs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// StateCheckpointsTotal counts state checkpoints by result (success|failure).
+	StateCheckpointsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "state_checkpoints_total",
+			Help: "Total state checkpoints, by result.",
+		},
+		[]string{"result"},
+	)
+
+	// StateCheckpointLatencySeconds measures how long saving a checkpoint takes.
+	StateCheckpointLatencySeconds = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "state_checkpoint_latency_seconds",
+			Help:    "Time spent saving a state checkpoint.",
+			Buckets: prometheus.DefBuckets,
+		},
+	)
+
+	// StateCheckpointEntries counts entries written by checkpoints.
+	StateCheckpointEntries = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "state_checkpoint_entries_total",
+			Help: "Total changed state entries written by checkpoints.",
+		},
+	)
+
+	// StateRestoredEntries counts entries loaded on partition assignment.
+	StateRestoredEntries = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "state_restored_entries_total",
+			Help: "Total state entries restored on partition assignment.",
+		},
+	)
+
+	// StateKeys is the number of keys held per store.
+	StateKeys = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "state_keys",
+			Help: "Keys currently held in memory, by store.",
+		},
+		[]string{"store"},
+	)
+)
+
+// RegisterState registers state store metrics with the provided Prometheus registry.
+func RegisterState(reg *prometheus.Registry) {
+	obs.MustRegister(reg,
+		StateCheckpointsTotal,
+		StateCheckpointLatencySeconds,
+		StateCheckpointEntries,
+		StateRestoredEntries,
+		StateKeys,
+	)
+	StateCheckpointsTotal.WithLabelValues("success").Add(0)
+	StateCheckpointsTotal.WithLabelValues("failure").Add(0)
+}
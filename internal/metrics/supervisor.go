@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"github.com/jonandereg/streamforge/internal/obs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// SupervisorRestartsTotal counts restarts of supervised goroutines by component.
+	SupervisorRestartsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "streamforge_supervisor_restarts_total",
+			Help: "Total restarts of supervised components after a panic or error.",
+		},
+		[]string{"component"},
+	)
+
+	// SupervisorPanicsTotal counts panics recovered by the supervisor by component.
+	SupervisorPanicsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "streamforge_supervisor_panics_total",
+			Help: "Total panics recovered in supervised components.",
+		},
+		[]string{"component"},
+	)
+
+	// SupervisorUp reports whether a supervised component is currently running (1) or not (0).
+	SupervisorUp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "streamforge_supervisor_up",
+			Help: "Whether a supervised component is running (1) or restarting/stopped (0).",
+		},
+		[]string{"component"},
+	)
+)
+
+// RegisterSupervisor registers supervisor metrics with the provided Prometheus registry.
+func RegisterSupervisor(reg *prometheus.Registry) {
+	obs.MustRegister(reg,
+		SupervisorRestartsTotal,
+		SupervisorPanicsTotal,
+		SupervisorUp,
+	)
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"github.com/jonandereg/streamforge/internal/obs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// WindowResultsTotal counts emitted windows by windower and kind
+	// (fired|updated|early).
+	WindowResultsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "window_results_total",
+			Help: "Total windows emitted, by windower and kind.",
+		},
+		[]string{"window", "kind"},
+	)
+
+	// WindowLateTotal counts ticks rejected as later than the allowed lateness.
+	WindowLateTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "window_late_total",
+			Help: "Total ticks later than the allowed lateness, by windower.",
+		},
+		[]string{"window"},
+	)
+
+	// WindowWatermarkSeconds is the current watermark per windower and
+	// partition, as a Unix timestamp.
+	WindowWatermarkSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "window_watermark_seconds",
+			Help: "Current event-time watermark as Unix seconds, by windower and partition.",
+		},
+		[]string{"window", "partition"},
+	)
+)
+
+// RegisterWindow registers windowing metrics with the provided Prometheus registry.
+func RegisterWindow(reg *prometheus.Registry) {
+	obs.MustRegister(reg, WindowResultsTotal, WindowLateTotal, WindowWatermarkSeconds)
+}
@@ -19,6 +19,9 @@ type Tick struct {
 var (
 	// ErrEmptySymbol is returned when a tick has an empty symbol.
 	ErrEmptySymbol = errors.New("Tick: empty symbol")
+	// ErrUnknownSymbol is returned when a tick's symbol is not a known
+	// instrument.
+	ErrUnknownSymbol = errors.New("tick: unknown symbol")
 	// ErrBadTS is returned when a tick has a zero timestamp.
 	ErrBadTS = errors.New("tick: zero timestamp")
 	// ErrBadPrice is returned when a tick has a negative price.
@@ -27,11 +30,21 @@ var (
 	ErrBadSize = errors.New("tick: negative size")
 )
 
-// Validate checks if the tick has valid field values.
-func (t Tick) Validate() error {
+// Instruments reports whether a symbol is a known instrument; see package
+// refdata.
+type Instruments interface {
+	Known(symbol string) bool
+}
+
+// Validate checks if the tick has valid field values and, when in is not
+// nil, whether its symbol is a known instrument.
+func (t Tick) Validate(in Instruments) error {
 	if t.Symbol == "" {
 		return ErrEmptySymbol
 	}
+	if in != nil && !in.Known(t.Symbol) {
+		return ErrUnknownSymbol
+	}
 	if t.Ts.IsZero() {
 		return ErrBadTS
 	}
@@ -1,15 +1,21 @@
 package obs
 
+import "time"
+
 // Config holds observability configuration settings.
 type Config struct {
-	ServiceName    string // e.g., "streamforge-ingestor"
-	ServiceVersion string // from pkg/version
-	Env            string // dev|staging|prod
-	LogLevel       string // debug|info|warn|error
-	LogJSON        bool   // true in prod
-	OTLPEndpoint   string // Jaeger OTLP HTTP, e.g., http://jaeger:4318
+	ServiceName    string  // e.g., "streamforge-ingestor"
+	ServiceVersion string  // from pkg/version
+	Env            string  // dev|staging|prod
+	LogLevel       string  // debug|info|warn|error
+	LogJSON        bool    // true in prod
+	OTLPEndpoint   string  // Jaeger OTLP HTTP, e.g., http://jaeger:4318
+	TraceSampler   string  // always|never|ratio; "" picks by Env (5% in prod, always otherwise)
+	TraceRatio     float64 // sampling ratio in [0,1] when TraceSampler is "ratio"
 	EnablePprof    bool
-	MetricsPath    string // default "/metrics"
-	HealthPath     string // default "/healthz"
-	ReadyPath      string // default "/readyz"
+	MetricsPath    string        // default "/metrics"
+	HealthPath     string        // default "/healthz"
+	ReadyPath      string        // default "/readyz"
+	HealthInterval time.Duration // how often dependency checks run; default 5s
+	HealthTimeout  time.Duration // per-check timeout; default HealthInterval
 }
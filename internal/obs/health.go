@@ -1,10 +1,15 @@
 package obs
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"sync"
 )
 
+// errNotReady is the startup check's error before SetReady and after SetNotReady.
+var errNotReady = errors.New("not ready")
+
 // Readiness tracks the readiness state of the application.
 type Readiness struct {
 	mu    sync.RWMutex
@@ -42,6 +47,16 @@ func (r *Readiness) SetNotReady() {
 	r.ready = false
 }
 
+// Check implements health.Check for the startup gate.
+func (r *Readiness) Check(context.Context) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if !r.ready {
+		return errNotReady
+	}
+	return nil
+}
+
 // Handler returns an HTTP handler for readiness checks.
 func (r *Readiness) Handler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
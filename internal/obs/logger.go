@@ -7,6 +7,13 @@ import (
 
 // NewLogger creates a configured zap logger based on the provided config.
 func NewLogger(cfg Config) (*zap.Logger, error) {
+	l, _, err := newLogger(cfg)
+	return l, err
+}
+
+// newLogger is NewLogger that also returns the logger's level so it can be
+// changed at runtime.
+func newLogger(cfg Config) (*zap.Logger, zap.AtomicLevel, error) {
 	var zapCfg zap.Config
 	var lvl zapcore.Level
 	if cfg.Env == "prod" {
@@ -27,7 +34,8 @@ func NewLogger(cfg Config) (*zap.Logger, error) {
 		lvl = zapcore.InfoLevel
 	}
 
-	zapCfg.Level = zap.NewAtomicLevelAt(lvl)
+	level := zap.NewAtomicLevelAt(lvl)
+	zapCfg.Level = level
 
 	zapCfg.EncoderConfig.TimeKey = "ts"
 	zapCfg.EncoderConfig.EncodeTime = zapcore.RFC3339NanoTimeEncoder
@@ -46,7 +54,7 @@ func NewLogger(cfg Config) (*zap.Logger, error) {
 		zap.AddStacktrace(zapcore.ErrorLevel),
 	)
 	if err != nil {
-		return nil, err
+		return nil, level, err
 	}
 
 	l.With(
@@ -55,5 +63,5 @@ func NewLogger(cfg Config) (*zap.Logger, error) {
 		zap.String("deployment.environment", cfg.Env),
 	)
 
-	return l, nil
+	return l, level, nil
 }
@@ -4,19 +4,24 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/jonandereg/streamforge/internal/health"
 	"github.com/prometheus/client_golang/prometheus"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // Obs holds observability components including logger, metrics, and tracing.
 type Obs struct {
 	Logger         *zap.Logger
+	LogLevel       zap.AtomicLevel // changes Logger's level at runtime
+	Sampler        *Sampler        // changes the trace sampler at runtime
 	PromRegistry   *prometheus.Registry
 	TracerProvider *sdktrace.TracerProvider
 	MetricsHandler http.Handler
-	HealthHandler  http.Handler
-	ReadyHandler   *Readiness
+	HealthHandler  http.Handler     // liveness: fails while a watchdog in Health is stuck
+	ReadyHandler   *Readiness       // startup gate, registered in Health as the "startup" check
+	Health         *health.Registry // dependency checks served on the readiness path
 	HTTPMetrics    *HTTPMetrics
 }
 
@@ -24,12 +29,16 @@ type Obs struct {
 // It returns an Obs struct with handlers and clients, plus a shutdown function
 // that should be called on service exit to flush logs and traces.
 func Init(ctx context.Context, cfg Config) (*Obs, func(context.Context) error, error) {
-	lg, err := NewLogger(cfg)
+	lg, level, err := newLogger(cfg)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	tp, err := NewTracerProvider(ctx, cfg)
+	sampler, err := NewSampler(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	tp, err := newTracerProvider(ctx, cfg, sampler)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -38,17 +47,22 @@ func Init(ctx context.Context, cfg Config) (*Obs, func(context.Context) error, e
 	if err != nil {
 		return nil, nil, err
 	}
-	healthH, readyH := NewHealthHandlers()
+	hr := health.NewRegistry(cfg.HealthInterval, cfg.HealthTimeout)
+	readyH := NewReadiness()
+	hr.Register("startup", health.Critical, readyH.Check)
 
 	httpM := NewHTTPMetrics(reg)
 
 	o := &Obs{
 		Logger:         lg,
+		LogLevel:       level,
+		Sampler:        sampler,
 		TracerProvider: tp,
 		PromRegistry:   reg,
 		MetricsHandler: metricsH,
-		HealthHandler:  healthH,
+		HealthHandler:  hr.LiveHandler(),
 		ReadyHandler:   readyH,
+		Health:         hr,
 		HTTPMetrics:    httpM,
 	}
 
@@ -65,3 +79,17 @@ func Init(ctx context.Context, cfg Config) (*Obs, func(context.Context) error, e
 
 	return o, shutdown, nil
 }
+
+// Reconfigure applies the runtime-tunable parts of cfg: log level and trace
+// sampling. Everything else in cfg is ignored.
+func (o *Obs) Reconfigure(cfg Config) error {
+	lvl, err := zapcore.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return err
+	}
+	if err := o.Sampler.Update(cfg); err != nil {
+		return err
+	}
+	o.LogLevel.SetLevel(lvl)
+	return nil
+}
@@ -2,16 +2,27 @@ package obs
 
 import (
 	"context"
+	"fmt"
+	"sync/atomic"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 // NewTracerProvider creates a configured OpenTelemetry tracer provider.
 func NewTracerProvider(ctx context.Context, cfg Config) (*sdktrace.TracerProvider, error) {
+	sampler, err := NewSampler(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newTracerProvider(ctx, cfg, sampler)
+}
+
+func newTracerProvider(ctx context.Context, cfg Config, sampler *Sampler) (*sdktrace.TracerProvider, error) {
 
 	exp, err := otlptracehttp.New(ctx,
 		otlptracehttp.WithEndpoint(cfg.OTLPEndpoint),
@@ -32,13 +43,6 @@ func NewTracerProvider(ctx context.Context, cfg Config) (*sdktrace.TracerProvide
 		return nil, err
 	}
 
-	var sampler sdktrace.Sampler
-	if cfg.Env == "prod" {
-		sampler = sdktrace.TraceIDRatioBased(0.05)
-	} else {
-		sampler = sdktrace.AlwaysSample()
-	}
-
 	tp := sdktrace.NewTracerProvider(
 		sdktrace.WithBatcher(exp),
 		sdktrace.WithResource(res),
@@ -46,6 +50,66 @@ func NewTracerProvider(ctx context.Context, cfg Config) (*sdktrace.TracerProvide
 	)
 
 	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
 
 	return tp, nil
 }
+
+// Sampler is the tracer provider's sampler. Its policy can be replaced at
+// runtime with Update, e.g. on config reload.
+type Sampler struct {
+	current atomic.Pointer[samplerBox]
+}
+
+type samplerBox struct{ sdktrace.Sampler }
+
+// NewSampler builds the root sampler from cfg. Spans with a remote parent
+// (e.g. extracted from Kafka headers) follow the parent's decision so a trace
+// is either kept end-to-end or not at all.
+func NewSampler(cfg Config) (*Sampler, error) {
+	s := &Sampler{}
+	if err := s.Update(cfg); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Update switches to the policy described by cfg.TraceSampler and
+// cfg.TraceRatio. On error the current policy is kept.
+func (s *Sampler) Update(cfg Config) error {
+	var root sdktrace.Sampler
+	switch cfg.TraceSampler {
+	case "":
+		if cfg.Env == "prod" {
+			root = sdktrace.TraceIDRatioBased(0.05)
+		} else {
+			root = sdktrace.AlwaysSample()
+		}
+	case "always":
+		root = sdktrace.AlwaysSample()
+	case "never":
+		root = sdktrace.NeverSample()
+	case "ratio":
+		if cfg.TraceRatio < 0 || cfg.TraceRatio > 1 {
+			return fmt.Errorf("trace ratio %v out of range [0,1]", cfg.TraceRatio)
+		}
+		root = sdktrace.TraceIDRatioBased(cfg.TraceRatio)
+	default:
+		return fmt.Errorf("unknown trace sampler %q", cfg.TraceSampler)
+	}
+	s.current.Store(&samplerBox{sdktrace.ParentBased(root)})
+	return nil
+}
+
+// ShouldSample implements sdktrace.Sampler.
+func (s *Sampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	return s.current.Load().ShouldSample(p)
+}
+
+// Description implements sdktrace.Sampler.
+func (s *Sampler) Description() string {
+	return s.current.Load().Description()
+}
@@ -0,0 +1,385 @@
+// Package pipeline assembles the ticks-processor processing chain from
+// config: stages run in order or fanned out, each with its own timeout,
+// error policy, metrics and span.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/deadletter"
+	"github.com/jonandereg/streamforge/internal/events"
+	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
+	"github.com/jonandereg/streamforge/internal/supervisor"
+	"github.com/jonandereg/streamforge/internal/worker"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+const tracerName = "github.com/jonandereg/streamforge/internal/pipeline"
+
+// Error policies of config.Stage.OnError.
+const (
+	PolicySkip  = "skip"
+	PolicyRetry = "retry"
+	PolicyDLQ   = "dlq"
+	PolicyHalt  = "halt"
+)
+
+// maxHaltBackoff caps the wait between attempts of a halted stage.
+const maxHaltBackoff = 30 * time.Second
+
+// Factory creates the processor for a stage.
+type Factory func(st config.Stage) (worker.Processor, error)
+
+// Registry maps stage types to their factories.
+type Registry map[string]Factory
+
+// Chain runs stages in order. A stage ending the message (a filter, or a
+// failure handled by its policy) stops the chain with worker.ErrSkip.
+type Chain struct {
+	stages []*stage
+	halted *atomic.Int64 // shared by all stages of the tree
+}
+
+// Build assembles stages into a Chain. Failed messages of stages with the
+// dlq or retry policy go to dlq.
+func Build(stages []config.Stage, reg Registry, dlq deadletter.Sink, log *zap.Logger) (*Chain, error) {
+	b := &builder{reg: reg, dlq: dlq, log: log.Named("pipeline"), halted: new(atomic.Int64)}
+	list, err := b.build(stages)
+	if err != nil {
+		return nil, err
+	}
+	return &Chain{stages: list, halted: b.halted}, nil
+}
+
+type builder struct {
+	reg    Registry
+	dlq    deadletter.Sink
+	log    *zap.Logger
+	halted *atomic.Int64
+}
+
+func (b *builder) build(stages []config.Stage) ([]*stage, error) {
+	out := make([]*stage, 0, len(stages))
+	for _, st := range stages {
+		var proc worker.Processor
+		switch st.Type {
+		case "chain":
+			children, err := b.build(st.Stages)
+			if err != nil {
+				return nil, err
+			}
+			proc = &Chain{stages: children, halted: b.halted}
+		case "fanout":
+			children, err := b.build(st.Stages)
+			if err != nil {
+				return nil, err
+			}
+			proc = fanout(children)
+		default:
+			f, ok := b.reg[st.Type]
+			if !ok {
+				return nil, fmt.Errorf("pipeline stage %q: unknown type %q", st.Name, st.Type)
+			}
+			p, err := f(st)
+			if err != nil {
+				return nil, fmt.Errorf("pipeline stage %q: %w", st.Name, err)
+			}
+			proc = p
+		}
+		policy := st.OnError
+		if policy == "" {
+			policy = PolicySkip
+		}
+		for _, r := range []string{"ok", "filtered", "retried", "skipped", "dead_lettered", "halted"} {
+			sfmetrics.PipelineStageResultsTotal.WithLabelValues(st.Name, r).Add(0)
+		}
+		sfmetrics.PipelineStageHalted.WithLabelValues(st.Name).Set(0)
+		out = append(out, &stage{
+			cfg:    st,
+			policy: policy,
+			proc:   proc,
+			dlq:    b.dlq,
+			halted: b.halted,
+			log:    b.log.With(zap.String("stage", st.Name)),
+		})
+	}
+	return out, nil
+}
+
+// Process implements worker.Processor.
+func (c *Chain) Process(ctx context.Context, msg events.TickMsg) error {
+	for _, s := range c.stages {
+		if err := s.Process(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start starts every stage processor that implements worker.Starter, in
+// order, stopping at the first error.
+func (c *Chain) Start(ctx context.Context) error {
+	for _, s := range c.stages {
+		if st, ok := s.proc.(worker.Starter); ok {
+			if err := st.Start(ctx); err != nil {
+				return fmt.Errorf("stage %s: %w", s.cfg.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Flush flushes every stage processor that implements worker.Flusher.
+func (c *Chain) Flush(ctx context.Context) error {
+	var errs []error
+	for _, s := range c.stages {
+		if f, ok := s.proc.(worker.Flusher); ok {
+			errs = append(errs, f.Flush(ctx))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every stage processor that implements worker.Closer.
+func (c *Chain) Close(ctx context.Context) error {
+	var errs []error
+	for _, s := range c.stages {
+		if cl, ok := s.proc.(worker.Closer); ok {
+			errs = append(errs, cl.Close(ctx))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Check fails while a stage with the halt policy is holding a message. It
+// is registered as a health check.
+func (c *Chain) Check(context.Context) error {
+	if n := c.halted.Load(); n > 0 {
+		return fmt.Errorf("%d worker(s) halted on a failing stage", n)
+	}
+	return nil
+}
+
+// Names lists the stages of the tree in order, children indented under
+// their parent by two spaces.
+func (c *Chain) Names() []string {
+	var out []string
+	var walk func(stages []*stage, indent string)
+	walk = func(stages []*stage, indent string) {
+		for _, s := range stages {
+			out = append(out, indent+s.cfg.Name+" ("+s.cfg.Type+", "+s.policy+")")
+			switch p := s.proc.(type) {
+			case *Chain:
+				walk(p.stages, indent+"  ")
+			case fanout:
+				walk(p, indent+"  ")
+			}
+		}
+	}
+	walk(c.stages, "")
+	return out
+}
+
+// fanout runs its stages concurrently on the same message. A stage ending
+// the message only ends its own branch.
+type fanout []*stage
+
+// Process implements worker.Processor. A panic in a branch is returned as
+// a *supervisor.PanicError instead of crashing the process.
+func (f fanout) Process(ctx context.Context, msg events.TickMsg) error {
+	errs := make([]error, len(f))
+	var wg sync.WaitGroup
+	for i, s := range f {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer supervisor.Recover(&errs[i])
+			if err := s.Process(ctx, msg); err != nil && !errors.Is(err, worker.ErrSkip) {
+				errs[i] = err
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Start starts every branch processor that implements worker.Starter.
+func (f fanout) Start(ctx context.Context) error {
+	return (&Chain{stages: f}).Start(ctx)
+}
+
+// Flush flushes every branch processor that implements worker.Flusher.
+func (f fanout) Flush(ctx context.Context) error {
+	return (&Chain{stages: f}).Flush(ctx)
+}
+
+// Close closes every branch processor that implements worker.Closer.
+func (f fanout) Close(ctx context.Context) error {
+	return (&Chain{stages: f}).Close(ctx)
+}
+
+// stage wraps a processor with its timeout, error policy, span and metrics.
+type stage struct {
+	cfg    config.Stage
+	policy string
+	proc   worker.Processor
+	dlq    deadletter.Sink
+	halted *atomic.Int64
+	log    *zap.Logger
+}
+
+// Process runs the stage and resolves a failure according to its policy.
+// It returns nil to continue the chain, an error wrapping worker.ErrSkip to
+// end it, or a panic or context error for the worker to handle.
+func (s *stage) Process(ctx context.Context, msg events.TickMsg) (err error) {
+	start := time.Now()
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "pipeline.stage")
+	span.SetAttributes(
+		attribute.String("stage", s.cfg.Name),
+		attribute.String("stage.type", s.cfg.Type),
+	)
+	msg.SpanContext = span.SpanContext()
+	defer func() {
+		sfmetrics.PipelineStageLatencySeconds.WithLabelValues(s.cfg.Name).Observe(time.Since(start).Seconds())
+		if err != nil && !errors.Is(err, worker.ErrSkip) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "stage")
+		}
+		span.End()
+	}()
+
+	err = s.call(ctx, msg)
+	switch {
+	case err == nil:
+		s.result("ok")
+		return nil
+	case errors.Is(err, worker.ErrSkip):
+		s.result("filtered")
+		return err
+	case isFatal(err):
+		return err
+	}
+
+	switch s.policy {
+	case PolicyRetry:
+		err = s.retry(ctx, msg, err)
+		if err == nil || errors.Is(err, worker.ErrSkip) || isFatal(err) {
+			return err
+		}
+		return s.deadLetter(ctx, msg, err)
+	case PolicyDLQ:
+		return s.deadLetter(ctx, msg, err)
+	case PolicyHalt:
+		return s.halt(ctx, msg, err)
+	default:
+		s.result("skipped")
+		s.log.Warn("stage failed, message skipped",
+			zap.String("symbol", msg.Tick.Symbol),
+			zap.Error(err),
+		)
+		return fmt.Errorf("%w: stage %s: %w", worker.ErrSkip, s.cfg.Name, err)
+	}
+}
+
+// call runs the processor once within the stage timeout.
+func (s *stage) call(ctx context.Context, msg events.TickMsg) error {
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+	return s.proc.Process(ctx, msg)
+}
+
+// retry calls the processor up to Retries more times after err.
+func (s *stage) retry(ctx context.Context, msg events.TickMsg, err error) error {
+	for range s.cfg.Retries {
+		s.result("retried")
+		if werr := wait(ctx, s.cfg.RetryBackoff); werr != nil {
+			return werr
+		}
+		if err = s.call(ctx, msg); err == nil {
+			s.result("ok")
+			return nil
+		}
+		if errors.Is(err, worker.ErrSkip) || isFatal(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// halt retries the message with growing backoff until it succeeds or ctx
+// ends, keeping the worker (and the partition's committed offset) on it.
+func (s *stage) halt(ctx context.Context, msg events.TickMsg, err error) error {
+	s.halted.Add(1)
+	gauge := sfmetrics.PipelineStageHalted.WithLabelValues(s.cfg.Name)
+	gauge.Inc()
+	defer func() {
+		s.halted.Add(-1)
+		gauge.Dec()
+	}()
+	backoff := max(s.cfg.RetryBackoff, 100*time.Millisecond)
+	for attempt := 1; ; attempt++ {
+		s.result("halted")
+		s.log.Error("stage failed, halting worker until it succeeds",
+			zap.String("symbol", msg.Tick.Symbol),
+			zap.Int("attempt", attempt),
+			zap.Duration("retry_in", backoff),
+			zap.Error(err),
+		)
+		if werr := wait(ctx, backoff); werr != nil {
+			return werr
+		}
+		backoff = min(2*backoff, maxHaltBackoff)
+		if err = s.call(ctx, msg); err == nil {
+			s.result("ok")
+			return nil
+		}
+		if errors.Is(err, worker.ErrSkip) || isFatal(err) {
+			return err
+		}
+	}
+}
+
+func (s *stage) deadLetter(ctx context.Context, msg events.TickMsg, cause error) error {
+	s.result("dead_lettered")
+	if err := s.dlq.Send(ctx, msg, "stage:"+s.cfg.Name, cause); err != nil {
+		return fmt.Errorf("stage %s: dead letter: %w (after %w)", s.cfg.Name, err, cause)
+	}
+	return fmt.Errorf("%w: stage %s dead-lettered: %w", worker.ErrSkip, s.cfg.Name, cause)
+}
+
+func (s *stage) result(r string) {
+	sfmetrics.PipelineStageResultsTotal.WithLabelValues(s.cfg.Name, r).Inc()
+}
+
+// isFatal reports errors no policy applies to: panics, which the worker
+// dead-letters before restarting, and the worker's own context ending.
+func isFatal(err error) bool {
+	var pe *supervisor.PanicError
+	return errors.As(err, &pe) || errors.Is(err, context.Canceled)
+}
+
+func wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,160 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/events"
+	"github.com/jonandereg/streamforge/internal/supervisor"
+	"github.com/jonandereg/streamforge/internal/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fake fails its first fails calls, then records the message.
+type fake struct {
+	fails atomic.Int32
+	err   error
+	delay time.Duration
+	calls atomic.Int32
+	seen  atomic.Int32
+}
+
+func (f *fake) Process(ctx context.Context, _ events.TickMsg) error {
+	f.calls.Add(1)
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if f.fails.Add(-1) >= 0 {
+		return f.err
+	}
+	f.seen.Add(1)
+	return nil
+}
+
+type recordingDLQ struct {
+	mu      sync.Mutex
+	reasons []string
+}
+
+func (d *recordingDLQ) Send(_ context.Context, _ events.TickMsg, reason string, _ error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reasons = append(d.reasons, reason)
+	return nil
+}
+
+func build(t *testing.T, stages []config.Stage, procs map[string]worker.Processor, dlq *recordingDLQ) *Chain {
+	t.Helper()
+	reg := Registry{"fake": func(st config.Stage) (worker.Processor, error) { return procs[st.Name], nil }}
+	c, err := Build(stages, reg, dlq, zap.NewNop())
+	require.NoError(t, err)
+	return c
+}
+
+func failing(n int32) *fake {
+	f := &fake{err: errors.New("boom")}
+	f.fails.Store(n)
+	return f
+}
+
+func TestPolicies(t *testing.T) {
+	ctx := context.Background()
+	msg := events.TickMsg{}
+
+	t.Run("skip ends the chain", func(t *testing.T) {
+		a, b := failing(1), &fake{}
+		c := build(t, []config.Stage{{Name: "a", Type: "fake"}, {Name: "b", Type: "fake"}},
+			map[string]worker.Processor{"a": a, "b": b}, &recordingDLQ{})
+		assert.ErrorIs(t, c.Process(ctx, msg), worker.ErrSkip)
+		assert.Zero(t, b.calls.Load())
+	})
+
+	t.Run("retry recovers", func(t *testing.T) {
+		a, dlq := failing(2), &recordingDLQ{}
+		c := build(t, []config.Stage{{Name: "a", Type: "fake", OnError: PolicyRetry, Retries: 2}},
+			map[string]worker.Processor{"a": a}, dlq)
+		assert.NoError(t, c.Process(ctx, msg))
+		assert.Equal(t, int32(3), a.calls.Load())
+		assert.Empty(t, dlq.reasons)
+	})
+
+	t.Run("retry exhausted dead-letters", func(t *testing.T) {
+		a, dlq := failing(5), &recordingDLQ{}
+		c := build(t, []config.Stage{{Name: "a", Type: "fake", OnError: PolicyRetry, Retries: 1}},
+			map[string]worker.Processor{"a": a}, dlq)
+		assert.ErrorIs(t, c.Process(ctx, msg), worker.ErrSkip)
+		assert.Equal(t, []string{"stage:a"}, dlq.reasons)
+	})
+
+	t.Run("halt holds the message until it succeeds", func(t *testing.T) {
+		a := failing(2)
+		c := build(t, []config.Stage{{Name: "a", Type: "fake", OnError: PolicyHalt, RetryBackoff: time.Millisecond}},
+			map[string]worker.Processor{"a": a}, &recordingDLQ{})
+		assert.NoError(t, c.Process(ctx, msg))
+		assert.Equal(t, int32(1), a.seen.Load())
+		assert.NoError(t, c.Check(ctx))
+	})
+
+	t.Run("halt reports unhealthy and stops on cancel", func(t *testing.T) {
+		c := build(t, []config.Stage{{Name: "a", Type: "fake", OnError: PolicyHalt}},
+			map[string]worker.Processor{"a": failing(1000)}, &recordingDLQ{})
+		hctx, cancel := context.WithCancel(ctx)
+		done := make(chan error)
+		go func() { done <- c.Process(hctx, msg) }()
+		assert.Eventually(t, func() bool { return c.Check(ctx) != nil }, time.Second, time.Millisecond)
+		cancel()
+		assert.ErrorIs(t, <-done, context.Canceled)
+		assert.NoError(t, c.Check(ctx))
+	})
+
+	t.Run("timeout counts as failure", func(t *testing.T) {
+		dlq := &recordingDLQ{}
+		c := build(t, []config.Stage{{Name: "a", Type: "fake", Timeout: time.Millisecond, OnError: PolicyDLQ}},
+			map[string]worker.Processor{"a": &fake{delay: time.Second}}, dlq)
+		assert.ErrorIs(t, c.Process(ctx, msg), worker.ErrSkip)
+		assert.Equal(t, []string{"stage:a"}, dlq.reasons)
+	})
+
+	t.Run("panics reach the worker", func(t *testing.T) {
+		c := build(t, []config.Stage{{Name: "f", Type: "fanout", Stages: []config.Stage{{Name: "a", Type: "fake"}}}},
+			map[string]worker.Processor{"a": panicking{}}, &recordingDLQ{})
+		var pe *supervisor.PanicError
+		assert.ErrorAs(t, c.Process(ctx, msg), &pe)
+	})
+}
+
+type panicking struct{}
+
+func (panicking) Process(context.Context, events.TickMsg) error { panic("bad stage") }
+
+func TestFanoutBranchesAreIndependent(t *testing.T) {
+	a, b, after := failing(1), &fake{}, &fake{}
+	c := build(t, []config.Stage{
+		{Name: "f", Type: "fanout", Stages: []config.Stage{
+			{Name: "a", Type: "fake"},
+			{Name: "branch", Type: "chain", Stages: []config.Stage{{Name: "b", Type: "fake"}}},
+		}},
+		{Name: "after", Type: "fake"},
+	}, map[string]worker.Processor{"a": a, "b": b, "after": after}, &recordingDLQ{})
+
+	require.NoError(t, c.Process(context.Background(), events.TickMsg{}))
+	assert.Equal(t, int32(1), b.seen.Load())
+	assert.Equal(t, int32(1), after.seen.Load(), "a skipped only its own branch")
+	assert.Equal(t, []string{"f (fanout, skip)", "  a (fake, skip)", "  branch (chain, skip)", "    b (fake, skip)", "after (fake, skip)"}, c.Names())
+}
+
+func TestBuildUnknownType(t *testing.T) {
+	_, err := Build([]config.Stage{{Name: "x", Type: "nope"}}, Registry{}, &recordingDLQ{}, zap.NewNop())
+	assert.ErrorContains(t, err, `unknown type "nope"`)
+}
@@ -4,14 +4,19 @@ import (
 	"context"
 
 	"github.com/jonandereg/streamforge/internal/events"
+	"go.opentelemetry.io/otel"
 	"go.uber.org/zap"
 )
 
+const tracerName = "github.com/jonandereg/streamforge/internal/processing"
+
 type NoopProcessor struct {
 	Log *zap.Logger
 }
 
 func (p *NoopProcessor) Process(ctx context.Context, msg events.TickMsg) error {
+	_, span := otel.Tracer(tracerName).Start(ctx, "processor.noop")
+	defer span.End()
 
 	if msg.Tick.Symbol == "" {
 		if p.Log != nil {
@@ -0,0 +1,431 @@
+// Package window groups ticks into event-time windows keyed by symbol (or
+// any other key), so bar builders, VWAP, volume profiles and alerting
+// stages share one implementation of time bucketing.
+//
+// Windows are tumbling, hopping or session windows over model.Tick.Ts. Each
+// Kafka partition has its own watermark, the newest event time seen on it
+// minus Spec.MaxOutOfOrder; a key's windows fire once the watermark of its
+// partition passes their end. A fired window is kept for
+// Spec.AllowedLateness and re-emitted as an update for each late tick;
+// ticks arriving after that are rejected with ErrLate for the caller to
+// route to a side output. With Spec.Early, open windows are also emitted as
+// each tick lands in them, for callers acting on partial windows.
+package window
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/events"
+	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
+	"github.com/jonandereg/streamforge/internal/model"
+)
+
+// Kind selects how ticks are assigned to windows.
+type Kind string
+
+// Window kinds.
+const (
+	// Tumbling windows are fixed, non-overlapping intervals of Size.
+	Tumbling Kind = "tumbling"
+	// Hopping windows of Size start every Slide, so a tick may fall into
+	// several of them.
+	Hopping Kind = "hopping"
+	// Session windows group ticks separated by less than Gap.
+	Session Kind = "session"
+)
+
+// ErrLate is returned for a tick whose windows have all been purged.
+var ErrLate = errors.New("tick is later than the allowed lateness")
+
+// Spec describes the windows and their watermark.
+type Spec struct {
+	Kind  Kind
+	Size  time.Duration // tumbling and hopping
+	Slide time.Duration // hopping
+	Gap   time.Duration // session
+	// MaxOutOfOrder holds the watermark back from the newest event time of
+	// a partition, giving out-of-order ticks time to arrive before a
+	// window fires.
+	MaxOutOfOrder time.Duration
+	// AllowedLateness keeps fired windows open for late ticks, each of
+	// which re-emits the window as an update.
+	AllowedLateness time.Duration
+	// IdleTimeout lets Advance move the watermark of a partition without
+	// ticks for this long along the wall clock; 0 disables it.
+	IdleTimeout time.Duration
+	// Origin aligns tumbling and hopping windows, which start at Origin
+	// plus a multiple of the slide (Size for tumbling). The zero Origin
+	// aligns them as time.Time.Truncate does.
+	Origin time.Time
+	// Early makes Add also return the open windows a tick lands in, as
+	// results marked Early.
+	Early bool
+}
+
+// Validate checks that the durations needed by Kind are set.
+func (s Spec) Validate() error {
+	var errs []error
+	switch s.Kind {
+	case Tumbling:
+		if s.Size <= 0 {
+			errs = append(errs, errors.New("size must be > 0"))
+		}
+	case Hopping:
+		if s.Size <= 0 || s.Slide <= 0 || s.Slide > s.Size {
+			errs = append(errs, errors.New("size and slide must be > 0 with slide <= size"))
+		}
+	case Session:
+		if s.Gap <= 0 {
+			errs = append(errs, errors.New("gap must be > 0"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown kind %q", s.Kind))
+	}
+	if s.MaxOutOfOrder < 0 || s.AllowedLateness < 0 || s.IdleTimeout < 0 {
+		errs = append(errs, errors.New("max_out_of_order, allowed_lateness and idle_timeout must be >= 0"))
+	}
+	return errors.Join(errs...)
+}
+
+// Window is the half-open event-time interval [Start, End).
+type Window struct {
+	Start, End time.Time
+}
+
+// Assign returns the tumbling or hopping windows containing ts, oldest
+// first. Session windows depend on the ticks around ts, so it returns nil
+// for them.
+func (s Spec) Assign(ts time.Time) []Window {
+	slide := s.Size
+	switch s.Kind {
+	case Hopping:
+		slide = s.Slide
+	case Session:
+		return nil
+	}
+	last := ts.Truncate(slide)
+	if !s.Origin.IsZero() {
+		off := ts.Sub(s.Origin) % slide
+		if off < 0 {
+			off += slide
+		}
+		last = ts.Add(-off)
+	}
+	var out []Window
+	for start := last; start.Add(s.Size).After(ts); start = start.Add(-slide) {
+		out = append(out, Window{Start: start, End: start.Add(s.Size)})
+	}
+	slices.Reverse(out)
+	return out
+}
+
+// Aggregator folds ticks into a window's accumulator, which starts as the
+// zero value of A. Merge combines two accumulators when session windows
+// merge; it is required for Session only.
+type Aggregator[A any] struct {
+	Add   func(acc A, t model.Tick) A
+	Merge func(a, b A) A
+}
+
+// Result is a window emitted when the watermark passes its end, or again as
+// an Update after a late tick within the allowed lateness. With Spec.Early,
+// a window is also emitted as Early for every tick before it fires.
+type Result[A any] struct {
+	Key    string
+	Window Window
+	Value  A
+	Update bool
+	Early  bool
+}
+
+// Windower assigns ticks to windows per key and emits them as watermarks
+// advance. It is safe for concurrent use.
+type Windower[A any] struct {
+	name string // metric label
+	spec Spec
+	agg  Aggregator[A]
+
+	mu    sync.Mutex
+	parts map[int]*partition
+	keys  map[string]*keyState[A]
+}
+
+type partition struct {
+	id        int
+	maxTs     time.Time // newest event time seen
+	watermark time.Time
+	seen      time.Time // wall clock of the last tick, for IdleTimeout
+	keys      map[string]bool
+	next      time.Time // earliest end or purge time due; zero when none
+}
+
+type keyState[A any] struct {
+	partition int
+	panes     []*pane[A] // by window start
+}
+
+type pane[A any] struct {
+	w     Window
+	acc   A
+	fired bool
+}
+
+// New creates a Windower named name (the metric label) for spec.
+func New[A any](name string, spec Spec, agg Aggregator[A]) (*Windower[A], error) {
+	if err := spec.Validate(); err != nil {
+		return nil, fmt.Errorf("window %s: %w", name, err)
+	}
+	if agg.Add == nil || spec.Kind == Session && agg.Merge == nil {
+		return nil, fmt.Errorf("window %s: aggregator needs Add, and Merge for session windows", name)
+	}
+	for _, k := range []string{"fired", "updated", "early"} {
+		sfmetrics.WindowResultsTotal.WithLabelValues(name, k).Add(0)
+	}
+	sfmetrics.WindowLateTotal.WithLabelValues(name).Add(0)
+	return &Windower[A]{
+		name:  name,
+		spec:  spec,
+		agg:   agg,
+		parts: make(map[int]*partition),
+		keys:  make(map[string]*keyState[A]),
+	}, nil
+}
+
+// Add assigns t to the windows of key and returns the windows that became
+// due: those the new watermark of at.Partition has passed, plus updates of
+// already fired windows t landed in and, with Spec.Early, the open ones. It
+// returns ErrLate, and changes no window, when all windows of t were purged.
+func (w *Windower[A]) Add(key string, t model.Tick, at events.KafkaMeta) ([]Result[A], error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	p := w.partition(at.Partition)
+	p.seen = time.Now()
+
+	ks := w.keys[key]
+	if ks == nil {
+		ks = &keyState[A]{partition: p.id}
+		w.keys[key] = ks
+	}
+	if ks.partition != p.id {
+		delete(w.parts[ks.partition].keys, key)
+		ks.partition = p.id
+	}
+	p.keys[key] = true
+
+	var out []Result[A]
+	if w.spec.Kind == Session {
+		out = w.addSession(key, ks, t, p)
+	} else {
+		out = w.addFixed(key, ks, t, p)
+	}
+	if out == nil && len(ks.panes) == 0 {
+		delete(w.keys, key)
+		delete(p.keys, key)
+	}
+	if out == nil {
+		sfmetrics.WindowLateTotal.WithLabelValues(w.name).Inc()
+		return nil, fmt.Errorf("%w: %s at %s, watermark %s", ErrLate, key, t.Ts.Format(time.RFC3339Nano), p.watermark.Format(time.RFC3339Nano))
+	}
+
+	if t.Ts.After(p.maxTs) {
+		p.maxTs = t.Ts
+	}
+	w.advance(p, p.maxTs.Add(-w.spec.MaxOutOfOrder))
+	out = append(out, w.due(p)...)
+	sortResults(out)
+	return out, nil
+}
+
+// Advance moves the watermark of every partition that has been idle for
+// IdleTimeout to now minus MaxOutOfOrder and returns the windows that
+// became due. Call it periodically, e.g. from a stage's Flush.
+func (w *Windower[A]) Advance(now time.Time) []Result[A] {
+	if w.spec.IdleTimeout <= 0 {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var out []Result[A]
+	for _, p := range w.parts {
+		if now.Sub(p.seen) >= w.spec.IdleTimeout {
+			w.advance(p, now.Add(-w.spec.MaxOutOfOrder))
+			out = append(out, w.due(p)...)
+		}
+	}
+	sortResults(out)
+	return out
+}
+
+// Watermark returns the current watermark of partition.
+func (w *Windower[A]) Watermark(partition int) time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if p, ok := w.parts[partition]; ok {
+		return p.watermark
+	}
+	return time.Time{}
+}
+
+func (w *Windower[A]) partition(id int) *partition {
+	p, ok := w.parts[id]
+	if !ok {
+		p = &partition{id: id, keys: make(map[string]bool)}
+		w.parts[id] = p
+	}
+	return p
+}
+
+// addFixed adds t to its tumbling or hopping windows. It returns a non-nil
+// slice (possibly empty) when t landed in a window, nil when it was late.
+func (w *Windower[A]) addFixed(key string, ks *keyState[A], t model.Tick, p *partition) []Result[A] {
+	var out []Result[A]
+	for _, win := range w.spec.Assign(t.Ts) {
+		if w.purged(win, p) {
+			continue
+		}
+		i, found := slices.BinarySearchFunc(ks.panes, win.Start, func(pn *pane[A], s time.Time) int { return pn.w.Start.Compare(s) })
+		if !found {
+			ks.panes = slices.Insert(ks.panes, i, &pane[A]{w: win})
+			w.schedule(p, win.End)
+		}
+		pn := ks.panes[i]
+		pn.acc = w.agg.Add(pn.acc, t)
+		if out == nil {
+			out = []Result[A]{}
+		}
+		if pn.fired {
+			out = append(out, w.update(key, pn))
+		} else if w.spec.Early {
+			out = append(out, w.early(key, pn))
+		}
+	}
+	return out
+}
+
+// addSession merges t into the sessions it extends or starts a new one.
+func (w *Windower[A]) addSession(key string, ks *keyState[A], t model.Tick, p *partition) []Result[A] {
+	win := Window{Start: t.Ts, End: t.Ts.Add(w.spec.Gap)}
+	merged := &pane[A]{w: win}
+	rest := ks.panes[:0:0]
+	for _, pn := range ks.panes {
+		if pn.w.Start.Before(win.End) && win.Start.Before(pn.w.End) {
+			merged.acc = w.agg.Merge(merged.acc, pn.acc)
+			merged.fired = merged.fired || pn.fired
+			merged.w.Start = minTime(merged.w.Start, pn.w.Start)
+			merged.w.End = maxTime(merged.w.End, pn.w.End)
+			continue
+		}
+		rest = append(rest, pn)
+	}
+	if len(rest) == len(ks.panes) && w.purged(win, p) {
+		return nil
+	}
+	merged.acc = w.agg.Add(merged.acc, t)
+	i, _ := slices.BinarySearchFunc(rest, merged.w.Start, func(pn *pane[A], s time.Time) int { return pn.w.Start.Compare(s) })
+	ks.panes = slices.Insert(rest, i, merged)
+	w.schedule(p, merged.w.End)
+	if merged.fired {
+		return []Result[A]{w.update(key, merged)}
+	}
+	if w.spec.Early {
+		return []Result[A]{w.early(key, merged)}
+	}
+	return []Result[A]{}
+}
+
+// purged reports whether win is past its allowed lateness.
+func (w *Windower[A]) purged(win Window, p *partition) bool {
+	return !win.End.Add(w.spec.AllowedLateness).After(p.watermark)
+}
+
+func (w *Windower[A]) advance(p *partition, wm time.Time) {
+	if wm.After(p.watermark) {
+		p.watermark = wm
+		sfmetrics.WindowWatermarkSeconds.WithLabelValues(w.name, strconv.Itoa(p.id)).Set(float64(wm.UnixMilli()) / 1000)
+	}
+}
+
+// schedule makes sure due runs once the watermark reaches at.
+func (w *Windower[A]) schedule(p *partition, at time.Time) {
+	if p.next.IsZero() || at.Before(p.next) {
+		p.next = at
+	}
+}
+
+// due fires the windows of p the watermark has passed and purges those past
+// their allowed lateness. Keys are only scanned once something is due.
+func (w *Windower[A]) due(p *partition) []Result[A] {
+	if p.next.IsZero() || p.next.After(p.watermark) {
+		return nil
+	}
+	p.next = time.Time{}
+	var out []Result[A]
+	for key := range p.keys {
+		ks := w.keys[key]
+		kept := ks.panes[:0]
+		for _, pn := range ks.panes {
+			if !pn.fired && !pn.w.End.After(p.watermark) {
+				pn.fired = true
+				sfmetrics.WindowResultsTotal.WithLabelValues(w.name, "fired").Inc()
+				out = append(out, Result[A]{Key: key, Window: pn.w, Value: pn.acc})
+			}
+			if w.purged(pn.w, p) {
+				continue
+			}
+			kept = append(kept, pn)
+			if pn.fired {
+				w.schedule(p, pn.w.End.Add(w.spec.AllowedLateness))
+			} else {
+				w.schedule(p, pn.w.End)
+			}
+		}
+		clear(ks.panes[len(kept):])
+		ks.panes = kept
+		if len(kept) == 0 {
+			delete(w.keys, key)
+			delete(p.keys, key)
+		}
+	}
+	sortResults(out)
+	return out
+}
+
+func (w *Windower[A]) update(key string, pn *pane[A]) Result[A] {
+	sfmetrics.WindowResultsTotal.WithLabelValues(w.name, "updated").Inc()
+	return Result[A]{Key: key, Window: pn.w, Value: pn.acc, Update: true}
+}
+
+func (w *Windower[A]) early(key string, pn *pane[A]) Result[A] {
+	sfmetrics.WindowResultsTotal.WithLabelValues(w.name, "early").Inc()
+	return Result[A]{Key: key, Window: pn.w, Value: pn.acc, Early: true}
+}
+
+// sortResults orders results by window end, then key.
+func sortResults[A any](rs []Result[A]) {
+	slices.SortFunc(rs, func(a, b Result[A]) int {
+		if c := a.Window.End.Compare(b.Window.End); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
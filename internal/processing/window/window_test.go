@@ -0,0 +1,125 @@
+package window
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/events"
+	"github.com/jonandereg/streamforge/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var t0 = time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+
+// volume sums tick sizes.
+var volume = Aggregator[float64]{
+	Add:   func(acc float64, t model.Tick) float64 { return acc + t.Size },
+	Merge: func(a, b float64) float64 { return a + b },
+}
+
+func sec(s float64) time.Time { return t0.Add(time.Duration(s * float64(time.Second))) }
+
+func add(t *testing.T, w *Windower[float64], key string, ts time.Time, partition int) []Result[float64] {
+	t.Helper()
+	out, err := w.Add(key, model.Tick{Symbol: key, Ts: ts, Size: 1}, events.KafkaMeta{Partition: partition})
+	require.NoError(t, err)
+	return out
+}
+
+func TestTumblingFiresOnWatermark(t *testing.T) {
+	w, err := New("test", Spec{Kind: Tumbling, Size: 10 * time.Second, MaxOutOfOrder: 2 * time.Second}, volume)
+	require.NoError(t, err)
+
+	assert.Empty(t, add(t, w, "AAPL", sec(1), 0))
+	assert.Empty(t, add(t, w, "AAPL", sec(11), 0), "watermark 9s has not passed 10s")
+	assert.Empty(t, add(t, w, "AAPL", sec(9), 0), "out of order but before the watermark")
+	out := add(t, w, "AAPL", sec(12.5), 0)
+	require.Len(t, out, 1)
+	assert.Equal(t, Result[float64]{Key: "AAPL", Window: Window{Start: sec(0), End: sec(10)}, Value: 2}, out[0])
+
+	_, err = w.Add("AAPL", model.Tick{Ts: sec(5), Size: 1}, events.KafkaMeta{})
+	assert.ErrorIs(t, err, ErrLate)
+}
+
+func TestPartitionsHaveOwnWatermarks(t *testing.T) {
+	w, err := New("test", Spec{Kind: Tumbling, Size: 10 * time.Second}, volume)
+	require.NoError(t, err)
+	add(t, w, "AAPL", sec(1), 0)
+	add(t, w, "MSFT", sec(1), 1)
+	out := add(t, w, "AAPL", sec(10), 0)
+	require.Len(t, out, 1, "only partition 0 advanced")
+	assert.Equal(t, "AAPL", out[0].Key)
+	assert.Equal(t, sec(10), w.Watermark(0))
+	assert.True(t, w.Watermark(1).Equal(sec(1)))
+}
+
+func TestHoppingAndLateness(t *testing.T) {
+	w, err := New("test", Spec{Kind: Hopping, Size: 10 * time.Second, Slide: 5 * time.Second, AllowedLateness: 5 * time.Second}, volume)
+	require.NoError(t, err)
+	add(t, w, "AAPL", sec(7), 0) // in [0,10) and [5,15)
+	out := add(t, w, "AAPL", sec(10), 0)
+	require.Len(t, out, 1)
+	assert.Equal(t, Window{Start: sec(0), End: sec(10)}, out[0].Window)
+
+	out = add(t, w, "AAPL", sec(8), 0)
+	require.Len(t, out, 1, "late tick updates the fired window")
+	assert.True(t, out[0].Update)
+	assert.Equal(t, 2.0, out[0].Value)
+
+	out = add(t, w, "AAPL", sec(16), 0)
+	require.Len(t, out, 1)
+	assert.Equal(t, Window{Start: sec(5), End: sec(15)}, out[0].Window)
+	assert.Equal(t, 3.0, out[0].Value)
+
+	_, err = w.Add("AAPL", model.Tick{Ts: sec(4)}, events.KafkaMeta{})
+	assert.ErrorIs(t, err, ErrLate, "[0,10) purged at watermark 15")
+}
+
+func TestSessionsMerge(t *testing.T) {
+	w, err := New("test", Spec{Kind: Session, Gap: 5 * time.Second, MaxOutOfOrder: 5 * time.Second, IdleTimeout: time.Minute}, volume)
+	require.NoError(t, err)
+	add(t, w, "AAPL", sec(0), 0)
+	add(t, w, "AAPL", sec(8), 0)
+	assert.Empty(t, add(t, w, "AAPL", sec(4), 0), "bridges both sessions")
+	out := add(t, w, "AAPL", sec(20), 0)
+	require.Len(t, out, 1)
+	assert.Equal(t, Result[float64]{Key: "AAPL", Window: Window{Start: sec(0), End: sec(13)}, Value: 3}, out[0])
+
+	assert.Empty(t, w.Advance(time.Now()), "not idle yet")
+	out = w.Advance(time.Now().Add(2 * time.Minute))
+	require.Len(t, out, 1, "idle partition follows the wall clock")
+	assert.Equal(t, sec(20), out[0].Window.Start)
+}
+
+func TestSpecValidate(t *testing.T) {
+	assert.Error(t, Spec{Kind: Hopping, Size: time.Second, Slide: 2 * time.Second}.Validate())
+	assert.Error(t, Spec{Kind: "sliding", Size: time.Second}.Validate())
+	_, err := New("test", Spec{Kind: Session, Gap: time.Second}, Aggregator[float64]{Add: volume.Add})
+	assert.ErrorContains(t, err, "Merge")
+}
+
+func TestAssignFromOrigin(t *testing.T) {
+	open := t0.Add(30 * time.Minute) // 15:30
+	s := Spec{Kind: Hopping, Size: time.Hour, Slide: 30 * time.Minute, Origin: open}
+	assert.Equal(t, []Window{
+		{Start: open.Add(-30 * time.Minute), End: open.Add(30 * time.Minute)},
+		{Start: open, End: open.Add(time.Hour)},
+	}, s.Assign(open.Add(10*time.Minute)))
+	assert.Equal(t, []Window{{Start: open.Add(-time.Hour), End: open}},
+		Spec{Kind: Tumbling, Size: time.Hour, Origin: open}.Assign(open.Add(-time.Minute)), "before the origin")
+	assert.Nil(t, Spec{Kind: Session, Gap: time.Second}.Assign(open))
+}
+
+func TestEarlyResults(t *testing.T) {
+	w, err := New("test", Spec{Kind: Tumbling, Size: 10 * time.Second, Early: true}, volume)
+	require.NoError(t, err)
+	out := add(t, w, "AAPL", sec(1), 0)
+	require.Len(t, out, 1)
+	assert.Equal(t, Result[float64]{Key: "AAPL", Window: Window{Start: sec(0), End: sec(10)}, Value: 1, Early: true}, out[0])
+
+	out = add(t, w, "AAPL", sec(10), 0)
+	require.Len(t, out, 2)
+	assert.Equal(t, Result[float64]{Key: "AAPL", Window: Window{Start: sec(0), End: sec(10)}, Value: 1}, out[0], "fired")
+	assert.Equal(t, Result[float64]{Key: "AAPL", Window: Window{Start: sec(10), End: sec(20)}, Value: 1, Early: true}, out[1])
+}
@@ -4,51 +4,254 @@ package finnhub
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/jonandereg/streamforge/internal/calendar"
+	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
 	"github.com/jonandereg/streamforge/internal/model"
+	"github.com/jonandereg/streamforge/internal/refdata"
+	"github.com/jonandereg/streamforge/internal/secrets"
 	"go.uber.org/zap"
 )
 
+// SrcID identifies Finnhub in Tick.SrcID and in symbol master aliases.
+const SrcID = "finnhub"
+
 // WSConfig holds configuration for the Finnhub WebSocket client.
 type WSConfig struct {
 	BaseURL       string
-	APIKey        string
+	APIKey        secrets.Value
 	Symbols       []string
 	ReconnectBase time.Duration // e.g. 200 * time.Millisecond
 	ReconnectMax  time.Duration // e.g. 5 * time.Second
+	StaleAfter    time.Duration // 0 disables stale-feed detection
+	// Instruments maps Finnhub symbols to canonical IDs; trades of
+	// instruments it does not list are dropped. Nil passes symbols through.
+	Instruments *refdata.Master
 }
 
 // Provider implements a Finnhub WebSocket client for streaming market data.
 type Provider struct {
 	cfg WSConfig
 	log *zap.Logger
+
+	mu          sync.Mutex // guards the fields below; held for every write to conn
+	symbols     []string
+	apiKey      secrets.Value
+	conn        *websocket.Conn
+	connectedAt time.Time
+	connects    int   // successful dials since start
+	lastErr     error // most recent dial or read error
+
+	feedMu    sync.Mutex // guards the fields below
+	calendars *calendar.Registry
+	lastTick  map[string]time.Time // local receive time by symbol
 }
 
 // New creates a new Finnhub WebSocket provider with the given configuration.
 func New(cfg WSConfig, log *zap.Logger) *Provider {
-	syms := make([]string, 0, len(cfg.Symbols))
+	cfg.Symbols = normalizeSymbols(cfg.Symbols, cfg.Instruments)
+
+	if cfg.ReconnectBase <= 0 {
+		cfg.ReconnectBase = 200 * time.Millisecond
+	}
+	if cfg.ReconnectMax <= 0 {
+		cfg.ReconnectMax = 5 * time.Second
+	}
+	return &Provider{
+		cfg:       cfg,
+		log:       log,
+		symbols:   cfg.Symbols,
+		apiKey:    cfg.APIKey,
+		calendars: calendar.Default(),
+		lastTick:  make(map[string]time.Time),
+	}
+}
 
-	for _, s := range cfg.Symbols {
-		s = strings.ToUpper(strings.TrimSpace(s))
-		if s != "" {
+// normalizeSymbols upper-cases and dedupes symbols, mapping Finnhub
+// symbols to canonical IDs so subscriptions can be given in either form.
+func normalizeSymbols(in []string, m *refdata.Master) []string {
+	syms := make([]string, 0, len(in))
+	for _, s := range in {
+		s = m.Canonical(SrcID, strings.ToUpper(strings.TrimSpace(s)))
+		if s != "" && !slices.Contains(syms, s) {
 			syms = append(syms, s)
 		}
+	}
+	return syms
+}
+
+// SetSymbols replaces the subscribed symbols. On a live connection only the
+// difference is sent; otherwise the new list is used on the next connect.
+func (p *Provider) SetSymbols(symbols []string) {
+	next := normalizeSymbols(symbols, p.cfg.Instruments)
 
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	prev := p.symbols
+	p.symbols = next
+	p.feedMu.Lock()
+	for _, s := range next {
+		if !slices.Contains(prev, s) {
+			p.lastTick[s] = time.Now() // silence counts from the subscription
+		}
+	}
+	p.feedMu.Unlock()
+	if p.conn == nil {
+		return
+	}
+	for _, s := range prev {
+		if !slices.Contains(next, s) {
+			p.send(p.conn, "unsubscribe", s)
+		}
+	}
+	for _, s := range next {
+		if !slices.Contains(prev, s) {
+			p.send(p.conn, "subscribe", s)
+		}
 	}
-	cfg.Symbols = syms
+}
 
-	if cfg.ReconnectBase <= 0 {
-		cfg.ReconnectBase = 200 * time.Millisecond
+// SetAPIKey replaces the API key after a rotation. A live connection is
+// closed so the provider reconnects with the new key.
+func (p *Provider) SetAPIKey(key secrets.Value) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.apiKey = key
+	if p.conn != nil {
+		p.log.Info("finnhub: api key rotated, reconnecting")
+		_ = p.conn.Close()
 	}
-	if cfg.ReconnectMax <= 0 {
-		cfg.ReconnectMax = 5 * time.Second
+}
+
+// Symbols returns the currently subscribed symbols.
+func (p *Provider) Symbols() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.symbols)
+}
+
+// Subscribed reports whether symbol is currently subscribed.
+func (p *Provider) Subscribed(symbol string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Contains(p.symbols, symbol)
+}
+
+// State is a snapshot of the provider connection.
+type State struct {
+	Connected      bool      `json:"connected"`
+	ConnectedSince time.Time `json:"connected_since,omitzero"`
+	Connects       int       `json:"connects"`
+	LastError      string    `json:"last_error,omitempty"`
+	Symbols        []string  `json:"symbols"`
+}
+
+// State returns the current connection state.
+func (p *Provider) State() State {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := State{
+		Connected: p.conn != nil,
+		Connects:  p.connects,
+		Symbols:   slices.Clone(p.symbols),
+	}
+	if st.Connected {
+		st.ConnectedSince = p.connectedAt
+	}
+	if p.lastErr != nil {
+		st.LastError = secrets.RedactError(p.lastErr).Error()
+	}
+	return st
+}
+
+// Check reports whether the provider currently holds a websocket connection.
+// It is meant to be registered as a health check.
+func (p *Provider) Check(context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn != nil {
+		return nil
+	}
+	if p.lastErr != nil {
+		return fmt.Errorf("finnhub: not connected: %w", secrets.RedactError(p.lastErr))
+	}
+	return errors.New("finnhub: not connected")
+}
+
+// SetCalendars sets the market hours stale-feed detection follows; by
+// default every market is always open.
+func (p *Provider) SetCalendars(r *calendar.Registry) {
+	p.feedMu.Lock()
+	defer p.feedMu.Unlock()
+	p.calendars = r
+}
+
+// Stale returns the subscribed symbols that had no tick for StaleAfter
+// while their market was open. Silence before the current session opened,
+// or before the connection was established, does not count.
+func (p *Provider) Stale(now time.Time) []string {
+	p.mu.Lock()
+	symbols, conn, connectedAt := slices.Clone(p.symbols), p.conn, p.connectedAt
+	p.mu.Unlock()
+	if conn == nil || p.cfg.StaleAfter <= 0 {
+		return nil
+	}
+
+	p.feedMu.Lock()
+	defer p.feedMu.Unlock()
+	var stale []string
+	for _, sym := range symbols {
+		sess, open := p.calendars.For(sym).SessionAt(now)
+		if !open {
+			continue
+		}
+		since := connectedAt
+		for _, t := range []time.Time{p.lastTick[sym], sess.Open} {
+			if t.After(since) {
+				since = t
+			}
+		}
+		if now.Sub(since) >= p.cfg.StaleAfter {
+			stale = append(stale, sym)
+		}
+	}
+	return stale
+}
+
+// CheckFeed reports the symbols whose feed is stale. It is meant to be
+// registered as a non-critical health check.
+func (p *Provider) CheckFeed(context.Context) error {
+	stale := p.Stale(time.Now())
+	sfmetrics.IngestorStaleSymbols.Set(float64(len(stale)))
+	if len(stale) > 0 {
+		return fmt.Errorf("finnhub: no ticks for %s in %s while the market is open", strings.Join(stale, ","), p.cfg.StaleAfter)
+	}
+	return nil
+}
+
+func (p *Provider) setErr(err error) {
+	p.mu.Lock()
+	p.lastErr = err
+	p.mu.Unlock()
+}
+
+// send writes a subscription message for a canonical symbol. Callers must
+// hold p.mu.
+func (p *Provider) send(conn *websocket.Conn, typ, symbol string) {
+	msg := fmt.Sprintf(`{"type":%q,"symbol":%q}`, typ, p.cfg.Instruments.ProviderSymbol(SrcID, symbol))
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+		p.log.Warn("finnhub: "+typ+" failed", zap.String("symbol", symbol), zap.Error(err))
+		return
 	}
-	return &Provider{cfg: cfg, log: log}
+	p.log.Info("finnhub: "+typ+"d", zap.String("symbol", symbol))
 }
 
 type envelope struct {
@@ -72,66 +275,46 @@ func (p *Provider) Start(ctx context.Context) (<-chan model.Tick, <-chan error)
 	go func() {
 		defer close(ticks)
 		defer close(errs)
-		backoff := p.cfg.ReconnectBase
-		for {
-			u, err := url.Parse(p.cfg.BaseURL)
-			if err != nil {
-				errs <- fmt.Errorf("finnhub: bad base url: %w", err)
-				return
-			}
-			q := u.Query()
-			q.Set("token", p.cfg.APIKey)
-			u.RawQuery = q.Encode()
-			p.log.Info("finnhub: connecting", zap.String("url", u.Redacted()))
-			conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
-			if err != nil {
-				p.log.Warn("finnhub: dial failed, will retry",
-					zap.Error(err),
-					zap.Duration("sleep", backoff),
-				)
-
-				select {
-				case <-time.After(backoff):
-				case <-ctx.Done():
-					return
-				}
-				if backoff < p.cfg.ReconnectMax {
-					backoff *= 2
-					if backoff > p.cfg.ReconnectMax {
-						backoff = p.cfg.ReconnectMax
-					}
-				}
-				continue
-			}
-			backoff = p.cfg.ReconnectBase
-
-			//subscribe to symbols
-			for _, s := range p.cfg.Symbols {
-				msg := fmt.Sprintf(`{"type":"subscribe","symbol":"%s"}`, s)
-				if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
-					p.log.Warn("finnhub: subscribe failed", zap.String("symbol", s), zap.Error(err))
-				} else {
-					p.log.Info("finnhub: subscribed", zap.String("symbol", s))
-				}
+		if err := p.Run(ctx, ticks, errs); err != nil {
+			select {
+			case errs <- err:
+			default:
 			}
+		}
+	}()
 
-			// Read loop blocks here until ctx cancel or read error
-			readErr := p.readLoop(ctx, conn, ticks, errs)
-
-			_ = conn.Close()
-			if ctx.Err() != nil {
-				return
-			}
+	return ticks, errs
+}
 
-			p.log.Warn("finnhub: connection closed, will reconnect",
-				zap.Error(readErr),
+// Run connects, subscribes and streams ticks into the caller's channels,
+// reconnecting with backoff until ctx is cancelled. It never closes ticks or
+// errs, so it can be restarted by a supervisor after a panic.
+func (p *Provider) Run(ctx context.Context, ticks chan<- model.Tick, errs chan<- error) error {
+	backoff := p.cfg.ReconnectBase
+	for {
+		u, err := url.Parse(p.cfg.BaseURL)
+		if err != nil {
+			return fmt.Errorf("finnhub: bad base url: %w", err)
+		}
+		p.log.Info("finnhub: connecting", zap.String("url", u.Redacted()))
+		p.mu.Lock()
+		key := p.apiKey
+		p.mu.Unlock()
+		q := u.Query()
+		q.Set("token", key.Reveal())
+		u.RawQuery = q.Encode()
+		conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+		if err != nil {
+			p.setErr(err)
+			p.log.Warn("finnhub: dial failed, will retry",
+				zap.Error(secrets.RedactError(err)),
 				zap.Duration("sleep", backoff),
 			)
 
 			select {
 			case <-time.After(backoff):
 			case <-ctx.Done():
-				return
+				return nil
 			}
 			if backoff < p.cfg.ReconnectMax {
 				backoff *= 2
@@ -139,12 +322,60 @@ func (p *Provider) Start(ctx context.Context) (<-chan model.Tick, <-chan error)
 					backoff = p.cfg.ReconnectMax
 				}
 			}
+			continue
+		}
+		backoff = p.cfg.ReconnectBase
+
+		// serve blocks here until ctx cancel or read error
+		readErr := p.serve(ctx, conn, ticks, errs)
 
+		p.mu.Lock()
+		p.lastErr = readErr
+		p.mu.Unlock()
+		if ctx.Err() != nil {
+			return nil
 		}
 
+		p.log.Warn("finnhub: connection closed, will reconnect",
+			zap.Error(secrets.RedactError(readErr)),
+			zap.Duration("sleep", backoff),
+		)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil
+		}
+		if backoff < p.cfg.ReconnectMax {
+			backoff *= 2
+			if backoff > p.cfg.ReconnectMax {
+				backoff = p.cfg.ReconnectMax
+			}
+		}
+
+	}
+}
+
+// serve subscribes to the symbols on conn and reads from it until ctx is
+// cancelled or a read fails. It closes conn on return, also when the read
+// loop panics and the panic is recovered further up.
+func (p *Provider) serve(ctx context.Context, conn *websocket.Conn, ticks chan<- model.Tick, errs chan<- error) error {
+	defer conn.Close()
+	p.mu.Lock()
+	p.conn = conn
+	p.connectedAt = time.Now().UTC()
+	p.connects++
+	for _, s := range p.symbols {
+		p.send(conn, "subscribe", s)
+	}
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.conn = nil
+		p.mu.Unlock()
 	}()
 
-	return ticks, errs
+	return p.readLoop(ctx, conn, ticks, errs)
 }
 
 func (p *Provider) readLoop(ctx context.Context, conn *websocket.Conn, ticks chan<- model.Tick, errs chan<- error) error {
@@ -169,14 +400,22 @@ func (p *Provider) readLoop(ctx context.Context, conn *websocket.Conn, ticks cha
 		}
 		for _, te := range env.Data {
 			t := model.Tick{
-				Symbol:   strings.ToUpper(strings.TrimSpace(te.Symbol)),
+				Symbol:   p.cfg.Instruments.Canonical(SrcID, strings.ToUpper(strings.TrimSpace(te.Symbol))),
 				Ts:       time.UnixMilli(te.TSMS).UTC(),
 				Price:    te.Price,
 				Size:     te.Size,
 				Exchange: te.Exchange,
-				SrcID:    "finnhub",
+				SrcID:    SrcID,
 			}
 			t = model.NormalizeTick(t)
+			if p.cfg.Instruments != nil && errors.Is(t.Validate(p.cfg.Instruments), model.ErrUnknownSymbol) {
+				sfmetrics.IngestorFetchErrorsTotal.WithLabelValues("validation").Inc()
+				p.log.Debug("finnhub: trade of unknown instrument dropped", zap.String("symbol", te.Symbol))
+				continue
+			}
+			p.feedMu.Lock()
+			p.lastTick[t.Symbol] = time.Now()
+			p.feedMu.Unlock()
 			select {
 			case ticks <- t:
 			case <-ctx.Done():
@@ -13,3 +13,10 @@ type Provider interface {
 	// Returns two read-only channels: ticks and errors.
 	Start(ctx context.Context) (<-chan model.Tick, <-chan error)
 }
+
+// Runner is implemented by providers that stream into caller-owned channels.
+// Run blocks until ctx is cancelled and never closes the channels, which lets
+// a supervisor restart it after a panic without tearing down consumers.
+type Runner interface {
+	Run(ctx context.Context, ticks chan<- model.Tick, errs chan<- error) error
+}
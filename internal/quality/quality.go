@@ -0,0 +1,304 @@
+// Package quality implements the data-quality stage: validation and
+// plausibility rules applied to every tick, each with a configurable action.
+// The same Checker runs in the ingestor before publishing and, as a Stage,
+// in the ticks-processor pipeline.
+package quality
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/anomaly"
+	"github.com/jonandereg/streamforge/internal/config"
+	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
+	"github.com/jonandereg/streamforge/internal/model"
+)
+
+// Rule names, as used in config keys, metric labels and the dq_flags header.
+const (
+	RuleInvalid       = "invalid"
+	RuleZeroPrice     = "zero_price"
+	RuleFuture        = "future"
+	RuleOutOfOrder    = "out_of_order"
+	RuleSpike         = "spike"
+	RuleUnknownSymbol = "unknown_symbol"
+	RuleAnomaly       = "anomaly"
+)
+
+// Action is what happens to a tick that violates a rule. Actions are
+// ordered by severity; a tick violating several rules gets the most severe.
+type Action int
+
+// Actions from least to most severe.
+const (
+	Off Action = iota
+	Flag
+	Drop
+	Quarantine
+)
+
+var actionNames = [...]string{"off", "flag", "drop", "quarantine"}
+
+func (a Action) String() string { return actionNames[a] }
+
+// ParseAction parses an action name from the config.
+func ParseAction(s string) (Action, error) {
+	for i, n := range actionNames {
+		if s == n {
+			return Action(i), nil
+		}
+	}
+	return Off, fmt.Errorf("unknown data-quality action %q", s)
+}
+
+// Violation is one rule a tick broke.
+type Violation struct {
+	Rule   string
+	Action Action
+	Detail string
+}
+
+// Verdict is the outcome of checking a tick.
+type Verdict struct {
+	Action     Action // most severe action among Violations; Off when clean
+	Violations []Violation
+}
+
+// Rules returns the names of the violated rules with the given action.
+func (v Verdict) Rules(a Action) []string {
+	var out []string
+	for _, x := range v.Violations {
+		if x.Action == a {
+			out = append(out, x.Rule)
+		}
+	}
+	return out
+}
+
+// String summarizes the violations as "rule: detail; ...".
+func (v Verdict) String() string {
+	parts := make([]string, len(v.Violations))
+	for i, x := range v.Violations {
+		parts[i] = x.Rule + ": " + x.Detail
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Checker applies the data-quality rules. It keeps per-symbol state (the
+// newest timestamp and a window of recent prices) and is safe for
+// concurrent use.
+type Checker struct {
+	stage string // metric label: "ingestor" or "processor"
+
+	mu        sync.Mutex
+	rules     rules
+	known     func(symbol string) bool
+	anomalies *anomaly.Tracker
+	symbols   map[string]*symbolState
+}
+
+// rules is the parsed form of config.Quality.
+type rules struct {
+	actions        map[string]Action
+	spikeWindow    int
+	spikeThreshold float64
+	maxFuture      time.Duration
+	maxLateness    time.Duration
+	minAnomaly     anomaly.Severity
+}
+
+type symbolState struct {
+	newest time.Time
+	prices []float64 // ring of the last spikeWindow accepted prices
+	next   int
+	sum    float64
+}
+
+// NewChecker creates a Checker for stage configured by cfg. known reports
+// whether a symbol is expected; nil disables the unknown_symbol rule.
+func NewChecker(stage string, cfg config.Quality, known func(string) bool) (*Checker, error) {
+	c := &Checker{stage: stage, known: known, symbols: make(map[string]*symbolState)}
+	if err := c.Configure(cfg); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Configure replaces the rule settings, e.g. after a config reload. A
+// changed spike window resets the price history.
+func (c *Checker) Configure(cfg config.Quality) error {
+	a := cfg.Actions
+	names := map[string]string{
+		RuleInvalid:       a.Invalid,
+		RuleZeroPrice:     a.ZeroPrice,
+		RuleFuture:        a.Future,
+		RuleOutOfOrder:    a.OutOfOrder,
+		RuleSpike:         a.Spike,
+		RuleUnknownSymbol: a.UnknownSymbol,
+		RuleAnomaly:       a.Anomaly,
+	}
+	r := rules{
+		actions:        make(map[string]Action, len(names)),
+		spikeWindow:    max(cfg.SpikeWindow, 1),
+		spikeThreshold: cfg.SpikeThreshold,
+		maxFuture:      cfg.MaxFuture,
+		maxLateness:    cfg.MaxLateness,
+		minAnomaly:     anomaly.Critical,
+	}
+	var errs []error
+	if cfg.AnomalySeverity != "" {
+		sev, err := anomaly.ParseSeverity(cfg.AnomalySeverity)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("quality.anomaly_severity: %w", err))
+		}
+		r.minAnomaly = sev
+	}
+	for rule, name := range names {
+		if name == "" && rule == RuleAnomaly {
+			name = "off"
+		}
+		act, err := ParseAction(name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("quality.actions.%s: %w", rule, err))
+			continue
+		}
+		r.actions[rule] = act
+		sfmetrics.QualityViolationsTotal.WithLabelValues(c.stage, rule, act.String()).Add(0)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	sfmetrics.QualityCheckedTotal.WithLabelValues(c.stage).Add(0)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if r.spikeWindow != c.rules.spikeWindow {
+		c.symbols = make(map[string]*symbolState)
+	}
+	c.rules = r
+	return nil
+}
+
+// SetKnown replaces the known-symbol lookup; nil disables the rule.
+func (c *Checker) SetKnown(known func(string) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.known = known
+}
+
+// SetAnomalies sets the tracker the anomaly rule judges ticks with; the rule
+// is skipped without one. Anomalies of at least quality.anomaly_severity
+// are violations.
+func (c *Checker) SetAnomalies(tr *anomaly.Tracker) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.anomalies = tr
+}
+
+// Known returns a lookup for the unknown_symbol rule that accepts the
+// given symbols.
+func Known(symbols []string) func(string) bool {
+	set := make(map[string]struct{}, len(symbols))
+	for _, s := range symbols {
+		set[s] = struct{}{}
+	}
+	return func(s string) bool {
+		_, ok := set[s]
+		return ok
+	}
+}
+
+// Check applies every rule to t, with now as the local clock, and records
+// the violations in metrics. A tick that passes or is only flagged updates
+// the symbol's state; dropped and quarantined ticks do not.
+func (c *Checker) Check(t model.Tick, now time.Time) Verdict {
+	sfmetrics.QualityCheckedTotal.WithLabelValues(c.stage).Inc()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var v Verdict
+	add := func(rule, format string, args ...any) {
+		act := c.rules.actions[rule]
+		if act == Off {
+			return
+		}
+		v.Violations = append(v.Violations, Violation{Rule: rule, Action: act, Detail: fmt.Sprintf(format, args...)})
+		v.Action = max(v.Action, act)
+		sfmetrics.QualityViolationsTotal.WithLabelValues(c.stage, rule, act.String()).Inc()
+	}
+
+	// Unknown symbols are left to RuleUnknownSymbol, which has its own action.
+	if err := t.Validate(nil); err != nil {
+		// Nothing else can be judged reliably on a malformed tick.
+		add(RuleInvalid, "%v", err)
+		return v
+	}
+	if t.Price == 0 {
+		add(RuleZeroPrice, "price is 0")
+	}
+	if c.known != nil && !c.known(t.Symbol) {
+		add(RuleUnknownSymbol, "%s is not a known symbol", t.Symbol)
+	}
+	if ahead := t.Ts.Sub(now); ahead > c.rules.maxFuture {
+		add(RuleFuture, "timestamp %s ahead of local clock", ahead)
+	}
+
+	st := c.symbols[t.Symbol]
+	if st != nil {
+		if behind := st.newest.Sub(t.Ts); behind > c.rules.maxLateness {
+			add(RuleOutOfOrder, "timestamp %s behind newest tick", behind)
+		}
+		if mean, ok := st.mean(c.rules.spikeWindow); ok && t.Price > 0 {
+			if dev := math.Abs(t.Price-mean) / mean; dev > c.rules.spikeThreshold {
+				add(RuleSpike, "price %v deviates %.1f%% from mean %v of last %d", t.Price, dev*100, mean, c.rules.spikeWindow)
+			}
+		}
+	}
+
+	if c.anomalies != nil && c.rules.actions[RuleAnomaly] != Off && v.Action < Drop {
+		for _, e := range c.anomalies.Observe(t) {
+			// A gap in arrival says nothing about the tick ending it.
+			if e.Kind != anomaly.ArrivalGap && e.Severity >= c.rules.minAnomaly {
+				add(RuleAnomaly, "%s", e)
+			}
+		}
+	}
+
+	if v.Action >= Drop {
+		return v
+	}
+	if st == nil {
+		st = &symbolState{prices: make([]float64, 0, c.rules.spikeWindow)}
+		c.symbols[t.Symbol] = st
+	}
+	if t.Ts.After(st.newest) {
+		st.newest = t.Ts
+	}
+	if t.Price > 0 {
+		st.push(t.Price, c.rules.spikeWindow)
+	}
+	return v
+}
+
+// mean returns the mean of the price window once it holds window prices.
+func (s *symbolState) mean(window int) (float64, bool) {
+	if len(s.prices) < window || s.sum <= 0 {
+		return 0, false
+	}
+	return s.sum / float64(len(s.prices)), true
+}
+
+func (s *symbolState) push(p float64, window int) {
+	if len(s.prices) < window {
+		s.prices = append(s.prices, p)
+		s.sum += p
+		return
+	}
+	s.sum += p - s.prices[s.next]
+	s.prices[s.next] = p
+	s.next = (s.next + 1) % window
+}
@@ -0,0 +1,153 @@
+package quality
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/anomaly"
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/events"
+	"github.com/jonandereg/streamforge/internal/model"
+	"github.com/jonandereg/streamforge/internal/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var t0 = time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+
+func testConfig() config.Quality {
+	cfg := config.Defaults().Quality
+	cfg.SpikeWindow = 3
+	return cfg
+}
+
+func tick(sym string, ts time.Time, price float64) model.Tick {
+	return model.Tick{Symbol: sym, Ts: ts, Price: price, Size: 1, SrcID: "test"}
+}
+
+func TestCheckRules(t *testing.T) {
+	c, err := NewChecker("test", testConfig(), Known([]string{"AAPL"}))
+	require.NoError(t, err)
+
+	for i, p := range []float64{100, 101, 99} {
+		v := c.Check(tick("AAPL", t0.Add(time.Duration(i)*time.Second), p), t0)
+		require.Equal(t, Off, v.Action, v.String())
+	}
+
+	cases := []struct {
+		name   string
+		tick   model.Tick
+		action Action
+		rules  []string
+	}{
+		{"invalid", tick("", t0, 100), Drop, []string{RuleInvalid}},
+		{"zero price", tick("AAPL", t0.Add(3*time.Second), 0), Drop, []string{RuleZeroPrice}},
+		{"unknown symbol", tick("TSLA", t0, 100), Drop, []string{RuleUnknownSymbol}},
+		{"future", tick("AAPL", t0.Add(time.Minute), 100), Drop, []string{RuleFuture}},
+		{"spike", tick("AAPL", t0.Add(3*time.Second), 150), Flag, []string{RuleSpike}},
+		{"out of order and spike", tick("AAPL", t0.Add(-time.Minute), 50), Flag, []string{RuleOutOfOrder, RuleSpike}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := c.Check(tc.tick, t0)
+			assert.Equal(t, tc.action, v.Action)
+			var rules []string
+			for _, x := range v.Violations {
+				rules = append(rules, x.Rule)
+			}
+			assert.Equal(t, tc.rules, rules)
+		})
+	}
+}
+
+func TestCheckDroppedTicksDoNotUpdateState(t *testing.T) {
+	cfg := testConfig()
+	cfg.Actions.Future = "drop"
+	c, err := NewChecker("test", cfg, nil)
+	require.NoError(t, err)
+
+	// A far-future tick is dropped, so it must not make later ticks look late.
+	require.Equal(t, Drop, c.Check(tick("AAPL", t0.Add(time.Hour), 100), t0).Action)
+	assert.Equal(t, Off, c.Check(tick("AAPL", t0, 100), t0).Action)
+}
+
+func TestConfigureActions(t *testing.T) {
+	c, err := NewChecker("test", testConfig(), Known(nil))
+	require.NoError(t, err)
+	require.Equal(t, Drop, c.Check(tick("AAPL", t0, 100), t0).Action)
+
+	cfg := testConfig()
+	cfg.Actions.UnknownSymbol = "off"
+	require.NoError(t, c.Configure(cfg))
+	assert.Equal(t, Off, c.Check(tick("AAPL", t0, 100), t0).Action)
+
+	cfg.Actions.Spike = "explode"
+	assert.ErrorContains(t, c.Configure(cfg), "quality.actions.spike")
+}
+
+type recordingSink struct{ got []Verdict }
+
+func (s *recordingSink) Send(_ context.Context, _ model.Tick, v Verdict) error {
+	s.got = append(s.got, v)
+	return nil
+}
+
+func TestStage(t *testing.T) {
+	cfg := testConfig()
+	cfg.Actions.UnknownSymbol = "quarantine"
+	c, err := NewChecker("test", cfg, Known([]string{"AAPL"}))
+	require.NoError(t, err)
+	sink := &recordingSink{}
+	s := &Stage{Checker: c, Quarantine: sink}
+	ctx := context.Background()
+	now := time.Now()
+
+	assert.NoError(t, s.Process(ctx, events.TickMsg{Tick: tick("AAPL", now, 100)}))
+	assert.ErrorIs(t, s.Process(ctx, events.TickMsg{Tick: tick("AAPL", now, 0)}), worker.ErrSkip)
+
+	err = s.Process(ctx, events.TickMsg{Tick: tick("TSLA", now, 100)})
+	assert.ErrorIs(t, err, worker.ErrSkip)
+	require.Len(t, sink.got, 1)
+	assert.Equal(t, []string{RuleUnknownSymbol}, sink.got[0].Rules(Quarantine))
+}
+
+func TestStageRejectsQuarantineWithoutSink(t *testing.T) {
+	c, err := NewChecker("test", testConfig(), Known(nil))
+	require.NoError(t, err)
+	s := &Stage{Checker: c}
+
+	cfg := testConfig()
+	cfg.Actions.UnknownSymbol = "quarantine"
+	cfg.QuarantineTopic = "ticks.quarantine" // set, but only read at startup
+	require.ErrorIs(t, s.Configure(cfg), ErrNoQuarantine)
+	err = s.Process(context.Background(), events.TickMsg{Tick: tick("AAPL", time.Now(), 100)})
+	assert.ErrorIs(t, err, worker.ErrSkip, "still dropped, not sent to a nil sink")
+
+	s.Quarantine = &recordingSink{}
+	assert.NoError(t, s.Configure(cfg))
+}
+
+func TestAnomalyRule(t *testing.T) {
+	cfg := testConfig()
+	cfg.SpikeThreshold = 10 // leave the jump to the anomaly rule
+	cfg.Actions.Anomaly = "quarantine"
+	c, err := NewChecker("test", cfg, nil)
+	require.NoError(t, err)
+	acfg := config.Defaults().Anomaly
+	acfg.MinSamples = 10
+	d, err := anomaly.NewDetector(acfg)
+	require.NoError(t, err)
+	c.SetAnomalies(anomaly.NewTracker(d))
+
+	for i := range 20 {
+		v := c.Check(tick("AAPL", t0.Add(time.Duration(i)*time.Second), 100+float64(i%2)*0.1), t0.Add(time.Minute))
+		require.Equal(t, Off, v.Action, v.String())
+	}
+	v := c.Check(tick("AAPL", t0.Add(20*time.Second), 130), t0.Add(time.Minute))
+	assert.Equal(t, Quarantine, v.Action)
+	assert.Equal(t, []string{RuleAnomaly}, v.Rules(Quarantine))
+
+	v = c.Check(tick("AAPL", t0.Add(2*time.Minute), 130), t0.Add(3*time.Minute))
+	assert.Equal(t, Off, v.Action, "an arrival gap is not the tick's fault")
+}
@@ -0,0 +1,128 @@
+package quality
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/broker"
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/events"
+	"github.com/jonandereg/streamforge/internal/model"
+	"github.com/jonandereg/streamforge/internal/worker"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const tracerName = "github.com/jonandereg/streamforge/internal/quality"
+
+// Sink receives quarantined ticks.
+type Sink interface {
+	Send(ctx context.Context, t model.Tick, v Verdict) error
+}
+
+// KafkaSink publishes quarantined ticks to a Kafka topic, keeping the tick
+// as the value and the violated rules in headers.
+type KafkaSink struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaSink creates a sink writing to topic.
+func NewKafkaSink(brokers []string, topic string, sec *broker.Security) *KafkaSink {
+	return &KafkaSink{writer: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Transport:    sec.Transport(),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: 10 * time.Millisecond,
+	}}
+}
+
+// Send publishes t to the quarantine topic.
+func (s *KafkaSink) Send(ctx context.Context, t model.Tick, v Verdict) error {
+	val, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	m := kafka.Message{
+		Key:   []byte(t.Symbol),
+		Value: val,
+		Headers: []kafka.Header{
+			{Key: "content-type", Value: []byte("application/json")},
+			{Key: "src_id", Value: []byte(t.SrcID)},
+			{Key: "dq_rules", Value: []byte(strings.Join(v.Rules(Quarantine), ","))},
+			{Key: "dq_reason", Value: []byte(v.String())},
+		},
+		Time: t.Ts,
+	}
+	otel.GetTextMapPropagator().Inject(ctx, broker.HeaderCarrier{Headers: &m.Headers})
+	return s.writer.WriteMessages(ctx, m)
+}
+
+// Close flushes and closes the underlying writer.
+func (s *KafkaSink) Close() error {
+	return s.writer.Close()
+}
+
+// Stage runs a Checker as a worker.Processor. Flagged ticks pass with their
+// rules recorded on the span; dropped and quarantined ones end the chain
+// with worker.ErrSkip.
+type Stage struct {
+	Checker *Checker
+	// Quarantine receives quarantined ticks; it must be set when a rule
+	// quarantines.
+	Quarantine Sink
+	Log        *zap.Logger
+}
+
+// ErrNoQuarantine rejects a reload that quarantines when the running stage
+// has no quarantine sink: quality.quarantine_topic only takes effect on
+// restart.
+var ErrNoQuarantine = errors.New("quality.actions: quarantine needs quality.quarantine_topic, which is not reloadable")
+
+// Configure applies a reloaded config to the checker. A config that
+// quarantines is rejected with ErrNoQuarantine when Quarantine is nil.
+func (s *Stage) Configure(cfg config.Quality) error {
+	if s.Quarantine == nil && cfg.Actions.Quarantines() {
+		return ErrNoQuarantine
+	}
+	return s.Checker.Configure(cfg)
+}
+
+// Process implements worker.Processor.
+func (s *Stage) Process(ctx context.Context, msg events.TickMsg) error {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "quality.check")
+	defer span.End()
+
+	v := s.Checker.Check(msg.Tick, time.Now())
+	if v.Action == Off {
+		return nil
+	}
+	span.SetAttributes(
+		attribute.String("dq.action", v.Action.String()),
+		attribute.String("dq.reason", v.String()),
+	)
+	switch v.Action {
+	case Flag:
+		if s.Log != nil {
+			s.Log.Debug("tick flagged",
+				zap.String("symbol", msg.Tick.Symbol),
+				zap.Strings("rules", v.Rules(Flag)),
+			)
+		}
+		return nil
+	case Quarantine:
+		if err := s.Quarantine.Send(ctx, msg.Tick, v); err != nil {
+			return fmt.Errorf("quarantine: %w", err)
+		}
+		return fmt.Errorf("%w: quarantined: %s", worker.ErrSkip, v)
+	default:
+		return fmt.Errorf("%w: data quality: %s", worker.ErrSkip, v)
+	}
+}
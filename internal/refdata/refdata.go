@@ -0,0 +1,213 @@
+// Package refdata is the symbol master: the canonical ID, asset class,
+// venue, currency and tick size of every known instrument, and the symbol
+// each provider uses for it. Providers map the symbols they receive to
+// canonical IDs, and model.Tick.Validate rejects instruments the master does
+// not list. Instruments are loaded from a YAML file (see
+// configs/instruments.example.yaml) or the instruments table.
+package refdata
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/jonandereg/streamforge/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Instrument is the reference data of one instrument.
+type Instrument struct {
+	ID         string  `yaml:"id" json:"id"`                   // canonical symbol, e.g. "AAPL", "BTC-USD"
+	AssetClass string  `yaml:"asset_class" json:"asset_class"` // equity, crypto, fx, ...
+	Venue      string  `yaml:"venue" json:"venue"`             // listing exchange or trading venue
+	Currency   string  `yaml:"currency" json:"currency"`       // quote currency
+	TickSize   float64 `yaml:"tick_size" json:"tick_size"`     // minimum price increment; 0 if unknown
+	// Aliases maps a provider (the tick SrcID, e.g. "finnhub") to the
+	// symbol it uses for the instrument when that differs from ID.
+	Aliases map[string]string `yaml:"aliases" json:"aliases,omitempty"`
+}
+
+// File is the layout of an instruments file.
+type File struct {
+	Instruments []Instrument `yaml:"instruments"`
+}
+
+// Master looks up instruments by canonical ID or provider symbol. A nil
+// Master has no reference data: every symbol is known and maps to itself.
+type Master struct {
+	list    []Instrument
+	byID    map[string]int
+	aliases map[string]map[string]string // provider -> provider symbol -> ID
+}
+
+// New builds a master from instruments. IDs and aliases are upper-cased and
+// must be unique.
+func New(instruments []Instrument) (*Master, error) {
+	m := &Master{
+		byID:    make(map[string]int, len(instruments)),
+		aliases: make(map[string]map[string]string),
+	}
+	var errs []error
+	for i, in := range instruments {
+		in.ID = normalize(in.ID)
+		switch {
+		case in.ID == "":
+			errs = append(errs, fmt.Errorf("instruments[%d]: id is required", i))
+			continue
+		case in.TickSize < 0:
+			errs = append(errs, fmt.Errorf("instrument %s: tick_size must be >= 0", in.ID))
+		}
+		if _, dup := m.byID[in.ID]; dup {
+			errs = append(errs, fmt.Errorf("instrument %s: duplicate id", in.ID))
+			continue
+		}
+		aliases := make(map[string]string, len(in.Aliases))
+		for prov, sym := range in.Aliases {
+			sym = normalize(sym)
+			if sym == "" {
+				errs = append(errs, fmt.Errorf("instrument %s: empty %s alias", in.ID, prov))
+				continue
+			}
+			byProv := m.aliases[prov]
+			if byProv == nil {
+				byProv = make(map[string]string)
+				m.aliases[prov] = byProv
+			}
+			if other, dup := byProv[sym]; dup {
+				errs = append(errs, fmt.Errorf("instrument %s: %s alias %s already used by %s", in.ID, prov, sym, other))
+				continue
+			}
+			byProv[sym] = in.ID
+			aliases[prov] = sym
+		}
+		in.Aliases = aliases
+		m.byID[in.ID] = len(m.list)
+		m.list = append(m.list, in)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Table lists the rows of the instruments table; see
+// store.TickStore.Instruments.
+type Table interface {
+	Instruments(ctx context.Context) ([]Instrument, error)
+}
+
+// Open loads the master cfg selects. Without a source it returns nil, the
+// master that knows every symbol; table is only used for the "table" source.
+func Open(ctx context.Context, cfg config.Instruments, table Table) (*Master, error) {
+	switch cfg.Source {
+	case "":
+		return nil, nil
+	case "file":
+		return Load(cfg.File)
+	case "table":
+		if table == nil {
+			return nil, errors.New("instruments: no database configured")
+		}
+		list, err := table.Instruments(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("instruments: %w", err)
+		}
+		return New(list)
+	}
+	return nil, fmt.Errorf("instruments: unknown source %q", cfg.Source)
+}
+
+// Load reads an instruments file.
+func Load(file string) (*Master, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("instruments: %w", err)
+	}
+	m, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("instruments %s: %w", file, err)
+	}
+	return m, nil
+}
+
+// Parse builds a master from the YAML of an instruments file.
+func Parse(data []byte) (*Master, error) {
+	var f File
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil {
+		return nil, err
+	}
+	return New(f.Instruments)
+}
+
+func normalize(s string) string { return strings.ToUpper(strings.TrimSpace(s)) }
+
+// Len returns the number of instruments.
+func (m *Master) Len() int {
+	if m == nil {
+		return 0
+	}
+	return len(m.list)
+}
+
+// Instruments returns every instrument in the order they were listed.
+func (m *Master) Instruments() []Instrument {
+	if m == nil {
+		return nil
+	}
+	return slices.Clone(m.list)
+}
+
+// Lookup returns the instrument with the canonical ID id.
+func (m *Master) Lookup(id string) (Instrument, bool) {
+	if m == nil {
+		return Instrument{}, false
+	}
+	i, ok := m.byID[id]
+	if !ok {
+		return Instrument{}, false
+	}
+	return m.list[i], true
+}
+
+// Known reports whether id is the canonical ID of an instrument. Every
+// symbol is known to a nil Master.
+func (m *Master) Known(id string) bool {
+	if m == nil {
+		return true
+	}
+	_, ok := m.byID[id]
+	return ok
+}
+
+// Canonical maps the symbol provider uses to the canonical ID. Symbols
+// without an alias are returned unchanged, so canonical IDs map to
+// themselves.
+func (m *Master) Canonical(provider, symbol string) string {
+	if m == nil {
+		return symbol
+	}
+	if id, ok := m.aliases[provider][symbol]; ok {
+		return id
+	}
+	return symbol
+}
+
+// ProviderSymbol maps a canonical ID to the symbol provider uses for it,
+// e.g. to subscribe. IDs without an alias are returned unchanged.
+func (m *Master) ProviderSymbol(provider, id string) string {
+	if m == nil {
+		return id
+	}
+	if i, ok := m.byID[id]; ok {
+		if sym, ok := m.list[i].Aliases[provider]; ok {
+			return sym
+		}
+	}
+	return id
+}
@@ -0,0 +1,70 @@
+package refdata
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExampleFile(t *testing.T) {
+	m, err := Load("../../configs/instruments.example.yaml")
+	require.NoError(t, err)
+
+	assert.Equal(t, "BTC-USD", m.Canonical("finnhub", "BINANCE:BTCUSDT"))
+	assert.Equal(t, "AAPL", m.Canonical("finnhub", "AAPL"))
+	assert.Equal(t, "BINANCE:BTCUSDT", m.ProviderSymbol("finnhub", "BTC-USD"))
+	assert.Equal(t, "AAPL", m.ProviderSymbol("finnhub", "AAPL"))
+	in, ok := m.Lookup("EUR-USD")
+	require.True(t, ok)
+	assert.Equal(t, "fx", in.AssetClass)
+	assert.Equal(t, 0.00001, in.TickSize)
+}
+
+func TestValidateRejectsUnknown(t *testing.T) {
+	m, err := New([]Instrument{{ID: "aapl"}})
+	require.NoError(t, err)
+	tick := model.Tick{Symbol: "AAPL", Ts: time.Now(), Price: 1}
+	assert.NoError(t, tick.Validate(m))
+	tick.Symbol = "TSLA"
+	assert.ErrorIs(t, tick.Validate(m), model.ErrUnknownSymbol)
+	assert.NoError(t, tick.Validate(nil))
+
+	var none *Master
+	assert.True(t, none.Known("TSLA"))
+	assert.Equal(t, "BINANCE:BTCUSDT", none.Canonical("finnhub", "BINANCE:BTCUSDT"))
+}
+
+func TestNewRejectsConflicts(t *testing.T) {
+	_, err := New([]Instrument{
+		{ID: "BTC-USD", Aliases: map[string]string{"finnhub": "BINANCE:BTCUSDT"}},
+		{ID: "btc-usd"},
+		{ID: "BTC-USDT", Aliases: map[string]string{"finnhub": "binance:btcusdt"}},
+		{ID: ""},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "BTC-USD: duplicate id")
+	assert.Contains(t, err.Error(), "alias BINANCE:BTCUSDT already used by BTC-USD")
+	assert.Contains(t, err.Error(), "instruments[3]: id is required")
+}
+
+type fakeTable []Instrument
+
+func (f fakeTable) Instruments(context.Context) ([]Instrument, error) { return f, nil }
+
+func TestOpen(t *testing.T) {
+	m, err := Open(context.Background(), config.Instruments{}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, m)
+
+	m, err = Open(context.Background(), config.Instruments{Source: "table"}, fakeTable{{ID: "AAPL"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, m.Len())
+
+	_, err = Open(context.Background(), config.Instruments{Source: "table"}, nil)
+	assert.Error(t, err)
+}
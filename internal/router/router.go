@@ -3,24 +3,73 @@ package router
 import (
 	"context"
 	"hash/fnv"
+	"strconv"
+	"sync/atomic"
 
 	"github.com/jonandereg/streamforge/internal/events"
+	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+const tracerName = "github.com/jonandereg/streamforge/internal/router"
+
+// DropPolicy decides what the router does when a worker queue is full.
+type DropPolicy string
+
+const (
+	// DropNewest discards the incoming message (default).
+	DropNewest DropPolicy = "drop"
+	// Block waits for room in the queue, applying backpressure to the consumer.
+	Block DropPolicy = "block"
+)
+
+// Policy holds the active DropPolicy and can be changed while the router runs.
+type Policy struct {
+	v atomic.Value
+}
+
+// NewPolicy returns a Policy set to p.
+func NewPolicy(p DropPolicy) *Policy {
+	pol := &Policy{}
+	pol.Set(p)
+	return pol
+}
+
+// Set changes the policy; unknown values fall back to DropNewest.
+func (p *Policy) Set(dp DropPolicy) {
+	if dp != Block {
+		dp = DropNewest
+	}
+	p.v.Store(dp)
+}
+
+// Load returns the current policy.
+func (p *Policy) Load() DropPolicy {
+	return p.v.Load().(DropPolicy)
+}
+
 // StartRouter creates numWorkers output channels and starts a goroutine that
 // routes each TickMsg to a deterministic worker index based on Tick.Symbol.
-
-func StartRouter(ctx context.Context, in <-chan events.TickMsg, numWorkers, queueCap int, onDrop func(events.TickMsg)) []chan events.TickMsg {
+// The outputs are closed once in is closed and drained, so workers finish the
+// queued messages on shutdown. ctx only carries trace context. policy decides
+// what happens when a queue is full; nil means DropNewest.
+func StartRouter(ctx context.Context, in <-chan events.TickMsg, numWorkers, queueCap int, policy *Policy, onDrop func(events.TickMsg)) []chan events.TickMsg {
 	if numWorkers <= 0 {
 		numWorkers = 1
 	}
 	if queueCap <= 0 {
 		queueCap = 0
 	}
+	if policy == nil {
+		policy = NewPolicy(DropNewest)
+	}
 
 	outs := make([]chan events.TickMsg, numWorkers)
+	labels := make([]string, numWorkers)
 	for i := range outs {
 		outs[i] = make(chan events.TickMsg, queueCap)
+		labels[i] = strconv.Itoa(i)
 	}
 
 	go func() {
@@ -29,23 +78,26 @@ func StartRouter(ctx context.Context, in <-chan events.TickMsg, numWorkers, queu
 				close(ch)
 			}
 		}()
-		for {
+		for msg := range in {
+			idx := workerIndex(msg.Tick.Symbol, numWorkers)
+			_, span := otel.Tracer(tracerName).Start(msg.TraceContext(ctx), "router.route")
+			span.SetAttributes(attribute.Int("worker", idx))
+			msg.SpanContext = span.SpanContext()
+			span.End()
+			if policy.Load() == Block {
+				outs[idx] <- msg
+				sfmetrics.ProcessorRouterQueueDepth.WithLabelValues(labels[idx]).Set(float64(len(outs[idx])))
+				continue
+			}
 			select {
-			case <-ctx.Done():
-				return
-			case msg, ok := <-in:
-				if !ok {
-					return
+			case outs[idx] <- msg:
+				sfmetrics.ProcessorRouterQueueDepth.WithLabelValues(labels[idx]).Set(float64(len(outs[idx])))
+			default:
+				sfmetrics.ProcessorRouterDroppedTotal.WithLabelValues(labels[idx]).Inc()
+				if onDrop != nil {
+					onDrop(msg)
 				}
-				idx := workerIndex(msg.Tick.Symbol, numWorkers)
-				select {
-				case outs[idx] <- msg:
-				default:
-					if onDrop != nil {
-						onDrop(msg)
-					}
-				}
-
+				msg.Done()
 			}
 		}
 	}()
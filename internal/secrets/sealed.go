@@ -0,0 +1,111 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// sealedPrefix tags the format of an encrypted secrets file:
+// "sfsec1:" + base64(nonce || AES-256-GCM ciphertext of a JSON object).
+const sealedPrefix = "sfsec1:"
+
+// ErrNoKey is returned when an encrypted file is used without a key.
+var ErrNoKey = errors.New("secrets key not set")
+
+// KeySize is the length of a secrets key in bytes (AES-256).
+const KeySize = 32
+
+// NewKey returns a random key encoded for STREAMFORGE_SECRETS_KEY.
+func NewKey() (string, error) {
+	k := make([]byte, KeySize)
+	if _, err := rand.Read(k); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(k), nil
+}
+
+// ParseKey decodes a base64 key as produced by NewKey.
+func ParseKey(s string) ([]byte, error) {
+	if s == "" {
+		return nil, ErrNoKey
+	}
+	k, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, errors.New("secrets key is not valid base64")
+	}
+	if len(k) != KeySize {
+		return nil, fmt.Errorf("secrets key must be %d bytes, got %d", KeySize, len(k))
+	}
+	return k, nil
+}
+
+// Seal encrypts a name→secret map with key.
+func Seal(secrets map[string]string, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := gcm.Seal(nonce, nonce, plain, []byte(sealedPrefix))
+	return []byte(sealedPrefix + base64.StdEncoding.EncodeToString(sealed) + "\n"), nil
+}
+
+// Open decrypts data produced by Seal.
+func Open(data []byte, key []byte) (map[string]string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	s := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(s, sealedPrefix) {
+		return nil, errors.New("not a sealed secrets file")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(s, sealedPrefix))
+	if err != nil || len(raw) < gcm.NonceSize() {
+		return nil, errors.New("corrupt sealed secrets file")
+	}
+	nonce, ct := raw[:gcm.NonceSize()], raw[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ct, []byte(sealedPrefix))
+	if err != nil {
+		return nil, errors.New("cannot decrypt secrets file: wrong key or tampered data")
+	}
+	out := make(map[string]string)
+	if err := json.Unmarshal(plain, &out); err != nil {
+		return nil, errors.New("sealed secrets are not a JSON object of strings")
+	}
+	return out, nil
+}
+
+// OpenFile reads and decrypts a sealed secrets file.
+func OpenFile(path string, key []byte) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Open(data, key)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) == 0 {
+		return nil, ErrNoKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
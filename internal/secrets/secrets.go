@@ -0,0 +1,178 @@
+// Package secrets resolves credentials from the environment, mounted secret
+// files or an encrypted local file, and keeps them out of logs.
+//
+// A secret is configured as a reference:
+//
+//	env:FINNHUB_TOKEN            environment variable
+//	file:/run/secrets/finnhub    file contents, trailing newline trimmed;
+//	                             relative paths are taken from Options.Dir
+//	enc:finnhub_token            key in the encrypted secrets file
+//
+// Anything else is used literally. Resolved values are wrapped in Value,
+// whose formatting methods never print the secret, and are remembered so
+// Redact can scrub them from arbitrary strings such as error messages.
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const redacted = "[REDACTED]"
+
+// Value holds a secret. String, GoString and the marshalers redact it, so a
+// Value can be logged or serialized safely; Reveal returns the plain text.
+type Value struct {
+	s string
+}
+
+// New wraps s as a secret and registers it for Redact.
+func New(s string) Value {
+	register(s)
+	return Value{s: s}
+}
+
+// Reveal returns the secret in plain text. Only pass the result to the
+// system that needs it, never to a logger.
+func (v Value) Reveal() string { return v.s }
+
+// IsZero reports whether the secret is empty.
+func (v Value) IsZero() bool { return v.s == "" }
+
+// Equal reports whether both values hold the same secret.
+func (v Value) Equal(o Value) bool { return v.s == o.s }
+
+func (v Value) String() string {
+	if v.s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// GoString implements fmt.GoStringer so %#v is redacted too.
+func (v Value) GoString() string { return `secrets.Value("` + v.String() + `")` }
+
+// MarshalText implements encoding.TextMarshaler (JSON, YAML, zap).
+func (v Value) MarshalText() ([]byte, error) { return []byte(v.String()), nil }
+
+// Options configures a Resolver.
+type Options struct {
+	// Dir is the base directory for relative file: references, e.g. /run/secrets.
+	Dir string
+	// EncryptedFile is the sealed file used by enc: references.
+	EncryptedFile string
+	// Key decrypts EncryptedFile (see ParseKey).
+	Key []byte
+}
+
+// Resolver turns secret references into values.
+type Resolver struct {
+	opts Options
+}
+
+// NewResolver returns a Resolver for opts.
+func NewResolver(opts Options) *Resolver {
+	return &Resolver{opts: opts}
+}
+
+// IsReference reports whether s is a secret reference rather than a literal.
+func IsReference(s string) bool {
+	scheme, _, ok := strings.Cut(s, ":")
+	return ok && (scheme == "env" || scheme == "file" || scheme == "enc")
+}
+
+// Resolve returns the secret ref points to. Files are re-read on every call,
+// so a rotated mount or re-sealed file is picked up by the next Resolve.
+// Errors name the reference, never the secret.
+func (r *Resolver) Resolve(ref string) (Value, error) {
+	if !IsReference(ref) {
+		return New(ref), nil
+	}
+	scheme, name, _ := strings.Cut(ref, ":")
+	switch scheme {
+	case "env":
+		v, ok := os.LookupEnv(name)
+		if !ok || v == "" {
+			return Value{}, fmt.Errorf("secret %s: variable not set", ref)
+		}
+		return New(v), nil
+	case "file":
+		path := name
+		if !filepath.IsAbs(path) && r.opts.Dir != "" {
+			path = filepath.Join(r.opts.Dir, path)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return Value{}, fmt.Errorf("secret %s: %w", ref, err)
+		}
+		v := strings.TrimRight(string(b), "\r\n")
+		if v == "" {
+			return Value{}, fmt.Errorf("secret %s: file is empty", ref)
+		}
+		return New(v), nil
+	default: // enc
+		if r.opts.EncryptedFile == "" {
+			return Value{}, fmt.Errorf("secret %s: no encrypted secrets file configured", ref)
+		}
+		all, err := OpenFile(r.opts.EncryptedFile, r.opts.Key)
+		if err != nil {
+			return Value{}, fmt.Errorf("secret %s: %w", ref, err)
+		}
+		v, ok := all[name]
+		if !ok || v == "" {
+			return Value{}, fmt.Errorf("secret %s: not found in %s", ref, r.opts.EncryptedFile)
+		}
+		return New(v), nil
+	}
+}
+
+var (
+	knownMu sync.RWMutex
+	known   = make(map[string]struct{})
+)
+
+// minRedactLen keeps very short values (e.g. "1") from mangling unrelated text.
+const minRedactLen = 4
+
+func register(s string) {
+	if len(s) < minRedactLen {
+		return
+	}
+	knownMu.Lock()
+	known[s] = struct{}{}
+	knownMu.Unlock()
+}
+
+// Redact replaces every secret resolved so far that occurs in s.
+func Redact(s string) string {
+	knownMu.RLock()
+	defer knownMu.RUnlock()
+	for v := range known {
+		s = strings.ReplaceAll(s, v, redacted)
+	}
+	return s
+}
+
+// RedactError returns err with secrets scrubbed from its message. The result
+// still unwraps to err for errors.Is/As.
+func RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	if clean := Redact(msg); clean != msg {
+		return &redactedError{msg: clean, err: err}
+	}
+	return err
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
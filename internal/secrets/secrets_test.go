@@ -0,0 +1,91 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestValueNeverFormatsSecret(t *testing.T) {
+	v := New("hunter2-token")
+	for _, s := range []string{
+		v.String(),
+		fmt.Sprintf("%v %s %+v %#v", v, v, v, v),
+		fmt.Sprintf("%v", struct{ Token Value }{v}),
+	} {
+		assert.NotContains(t, s, "hunter2")
+	}
+	b, err := json.Marshal(map[string]Value{"token": v})
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "hunter2")
+	assert.Equal(t, "hunter2-token", v.Reveal())
+
+	err = RedactError(errors.New(`dial wss://x/?token=hunter2-token: refused`))
+	assert.Equal(t, "dial wss://x/?token=[REDACTED]: refused", err.Error())
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tok"), []byte("from-file\n"), 0o600))
+	key, err := NewKey()
+	require.NoError(t, err)
+	k, err := ParseKey(key)
+	require.NoError(t, err)
+	sealed, err := Seal(map[string]string{"tok": "from-enc"}, k)
+	require.NoError(t, err)
+	enc := filepath.Join(dir, "secrets.enc")
+	require.NoError(t, os.WriteFile(enc, sealed, 0o600))
+	t.Setenv("SF_TEST_TOKEN", "from-env")
+
+	r := NewResolver(Options{Dir: dir, EncryptedFile: enc, Key: k})
+	for ref, want := range map[string]string{
+		"literal":           "literal",
+		"env:SF_TEST_TOKEN": "from-env",
+		"file:tok":          "from-file",
+		"enc:tok":           "from-enc",
+	} {
+		v, err := r.Resolve(ref)
+		require.NoError(t, err, ref)
+		assert.Equal(t, want, v.Reveal(), ref)
+	}
+
+	_, err = r.Resolve("enc:missing")
+	assert.Error(t, err)
+	other, _ := NewKey()
+	k2, _ := ParseKey(other)
+	_, err = NewResolver(Options{EncryptedFile: enc, Key: k2}).Resolve("enc:tok")
+	assert.Error(t, err, "wrong key")
+}
+
+func TestWatchReportsRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tok")
+	require.NoError(t, os.WriteFile(path, []byte("first"), 0o600))
+	r := NewResolver(Options{})
+	cur, err := r.Resolve("file:" + path)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	got := make(chan Value, 1)
+	go func() {
+		_ = r.Watch(ctx, "file:"+path, cur, 5*time.Millisecond, zap.NewNop(), func(v Value) { got <- v })
+	}()
+
+	require.NoError(t, os.WriteFile(path, []byte("second"), 0o600))
+	select {
+	case v := <-got:
+		assert.Equal(t, "second", v.Reveal())
+	case <-time.After(2 * time.Second):
+		t.Fatal("rotation not detected")
+	}
+}
@@ -0,0 +1,41 @@
+package secrets
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Watch re-resolves ref every interval and calls onChange with the new value
+// whenever it differs from current. Resolution errors are logged and the
+// current value is kept. It returns when ctx is done.
+func (r *Resolver) Watch(ctx context.Context, ref string, current Value, interval time.Duration, log *zap.Logger, onChange func(Value)) error {
+	if !IsReference(ref) {
+		<-ctx.Done() // literals never rotate
+		return nil
+	}
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			v, err := r.Resolve(ref)
+			if err != nil {
+				log.Warn("secret refresh failed, keeping current value", zap.String("ref", ref), zap.Error(err))
+				continue
+			}
+			if v.Equal(current) {
+				continue
+			}
+			current = v
+			log.Info("secret rotated", zap.String("ref", ref))
+			onChange(v)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jonandereg/streamforge/internal/broker"
+	"github.com/jonandereg/streamforge/internal/config"
+)
+
+// Backend persists entries. Save applies changed entries on top of what was
+// saved before; Load returns the latest entry of every key.
+type Backend interface {
+	Save(ctx context.Context, entries []Entry) error
+	Load(ctx context.Context) ([]Entry, error)
+	Close() error
+}
+
+// Open creates the backend selected by cfg.Backend. It returns nil when
+// state is not checkpointed.
+func Open(cfg config.State, brokers []string, sec *broker.Security) (Backend, error) {
+	switch cfg.Backend {
+	case "":
+		return nil, nil
+	case "disk":
+		return NewDiskBackend(cfg.Dir)
+	case "kafka":
+		return NewKafkaBackend(brokers, cfg.ChangelogTopic, sec), nil
+	default:
+		return nil, fmt.Errorf("unknown state backend %q", cfg.Backend)
+	}
+}
+
+// snapshotFile is the file name of the disk backend's snapshot.
+const snapshotFile = "snapshot.json"
+
+// DiskBackend keeps every entry in one JSON file, rewritten atomically on
+// each save. Instances must not share a directory.
+type DiskBackend struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]Entry // by store and key; nil until first loaded
+}
+
+// NewDiskBackend creates a backend writing to dir, creating it if needed.
+func NewDiskBackend(dir string) (*DiskBackend, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("state dir: %w", err)
+	}
+	return &DiskBackend{path: filepath.Join(dir, snapshotFile)}, nil
+}
+
+// Save merges entries into the snapshot and writes it.
+func (b *DiskBackend) Save(_ context.Context, entries []Entry) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.read(); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Value == nil {
+			delete(b.entries, entryID(e))
+		} else {
+			b.entries[entryID(e)] = e
+		}
+	}
+	return b.write()
+}
+
+// Load returns the entries of the snapshot.
+func (b *DiskBackend) Load(context.Context) ([]Entry, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.read(); err != nil {
+		return nil, err
+	}
+	out := make([]Entry, 0, len(b.entries))
+	for _, e := range b.entries {
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// Close implements Backend.
+func (b *DiskBackend) Close() error { return nil }
+
+// read loads the snapshot file once; a missing file is an empty snapshot.
+func (b *DiskBackend) read() error {
+	if b.entries != nil {
+		return nil
+	}
+	b.entries = make(map[string]Entry)
+	data, err := os.ReadFile(b.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		b.entries = nil
+		return fmt.Errorf("read snapshot: %w", err)
+	}
+	var list []Entry
+	if err := json.Unmarshal(data, &list); err != nil {
+		b.entries = nil
+		return fmt.Errorf("decode snapshot %s: %w", b.path, err)
+	}
+	for _, e := range list {
+		b.entries[entryID(e)] = e
+	}
+	return nil
+}
+
+// write replaces the snapshot file through a synced temporary file so a
+// crash leaves either the old or the new snapshot.
+func (b *DiskBackend) write() error {
+	list := make([]Entry, 0, len(b.entries))
+	for _, e := range b.entries {
+		list = append(list, e)
+	}
+	slices.SortFunc(list, func(x, y Entry) int { return strings.Compare(entryID(x), entryID(y)) })
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(b.path), snapshotFile+".*")
+	if err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck // gone after a successful rename
+	if _, err := f.Write(data); err != nil {
+		f.Close() //nolint:errcheck,gosec // write error wins
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close() //nolint:errcheck,gosec // sync error wins
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := os.Rename(f.Name(), b.path); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	return nil
+}
+
+// entryID identifies an entry across stores; it is also the changelog key.
+func entryID(e Entry) string { return e.Store + "/" + e.Key }
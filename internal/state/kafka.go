@@ -0,0 +1,186 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/broker"
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaBackend writes entries to a changelog topic keyed by store and key,
+// with tombstones for deleted keys. The topic should be created with
+// cleanup.policy=compact so it keeps only the latest entry of each key.
+type KafkaBackend struct {
+	topic  string
+	writer *kafka.Writer
+	client *kafka.Client
+	dialer *kafka.Dialer
+	addrs  []string
+}
+
+// NewKafkaBackend creates a backend for the changelog topic.
+func NewKafkaBackend(brokers []string, topic string, sec *broker.Security) *KafkaBackend {
+	return &KafkaBackend{
+		topic: topic,
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Transport:    sec.Transport(),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			BatchTimeout: 10 * time.Millisecond,
+		},
+		client: &kafka.Client{Addr: kafka.TCP(brokers...), Timeout: 10 * time.Second, Transport: sec.Transport()},
+		dialer: sec.Dialer(),
+		addrs:  brokers,
+	}
+}
+
+// Save publishes entries to the changelog.
+func (b *KafkaBackend) Save(ctx context.Context, entries []Entry) error {
+	msgs := make([]kafka.Message, 0, len(entries))
+	for _, e := range entries {
+		m := kafka.Message{Key: []byte(entryID(e))}
+		if e.Value != nil {
+			v, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			m.Value = v
+		}
+		msgs = append(msgs, m)
+	}
+	return b.writer.WriteMessages(ctx, msgs...)
+}
+
+// Load reads the changelog from the start up to its current end and returns
+// the latest entry of every key.
+func (b *KafkaBackend) Load(ctx context.Context) ([]Entry, error) {
+	first, last, err := b.bounds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]*Entry)
+	for p, end := range last {
+		if err := b.readPartition(ctx, p, first[p], end, latest); err != nil {
+			return nil, err
+		}
+	}
+	out := make([]Entry, 0, len(latest))
+	for _, e := range latest {
+		if e != nil {
+			out = append(out, *e)
+		}
+	}
+	return out, nil
+}
+
+// Close flushes and closes the writer.
+func (b *KafkaBackend) Close() error {
+	return b.writer.Close()
+}
+
+// readPartition applies the messages of partition p in [from, end) to latest;
+// a tombstone maps its key to nil.
+func (b *KafkaBackend) readPartition(ctx context.Context, p int, from, end int64, latest map[string]*Entry) error {
+	if from >= end {
+		return nil
+	}
+	r := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   b.addrs,
+		Topic:     b.topic,
+		Partition: p,
+		Dialer:    b.dialer,
+	})
+	defer r.Close() //nolint:errcheck // read-only
+	if err := r.SetOffset(from); err != nil {
+		return fmt.Errorf("changelog partition %d: %w", p, err)
+	}
+	for {
+		m, err := r.ReadMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("changelog partition %d: %w", p, err)
+		}
+		if m.Value == nil {
+			latest[string(m.Key)] = nil
+		} else {
+			var e Entry
+			if err := json.Unmarshal(m.Value, &e); err != nil {
+				return fmt.Errorf("changelog partition %d offset %d: %w", p, m.Offset, err)
+			}
+			latest[string(m.Key)] = &e
+		}
+		if m.Offset >= end-1 {
+			return nil
+		}
+	}
+}
+
+// bounds returns the first and end offsets of every changelog partition.
+func (b *KafkaBackend) bounds(ctx context.Context) (first, last map[int]int64, err error) {
+	md, err := b.client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{b.topic}})
+	if err != nil {
+		return nil, nil, fmt.Errorf("changelog metadata: %w", err)
+	}
+	var parts []int
+	for _, t := range md.Topics {
+		if t.Name != b.topic {
+			continue
+		}
+		if t.Error != nil {
+			if errors.Is(t.Error, kafka.UnknownTopicOrPartition) {
+				return nil, nil, nil // nothing saved yet
+			}
+			return nil, nil, fmt.Errorf("changelog metadata %s: %w", b.topic, t.Error)
+		}
+		for _, p := range t.Partitions {
+			parts = append(parts, p.ID)
+		}
+	}
+	first, err = b.listOffsets(ctx, parts, kafka.FirstOffset)
+	if err != nil {
+		return nil, nil, err
+	}
+	last, err = b.listOffsets(ctx, parts, kafka.LastOffset)
+	if err != nil {
+		return nil, nil, err
+	}
+	return first, last, nil
+}
+
+// listOffsets resolves the first (kind kafka.FirstOffset) or last
+// (kafka.LastOffset) offsets of parts.
+func (b *KafkaBackend) listOffsets(ctx context.Context, parts []int, kind int64) (map[int]int64, error) {
+	out := make(map[int]int64, len(parts))
+	if len(parts) == 0 {
+		return out, nil
+	}
+	reqs := make([]kafka.OffsetRequest, 0, len(parts))
+	for _, p := range parts {
+		reqs = append(reqs, kafka.OffsetRequest{Partition: p, Timestamp: kind})
+	}
+	res, err := b.client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{b.topic: reqs},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("changelog offsets: %w", err)
+	}
+	for _, po := range res.Topics[b.topic] {
+		if po.Error != nil {
+			return nil, fmt.Errorf("changelog offsets partition %d: %w", po.Partition, po.Error)
+		}
+		// kafka-go files answers by the timestamp the broker sends with
+		// them, -1 (kafka.LastOffset) for both kinds, so a first offset
+		// arrives in LastOffset while FirstOffset keeps the placeholder 0.
+		if kind == kafka.FirstOffset && po.LastOffset < 0 {
+			out[po.Partition] = po.FirstOffset
+		} else {
+			out[po.Partition] = po.LastOffset
+		}
+	}
+	return out, nil
+}
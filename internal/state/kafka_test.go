@@ -0,0 +1,59 @@
+package state
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	"github.com/segmentio/kafka-go/protocol/listoffsets"
+	"github.com/segmentio/kafka-go/protocol/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// compacted answers metadata and offset lookups for a changelog whose
+// partitions start past 0, the way a broker does: earliest and latest
+// lookups come back with timestamp -1.
+type compacted struct {
+	topic       string
+	first, last map[int]int64
+}
+
+func (c *compacted) RoundTrip(_ context.Context, _ net.Addr, req protocol.Message) (protocol.Message, error) {
+	switch req := req.(type) {
+	case *metadata.Request:
+		t := metadata.ResponseTopic{Name: c.topic}
+		for p := range c.first {
+			t.Partitions = append(t.Partitions, metadata.ResponsePartition{PartitionIndex: int32(p)})
+		}
+		return &metadata.Response{Topics: []metadata.ResponseTopic{t}}, nil
+	case *listoffsets.Request:
+		res := &listoffsets.Response{}
+		for _, rt := range req.Topics {
+			t := listoffsets.ResponseTopic{Topic: rt.Topic}
+			for _, rp := range rt.Partitions {
+				off := c.last[int(rp.Partition)]
+				if rp.Timestamp == kafka.FirstOffset {
+					off = c.first[int(rp.Partition)]
+				}
+				t.Partitions = append(t.Partitions, listoffsets.ResponsePartition{Partition: rp.Partition, Timestamp: -1, Offset: off})
+			}
+			res.Topics = append(res.Topics, t)
+		}
+		return res, nil
+	}
+	panic("unexpected request")
+}
+
+func TestKafkaBounds(t *testing.T) {
+	b := &KafkaBackend{
+		topic:  "state",
+		client: &kafka.Client{Addr: kafka.TCP("broker:9092"), Transport: &compacted{topic: "state", first: map[int]int64{0: 12, 1: 0}, last: map[int]int64{0: 30, 1: 4}}},
+	}
+	first, last, err := b.bounds(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[int]int64{0: 12, 1: 0}, first)
+	assert.Equal(t, map[int]int64{0: 30, 1: 4}, last)
+}
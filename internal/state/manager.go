@@ -0,0 +1,270 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// Manager checkpoints the registered stores and ties checkpoints to
+// consumer offsets: an offset is committable once a checkpoint taken after
+// every message before it was processed has been saved. It implements
+// consumer.StateSync.
+type Manager struct {
+	backend Backend
+	log     *zap.Logger
+
+	ckptMu sync.Mutex // serializes checkpoints
+
+	mu        sync.Mutex
+	stores    map[string]store
+	done      map[int]int64 // processed-up-to offsets reported by the consumer
+	durable   map[int]int64 // done as of the last saved checkpoint
+	committed map[int]int64 // offsets last handed out by Committable
+	lastErr   error
+	flush     func(context.Context) error
+}
+
+// NewManager creates a Manager saving to backend. With a nil backend state
+// lives in memory only: nothing is restored and checkpoints save nothing.
+func NewManager(backend Backend, log *zap.Logger) *Manager {
+	return &Manager{
+		backend:   backend,
+		log:       log.Named("state"),
+		stores:    make(map[string]store),
+		done:      make(map[int]int64),
+		durable:   make(map[int]int64),
+		committed: make(map[int]int64),
+	}
+}
+
+// Register creates the store called name. Stores must be registered before
+// the consumer starts so their state can be restored.
+func Register[T any](m *Manager, name string) (*Store[T], error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.stores[name]; ok {
+		return nil, fmt.Errorf("state store %q registered twice", name)
+	}
+	s := newStore[T](name)
+	m.stores[name] = s
+	sfmetrics.StateKeys.WithLabelValues(name).Set(0)
+	return s, nil
+}
+
+// SetFlush sets the function each checkpoint calls before saving, to write
+// the output stages buffered for the offsets it covers; a failed flush
+// fails the checkpoint, so those offsets are not committed.
+func (m *Manager) SetFlush(fn func(context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.flush = fn
+}
+
+// Restore loads the saved state of partitions, or of every partition when
+// partitions is nil. Commits for them wait for the next checkpoint.
+func (m *Manager) Restore(ctx context.Context, partitions []int) error {
+	if m.backend == nil {
+		return nil
+	}
+	entries, err := m.backend.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("state restore: %w", err)
+	}
+	want := toSet(partitions)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, e := range entries {
+		if want != nil && !want[e.Partition] {
+			continue
+		}
+		s, ok := m.stores[e.Store]
+		if !ok {
+			continue // store of a stage no longer configured
+		}
+		if err := s.restore(e); err != nil {
+			return err
+		}
+		n++
+	}
+	for _, p := range partitions {
+		delete(m.done, p)
+		delete(m.durable, p)
+		delete(m.committed, p)
+	}
+	sfmetrics.StateRestoredEntries.Add(float64(n))
+	m.log.Info("state restored", zap.Ints("partitions", partitions), zap.Int("entries", n))
+	return nil
+}
+
+// Release drops the state of partitions that are no longer assigned. Call
+// it after their final checkpoint and commit.
+func (m *Manager) Release(partitions []int) {
+	set := toSet(partitions)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.stores {
+		s.release(set)
+	}
+	for _, p := range partitions {
+		delete(m.done, p)
+		delete(m.durable, p)
+		delete(m.committed, p)
+	}
+}
+
+// Checkpoint flushes buffered output, then saves every entry changed since
+// the previous checkpoint.
+// offsets reports processed-up-to offsets in addition to those already
+// passed to Committable, e.g. before a final commit.
+func (m *Manager) Checkpoint(ctx context.Context, offsets map[int]int64) (err error) {
+	m.ckptMu.Lock()
+	defer m.ckptMu.Unlock()
+	start := time.Now()
+	defer func() {
+		sfmetrics.StateCheckpointLatencySeconds.Observe(time.Since(start).Seconds())
+		result := "success"
+		if err != nil {
+			result = "failure"
+		}
+		sfmetrics.StateCheckpointsTotal.WithLabelValues(result).Inc()
+		m.mu.Lock()
+		m.lastErr = err
+		m.mu.Unlock()
+	}()
+
+	// Capture the offsets before the entries: everything before them is
+	// already applied, and entries applied later are skipped on replay.
+	m.mu.Lock()
+	m.report(offsets)
+	capture := maps.Clone(m.done)
+	stores := maps.Clone(m.stores)
+	flush := m.flush
+	m.mu.Unlock()
+
+	// Output buffered for the captured offsets is written before they can
+	// become committable.
+	if flush != nil {
+		if err := flush(ctx); err != nil {
+			return fmt.Errorf("state checkpoint: flush: %w", err)
+		}
+	}
+
+	var entries []Entry
+	for _, s := range stores {
+		changed, err := s.changes()
+		entries = append(entries, changed...)
+		if err != nil {
+			unsave(stores, entries)
+			return err
+		}
+	}
+	if len(entries) > 0 {
+		if m.backend != nil {
+			if err := m.backend.Save(ctx, entries); err != nil {
+				unsave(stores, entries)
+				return fmt.Errorf("state checkpoint: %w", err)
+			}
+		}
+		for _, s := range stores {
+			s.saved()
+		}
+	}
+	sfmetrics.StateCheckpointEntries.Add(float64(len(entries)))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for p, off := range capture {
+		if _, ok := m.done[p]; ok && off > m.durable[p] {
+			m.durable[p] = off // still assigned
+		}
+	}
+	return nil
+}
+
+// Committable records offsets as processed and returns, per partition, the
+// offset that may be committed now: the processed offset as of the last
+// checkpoint. A partition held back earlier is returned once a checkpoint
+// catches up, even if it is absent from offsets.
+func (m *Manager) Committable(offsets map[int]int64) map[int]int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.report(offsets)
+	out := make(map[int]int64)
+	for p, off := range m.durable {
+		if c, ok := m.committed[p]; !ok || off > c {
+			out[p] = off
+			m.committed[p] = off
+		}
+	}
+	return out
+}
+
+// Run checkpoints every interval until ctx is cancelled. The final
+// checkpoint is taken by the consumer before its last commit.
+func (m *Manager) Run(ctx context.Context, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			if err := m.Checkpoint(ctx, nil); err != nil && ctx.Err() == nil {
+				m.log.Warn("checkpoint failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Check reports the error of the last checkpoint. It is registered as a
+// health check.
+func (m *Manager) Check(context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastErr
+}
+
+// Close closes the backend.
+func (m *Manager) Close() error {
+	if m.backend == nil {
+		return nil
+	}
+	return m.backend.Close()
+}
+
+// report merges processed offsets; the caller holds m.mu.
+func (m *Manager) report(offsets map[int]int64) {
+	for p, off := range offsets {
+		if off > m.done[p] {
+			m.done[p] = off
+		}
+	}
+}
+
+func unsave(stores map[string]store, entries []Entry) {
+	keys := make(map[string][]string)
+	for _, e := range entries {
+		keys[e.Store] = append(keys[e.Store], e.Key)
+	}
+	for name, k := range keys {
+		stores[name].unsaved(k)
+	}
+}
+
+func toSet(partitions []int) map[int]bool {
+	if partitions == nil {
+		return nil
+	}
+	set := make(map[int]bool, len(partitions))
+	for _, p := range partitions {
+		set[p] = true
+	}
+	return set
+}
@@ -0,0 +1,145 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jonandereg/streamforge/internal/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type bar struct {
+	Open, Close float64
+	Count       int
+}
+
+func at(partition int, offset int64) events.KafkaMeta {
+	return events.KafkaMeta{Partition: partition, Offset: offset}
+}
+
+func newManager(t *testing.T, b Backend) (*Manager, *Store[bar]) {
+	t.Helper()
+	m := NewManager(b, zap.NewNop())
+	s, err := Register[bar](m, "bars")
+	require.NoError(t, err)
+	return m, s
+}
+
+func TestReplayedMessagesAreNotApplied(t *testing.T) {
+	_, s := newManager(t, nil)
+	assert.True(t, s.Put("AAPL", bar{Open: 1, Count: 1}, at(0, 10)))
+	assert.True(t, s.Applied("AAPL", at(0, 10)))
+	assert.False(t, s.Put("AAPL", bar{Count: 99}, at(0, 9)), "replayed")
+	assert.True(t, s.Put("AAPL", bar{Open: 1, Count: 2}, at(0, 11)))
+	v, ok := s.Get("AAPL")
+	require.True(t, ok)
+	assert.Equal(t, 2, v.Count)
+
+	assert.True(t, s.Delete("AAPL", at(0, 12)))
+	_, ok = s.Get("AAPL")
+	assert.False(t, ok)
+}
+
+func TestModifyKeepsOffset(t *testing.T) {
+	_, s := newManager(t, nil)
+	assert.False(t, s.Modify("AAPL", func(*bar) { t.Fatal("called for a missing key") }))
+	s.Put("AAPL", bar{Count: 1}, at(0, 10))
+	assert.True(t, s.Modify("AAPL", func(b *bar) { b.Count++ }))
+	v, _ := s.Get("AAPL")
+	assert.Equal(t, 2, v.Count)
+	assert.True(t, s.Applied("AAPL", at(0, 10)))
+	assert.False(t, s.Applied("AAPL", at(0, 11)), "offset not advanced")
+}
+
+func TestDiskRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	disk, err := NewDiskBackend(dir)
+	require.NoError(t, err)
+
+	m, s := newManager(t, disk)
+	s.Put("AAPL", bar{Open: 1, Close: 2, Count: 3}, at(0, 10))
+	s.Put("MSFT", bar{Open: 5}, at(1, 4))
+	s.Put("TSLA", bar{Open: 7}, at(1, 5))
+	require.NoError(t, m.Checkpoint(ctx, nil))
+	s.Delete("TSLA", at(1, 6))
+	require.NoError(t, m.Checkpoint(ctx, nil))
+
+	// A fresh process restores only the partitions it is assigned.
+	reopened, err := NewDiskBackend(dir)
+	require.NoError(t, err)
+	m2, s2 := newManager(t, reopened)
+	require.NoError(t, m2.Restore(ctx, []int{0}))
+	v, ok := s2.Get("AAPL")
+	require.True(t, ok)
+	assert.Equal(t, bar{Open: 1, Close: 2, Count: 3}, v)
+	assert.True(t, s2.Applied("AAPL", at(0, 10)))
+	assert.Equal(t, 1, s2.Len())
+
+	require.NoError(t, m2.Restore(ctx, []int{1}))
+	_, ok = s2.Get("TSLA")
+	assert.False(t, ok, "deleted before the checkpoint")
+	assert.Equal(t, 2, s2.Len())
+
+	m2.Release([]int{0})
+	_, ok = s2.Get("AAPL")
+	assert.False(t, ok)
+}
+
+// failing fails saves while err is set.
+type failing struct {
+	err   error
+	saved []Entry
+}
+
+func (f *failing) Save(_ context.Context, e []Entry) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.saved = append(f.saved, e...)
+	return nil
+}
+func (f *failing) Load(context.Context) ([]Entry, error) { return nil, nil }
+func (f *failing) Close() error                          { return nil }
+
+func TestCommitsWaitForCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	b := &failing{}
+	m, s := newManager(t, b)
+
+	s.Put("AAPL", bar{Count: 1}, at(0, 41))
+	assert.Empty(t, m.Committable(map[int]int64{0: 42}), "not checkpointed yet")
+
+	b.err = errors.New("broker down")
+	require.Error(t, m.Checkpoint(ctx, nil))
+	assert.Error(t, m.Check(ctx))
+	assert.Empty(t, m.Committable(nil))
+
+	b.err = nil
+	require.NoError(t, m.Checkpoint(ctx, nil))
+	assert.Len(t, b.saved, 1, "the failed entry is saved again")
+	assert.NoError(t, m.Check(ctx))
+	assert.Equal(t, map[int]int64{0: 42}, m.Committable(nil), "held back offset released")
+	assert.Empty(t, m.Committable(map[int]int64{0: 50}))
+
+	require.NoError(t, m.Checkpoint(ctx, map[int]int64{1: 7}))
+	assert.Equal(t, map[int]int64{0: 50, 1: 7}, m.Committable(nil))
+}
+
+func TestCheckpointFlushesFirst(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newManager(t, nil)
+	flushErr := errors.New("sink down")
+	m.SetFlush(func(context.Context) error { return flushErr })
+
+	assert.Empty(t, m.Committable(map[int]int64{0: 10}))
+	require.ErrorIs(t, m.Checkpoint(ctx, nil), flushErr)
+	assert.Empty(t, m.Committable(nil), "output not written, offsets held back")
+
+	flushErr = nil
+	require.NoError(t, m.Checkpoint(ctx, nil))
+	assert.Equal(t, map[int]int64{0: 10}, m.Committable(nil))
+}
@@ -0,0 +1,248 @@
+// Package state keeps ticks-processor stage state (open bars, rolling
+// windows) across restarts. Stages hold their state in Stores keyed by
+// symbol; a Manager saves changed entries to a Backend and restores them
+// when partitions are assigned. Each entry remembers the offset of the last
+// message applied to it, so messages replayed after a restart are not
+// applied twice, and the consumer commits an offset only once a checkpoint
+// covers it.
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/jonandereg/streamforge/internal/events"
+	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
+)
+
+// Entry is one saved key of a store.
+type Entry struct {
+	Store     string `json:"store"`
+	Key       string `json:"key"`
+	Partition int    `json:"partition"`
+	Offset    int64  `json:"offset"`
+	// Value is the JSON-encoded state; nil deletes the key.
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// store is the untyped view of a Store used by the Manager.
+type store interface {
+	// changes returns the entries changed since the previous call.
+	changes() ([]Entry, error)
+	// unsaved marks keys changed again after a failed save.
+	unsaved(keys []string)
+	// saved forgets deleted keys once their deletion is saved.
+	saved()
+	restore(e Entry) error
+	release(partitions map[int]bool)
+}
+
+// Store holds the state of one stage keyed by symbol. Values are encoded as
+// JSON when saved, so T must round-trip through encoding/json. It is safe
+// for concurrent use.
+type Store[T any] struct {
+	name string
+
+	mu    sync.Mutex
+	items map[string]*item[T]
+	dirty map[string]bool
+}
+
+type item[T any] struct {
+	v         T
+	partition int
+	offset    int64
+	deleted   bool
+}
+
+func newStore[T any](name string) *Store[T] {
+	return &Store[T]{name: name, items: make(map[string]*item[T]), dirty: make(map[string]bool)}
+}
+
+// Get returns the value of key.
+func (s *Store[T]) Get(key string) (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it, ok := s.items[key]
+	if !ok || it.deleted {
+		var zero T
+		return zero, false
+	}
+	return it.v, true
+}
+
+// Applied reports whether the message at was already applied to key, i.e.
+// it is being replayed after a restore.
+func (s *Store[T]) Applied(key string, at events.KafkaMeta) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it, ok := s.items[key]
+	return ok && it.partition == at.Partition && it.offset >= at.Offset
+}
+
+// Put sets key to v as of the message at. It returns false and changes
+// nothing if at was already applied.
+func (s *Store[T]) Put(key string, v T, at events.KafkaMeta) bool {
+	return s.set(key, at, func(it *item[T]) {
+		it.v = v
+		it.deleted = false
+	})
+}
+
+// Delete removes key as of the message at.
+func (s *Store[T]) Delete(key string, at events.KafkaMeta) bool {
+	return s.set(key, at, func(it *item[T]) {
+		var zero T
+		it.v = zero
+		it.deleted = true
+	})
+}
+
+// Update calls fn with the value of key, which it may change in place, as
+// of the message at. fn runs under the store's lock so values holding
+// slices or maps cannot race a checkpoint encoding them; it must be quick.
+// Update returns false without calling fn if at was already applied.
+func (s *Store[T]) Update(key string, at events.KafkaMeta, fn func(v *T, exists bool)) bool {
+	return s.set(key, at, func(it *item[T]) {
+		exists := !it.deleted
+		if it.deleted {
+			var zero T
+			it.v = zero
+		}
+		fn(&it.v, exists)
+		it.deleted = false
+	})
+}
+
+// Modify calls fn with the value of key, which it may change in place,
+// without advancing the key's offset, for changes driven by the clock
+// rather than by a message. It returns false without calling fn when key
+// does not exist.
+func (s *Store[T]) Modify(key string, fn func(v *T)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it, ok := s.items[key]
+	if !ok || it.deleted {
+		return false
+	}
+	fn(&it.v)
+	s.dirty[key] = true
+	return true
+}
+
+func (s *Store[T]) set(key string, at events.KafkaMeta, fn func(*item[T])) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it, ok := s.items[key]
+	if ok && it.partition == at.Partition && it.offset >= at.Offset {
+		return false
+	}
+	if !ok {
+		it = &item[T]{deleted: true} // until fn sets it
+		s.items[key] = it
+		sfmetrics.StateKeys.WithLabelValues(s.name).Inc()
+	}
+	fn(it)
+	it.partition, it.offset = at.Partition, at.Offset
+	s.dirty[key] = true
+	return true
+}
+
+// Keys returns the keys held, in no particular order.
+func (s *Store[T]) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]string, 0, len(s.items))
+	for k, it := range s.items {
+		if !it.deleted {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// Len returns the number of keys held.
+func (s *Store[T]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+func (s *Store[T]) changes() ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Entry, 0, len(s.dirty))
+	for key := range s.dirty {
+		it := s.items[key]
+		e := Entry{Store: s.name, Key: key, Partition: it.partition, Offset: it.offset}
+		if !it.deleted {
+			b, err := json.Marshal(it.v)
+			if err != nil {
+				return nil, fmt.Errorf("state %s: encode %q: %w", s.name, key, err)
+			}
+			e.Value = b
+		}
+		out = append(out, e)
+	}
+	clear(s.dirty)
+	return out, nil
+}
+
+func (s *Store[T]) unsaved(keys []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range keys {
+		if _, ok := s.items[k]; ok {
+			s.dirty[k] = true
+		}
+	}
+}
+
+func (s *Store[T]) saved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, it := range s.items {
+		if it.deleted && !s.dirty[k] {
+			s.remove(k)
+		}
+	}
+}
+
+func (s *Store[T]) restore(e Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e.Value == nil {
+		if _, ok := s.items[e.Key]; ok {
+			s.remove(e.Key)
+		}
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(e.Value, &v); err != nil {
+		return fmt.Errorf("state %s: decode %q: %w", s.name, e.Key, err)
+	}
+	if _, ok := s.items[e.Key]; !ok {
+		sfmetrics.StateKeys.WithLabelValues(s.name).Inc()
+	}
+	s.items[e.Key] = &item[T]{v: v, partition: e.Partition, offset: e.Offset}
+	delete(s.dirty, e.Key)
+	return nil
+}
+
+func (s *Store[T]) release(partitions map[int]bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, it := range s.items {
+		if partitions[it.partition] {
+			s.remove(k)
+		}
+	}
+}
+
+// remove drops key; the caller holds s.mu.
+func (s *Store[T]) remove(key string) {
+	delete(s.items, key)
+	delete(s.dirty, key)
+	sfmetrics.StateKeys.WithLabelValues(s.name).Dec()
+}
@@ -0,0 +1,315 @@
+// Package store reads ticks and bars from the TimescaleDB ticks hypertable
+// and writes computed indicators and fired alerts (see db/migrations).
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jonandereg/streamforge/internal/config"
+	"github.com/jonandereg/streamforge/internal/corpactions"
+	"github.com/jonandereg/streamforge/internal/model"
+	"github.com/jonandereg/streamforge/internal/refdata"
+	"github.com/jonandereg/streamforge/internal/secrets"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/jonandereg/streamforge/internal/store"
+
+// ErrNotConfigured is returned by New when database.url is empty.
+var ErrNotConfigured = errors.New("database.url not set")
+
+// Bar is an OHLCV aggregate of the ticks in [Start, Start+interval).
+type Bar struct {
+	Start  time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+	Count  int64
+}
+
+// TickStore queries the ticks hypertable and writes the indicators and
+// alerts ones.
+type TickStore struct {
+	pool *pgxpool.Pool
+}
+
+// New connects a pool to cfg.URL, resolving it with r, and pings it.
+func New(ctx context.Context, cfg config.Database, r *secrets.Resolver) (*TickStore, error) {
+	if cfg.URL == "" {
+		return nil, ErrNotConfigured
+	}
+	url, err := r.Resolve(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("database.url: %w", err)
+	}
+	pcfg, err := pgxpool.ParseConfig(url.Reveal())
+	if err != nil {
+		return nil, secrets.RedactError(fmt.Errorf("database.url: %w", err))
+	}
+	if cfg.MaxConns > 0 {
+		pcfg.MaxConns = cfg.MaxConns
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, pcfg)
+	if err != nil {
+		return nil, secrets.RedactError(err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, secrets.RedactError(fmt.Errorf("database ping: %w", err))
+	}
+	return &TickStore{pool: pool}, nil
+}
+
+// Ping checks a pooled connection. It is registered as a health check.
+func (s *TickStore) Ping(ctx context.Context) error {
+	return s.pool.Ping(ctx)
+}
+
+// Close closes the pool.
+func (s *TickStore) Close() {
+	s.pool.Close()
+}
+
+const ticksSQL = `
+SELECT symbol, ts, price::float8, size::float8, exchange, src_id
+FROM ticks
+WHERE symbol = ANY($1) AND ts >= $2 AND ts < $3
+ORDER BY ts, symbol, src_id`
+
+// Ticks calls fn for every tick of symbols in [from, to) in timestamp order.
+// Rows are streamed, so fn may block (e.g. on a slow client) without the
+// whole range being buffered. Iteration stops at the first error from fn.
+func (s *TickStore) Ticks(ctx context.Context, symbols []string, from, to time.Time, fn func(model.Tick) error) error {
+	ctx, span := s.start(ctx, "store.ticks", attribute.StringSlice("symbols", symbols))
+	defer span.End()
+
+	rows, err := s.pool.Query(ctx, ticksSQL, symbols, from, to)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		t, err := scanTick(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+const latestSQL = `
+SELECT DISTINCT ON (symbol) symbol, ts, price::float8, size::float8, exchange, src_id
+FROM ticks
+WHERE symbol = ANY($1)
+ORDER BY symbol, ts DESC`
+
+// Latest returns the most recent stored tick of each symbol that has one.
+func (s *TickStore) Latest(ctx context.Context, symbols []string) ([]model.Tick, error) {
+	ctx, span := s.start(ctx, "store.latest", attribute.StringSlice("symbols", symbols))
+	defer span.End()
+
+	rows, err := s.pool.Query(ctx, latestSQL, symbols)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, scanTick)
+}
+
+const barsSQL = `
+SELECT time_bucket($2::interval, ts, $6::timestamptz) AS bucket,
+       first(price, ts)::float8, max(price)::float8, min(price)::float8, last(price, ts)::float8,
+       sum(size)::float8, count(*)
+FROM ticks
+WHERE symbol = $1 AND ts >= $3 AND ts < $4
+GROUP BY bucket
+ORDER BY bucket
+LIMIT $5`
+
+// DefaultOrigin is where bars are aligned by default: midnight UTC on a
+// Monday, as in TimescaleDB's time_bucket.
+var DefaultOrigin = time.Date(2000, 1, 3, 0, 0, 0, 0, time.UTC)
+
+// Bars aggregates the ticks of symbol in [from, to) into bars of interval
+// starting at origin plus a multiple of interval, returning at most limit
+// bars in time order.
+func (s *TickStore) Bars(ctx context.Context, symbol string, interval time.Duration, origin, from, to time.Time, limit int) ([]Bar, error) {
+	ctx, span := s.start(ctx, "store.bars",
+		attribute.String("symbol", symbol),
+		attribute.String("interval", interval.String()),
+	)
+	defer span.End()
+
+	iv := fmt.Sprintf("%d microseconds", interval.Microseconds())
+	rows, err := s.pool.Query(ctx, barsSQL, symbol, iv, from, to, limit, origin)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Bar, error) {
+		var b Bar
+		err := row.Scan(&b.Start, &b.Open, &b.High, &b.Low, &b.Close, &b.Volume, &b.Count)
+		b.Start = b.Start.UTC()
+		return b, err
+	})
+}
+
+// IndicatorValue is one row of the indicators hypertable.
+type IndicatorValue struct {
+	Ts     time.Time
+	Symbol string
+	Name   string
+	Value  float64
+}
+
+// InsertIndicators copies rows into the indicators hypertable.
+func (s *TickStore) InsertIndicators(ctx context.Context, rows []IndicatorValue) error {
+	ctx, span := s.start(ctx, "store.insert_indicators", attribute.Int("rows", len(rows)))
+	defer span.End()
+
+	_, err := s.pool.CopyFrom(ctx, pgx.Identifier{"indicators"},
+		[]string{"ts", "symbol", "indicator", "value"},
+		pgx.CopyFromSlice(len(rows), func(i int) ([]any, error) {
+			r := rows[i]
+			return []any{r.Ts, r.Symbol, r.Name, r.Value}, nil
+		}),
+	)
+	return err
+}
+
+// AlertRecord is one row of the alerts hypertable.
+type AlertRecord struct {
+	ID      string
+	Ts      time.Time
+	Rule    string
+	Kind    string
+	Symbol  string
+	Price   float64
+	Message string
+}
+
+const insertAlertSQL = `
+INSERT INTO alerts (id, ts, rule, kind, symbol, price, message)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT DO NOTHING`
+
+// InsertAlert persists a fired alert. An alert already stored under the
+// same ID and ts, e.g. fired again for a replayed tick, is ignored.
+func (s *TickStore) InsertAlert(ctx context.Context, a AlertRecord) error {
+	ctx, span := s.start(ctx, "store.insert_alert", attribute.String("rule", a.Rule))
+	defer span.End()
+
+	_, err := s.pool.Exec(ctx, insertAlertSQL, a.ID, a.Ts, a.Rule, a.Kind, a.Symbol, a.Price, a.Message)
+	return err
+}
+
+const instrumentsSQL = `
+SELECT id, asset_class, venue, currency, tick_size, aliases
+FROM instruments
+ORDER BY id`
+
+// Instruments returns the symbol master from the instruments table.
+func (s *TickStore) Instruments(ctx context.Context) ([]refdata.Instrument, error) {
+	ctx, span := s.start(ctx, "store.instruments")
+	defer span.End()
+
+	rows, err := s.pool.Query(ctx, instrumentsSQL)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (refdata.Instrument, error) {
+		var in refdata.Instrument
+		err := row.Scan(&in.ID, &in.AssetClass, &in.Venue, &in.Currency, &in.TickSize, &in.Aliases)
+		return in, err
+	})
+}
+
+const corporateActionsSQL = `
+SELECT symbol, ex_date, kind, ratio, amount
+FROM corporate_actions
+WHERE symbol = $1 AND ex_date >= $2::date
+ORDER BY ex_date, kind`
+
+// CorporateActions returns the actions of symbol with an ex-date on or
+// after the date of since, in ex-date order.
+func (s *TickStore) CorporateActions(ctx context.Context, symbol string, since time.Time) ([]corpactions.Action, error) {
+	ctx, span := s.start(ctx, "store.corporate_actions", attribute.String("symbol", symbol))
+	defer span.End()
+
+	rows, err := s.pool.Query(ctx, corporateActionsSQL, symbol, since.UTC().Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (corpactions.Action, error) {
+		var a corpactions.Action
+		err := row.Scan(&a.Symbol, &a.ExDate, &a.Kind, &a.Ratio, &a.Amount)
+		return a, err
+	})
+}
+
+const upsertCorporateActionSQL = `
+INSERT INTO corporate_actions (symbol, ex_date, kind, ratio, amount)
+VALUES ($1, $2::date, $3, $4, $5)
+ON CONFLICT (symbol, ex_date, kind) DO UPDATE
+SET ratio = EXCLUDED.ratio, amount = EXCLUDED.amount, updated_at = now()`
+
+// UpsertCorporateActions stores actions in one transaction, replacing the
+// ratio and amount of actions already stored for the same symbol, ex-date
+// and kind.
+func (s *TickStore) UpsertCorporateActions(ctx context.Context, actions []corpactions.Action) error {
+	ctx, span := s.start(ctx, "store.upsert_corporate_actions", attribute.Int("rows", len(actions)))
+	defer span.End()
+
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		var b pgx.Batch
+		for _, a := range actions {
+			b.Queue(upsertCorporateActionSQL, a.Symbol, a.ExDate.Format(time.DateOnly), string(a.Kind), a.Ratio, a.Amount)
+		}
+		return tx.SendBatch(ctx, &b).Close()
+	})
+}
+
+const priceBeforeSQL = `
+SELECT price::float8
+FROM ticks
+WHERE symbol = $1 AND ts < $2
+ORDER BY ts DESC
+LIMIT 1`
+
+// PriceBefore returns the price of the last tick of symbol before t; false
+// when there is none.
+func (s *TickStore) PriceBefore(ctx context.Context, symbol string, t time.Time) (float64, bool, error) {
+	ctx, span := s.start(ctx, "store.price_before", attribute.String("symbol", symbol))
+	defer span.End()
+
+	var p float64
+	err := s.pool.QueryRow(ctx, priceBeforeSQL, symbol, t).Scan(&p)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	return p, err == nil, err
+}
+
+func scanTick(row pgx.CollectableRow) (model.Tick, error) {
+	var t model.Tick
+	err := row.Scan(&t.Symbol, &t.Ts, &t.Price, &t.Size, &t.Exchange, &t.SrcID)
+	t.Ts = t.Ts.UTC()
+	return t, err
+}
+
+func (s *TickStore) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append(attrs, attribute.String("db.system", "postgresql"))...),
+	)
+}
@@ -0,0 +1,181 @@
+// Package stream fans live ticks out to in-process subscribers such as gRPC
+// streams. Publishing never blocks: a subscriber whose buffer is full is
+// cancelled instead of slowing the pipeline down.
+package stream
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/jonandereg/streamforge/internal/events"
+	"github.com/jonandereg/streamforge/internal/model"
+)
+
+var (
+	// ErrSlowConsumer is reported by Subscription.Err when the subscriber
+	// fell more than its buffer behind.
+	ErrSlowConsumer = errors.New("stream: subscriber too slow")
+	// ErrClosed is reported by Subscription.Err after Hub.Close.
+	ErrClosed = errors.New("stream: hub closed")
+)
+
+// Hub distributes published ticks to the subscribers of their symbol and
+// remembers the latest tick per symbol.
+type Hub struct {
+	mu     sync.RWMutex
+	subs   map[string]map[*Subscription]struct{} // symbol → subscribers
+	latest map[string]model.Tick
+	closed bool
+}
+
+// NewHub creates an empty Hub.
+func NewHub() *Hub {
+	return &Hub{
+		subs:   make(map[string]map[*Subscription]struct{}),
+		latest: make(map[string]model.Tick),
+	}
+}
+
+// Subscription receives the live ticks of a set of symbols on C. C is closed
+// when the subscription ends; Err tells why.
+type Subscription struct {
+	C <-chan model.Tick
+
+	hub     *Hub
+	ch      chan model.Tick
+	symbols []string
+	done    bool // guarded by hub.mu
+	err     error
+	held    bool         // guarded by hub.mu; ticks go to backlog instead of ch
+	backlog []model.Tick // guarded by hub.mu
+}
+
+// Subscribe registers a subscription for symbols with room for buffer
+// undelivered ticks.
+func (h *Hub) Subscribe(symbols []string, buffer int) *Subscription {
+	return h.subscribe(symbols, buffer, false)
+}
+
+// SubscribeHeld is Subscribe for a subscriber that is not ready to receive
+// yet, e.g. while it replays history: ticks published until Release are
+// held without limit instead of counting against buffer.
+func (h *Hub) SubscribeHeld(symbols []string, buffer int) *Subscription {
+	return h.subscribe(symbols, buffer, true)
+}
+
+func (h *Hub) subscribe(symbols []string, buffer int, held bool) *Subscription {
+	ch := make(chan model.Tick, buffer)
+	s := &Subscription{C: ch, hub: h, ch: ch, symbols: slices.Clone(symbols), held: held}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		s.endLocked(ErrClosed)
+		return s
+	}
+	for _, sym := range s.symbols {
+		set, ok := h.subs[sym]
+		if !ok {
+			set = make(map[*Subscription]struct{})
+			h.subs[sym] = set
+		}
+		set[s] = struct{}{}
+	}
+	return s
+}
+
+// Close ends every subscription with ErrClosed; later subscriptions end
+// immediately. Publishing still updates the latest ticks.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, set := range h.subs {
+		for s := range set {
+			s.endLocked(ErrClosed)
+		}
+	}
+}
+
+// Release returns the ticks held since SubscribeHeld, in publish order, and
+// starts delivering on C; from then on a subscriber that falls more than its
+// buffer behind is dropped.
+func (s *Subscription) Release() []model.Tick {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	held := s.backlog
+	s.held, s.backlog = false, nil
+	return held
+}
+
+// Close ends the subscription. It is safe to call more than once.
+func (s *Subscription) Close() { s.end(context.Canceled) }
+
+// Err returns why C was closed: context.Canceled after Close, ErrClosed or
+// ErrSlowConsumer. It is nil while the subscription is live.
+func (s *Subscription) Err() error {
+	s.hub.mu.RLock()
+	defer s.hub.mu.RUnlock()
+	return s.err
+}
+
+func (s *Subscription) end(err error) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.endLocked(err)
+}
+
+// endLocked removes s from the hub once; h.mu must be held for writing.
+func (s *Subscription) endLocked(err error) {
+	if s.done {
+		return
+	}
+	s.done = true
+	for _, sym := range s.symbols {
+		delete(s.hub.subs[sym], s)
+		if len(s.hub.subs[sym]) == 0 {
+			delete(s.hub.subs, sym)
+		}
+	}
+	s.err = err
+	s.backlog = nil
+	close(s.ch)
+}
+
+// Publish delivers t to the subscribers of t.Symbol and records it as the
+// latest tick unless it is older than the one already recorded.
+func (h *Hub) Publish(t model.Tick) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if prev, ok := h.latest[t.Symbol]; !ok || !t.Ts.Before(prev.Ts) {
+		h.latest[t.Symbol] = t
+	}
+	for s := range h.subs[t.Symbol] {
+		if s.held {
+			s.backlog = append(s.backlog, t)
+			continue
+		}
+		select {
+		case s.ch <- t:
+		default:
+			s.endLocked(ErrSlowConsumer)
+		}
+	}
+}
+
+// Latest returns the most recent published tick of symbol.
+func (h *Hub) Latest(symbol string) (model.Tick, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	t, ok := h.latest[symbol]
+	return t, ok
+}
+
+// Process publishes msg's tick; it lets a Hub sit in the worker pipeline.
+func (h *Hub) Process(_ context.Context, msg events.TickMsg) error {
+	if msg.Tick.Symbol != "" {
+		h.Publish(msg.Tick)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tick(sym string, sec int64) model.Tick {
+	return model.Tick{Symbol: sym, Ts: time.Unix(sec, 0).UTC(), Price: float64(sec)}
+}
+
+func TestPublishRoutesBySymbol(t *testing.T) {
+	h := NewHub()
+	aapl := h.Subscribe([]string{"AAPL"}, 4)
+	both := h.Subscribe([]string{"AAPL", "MSFT"}, 4)
+
+	h.Publish(tick("AAPL", 1))
+	h.Publish(tick("MSFT", 2))
+	h.Publish(tick("TSLA", 3))
+
+	assert.Len(t, aapl.C, 1)
+	assert.Len(t, both.C, 2)
+
+	aapl.Close()
+	aapl.Close()
+	<-aapl.C
+	_, open := <-aapl.C
+	assert.False(t, open)
+	assert.ErrorIs(t, aapl.Err(), context.Canceled)
+}
+
+func TestSlowConsumerIsDropped(t *testing.T) {
+	h := NewHub()
+	sub := h.Subscribe([]string{"AAPL"}, 1)
+	h.Publish(tick("AAPL", 1))
+	h.Publish(tick("AAPL", 2)) // overflows
+
+	got := <-sub.C
+	assert.Equal(t, int64(1), got.Ts.Unix())
+	_, open := <-sub.C
+	assert.False(t, open)
+	assert.ErrorIs(t, sub.Err(), ErrSlowConsumer)
+
+	h.Publish(tick("AAPL", 3)) // no subscriber left; must not panic
+}
+
+func TestLatestAndClose(t *testing.T) {
+	h := NewHub()
+	h.Publish(tick("AAPL", 5))
+	h.Publish(tick("AAPL", 4)) // late tick does not replace the latest
+	got, ok := h.Latest("AAPL")
+	require.True(t, ok)
+	assert.Equal(t, int64(5), got.Ts.Unix())
+
+	sub := h.Subscribe([]string{"AAPL"}, 1)
+	h.Close()
+	_, open := <-sub.C
+	assert.False(t, open)
+	assert.ErrorIs(t, sub.Err(), ErrClosed)
+	assert.ErrorIs(t, h.Subscribe([]string{"AAPL"}, 1).Err(), ErrClosed)
+}
+
+func TestHeldSubscription(t *testing.T) {
+	h := NewHub()
+	sub := h.SubscribeHeld([]string{"AAPL"}, 1)
+	for sec := range int64(5) {
+		h.Publish(tick("AAPL", sec))
+	}
+	held := sub.Release()
+	require.Len(t, held, 5)
+	assert.Equal(t, int64(4), held[4].Ts.Unix())
+
+	h.Publish(tick("AAPL", 5))
+	h.Publish(tick("AAPL", 6))
+	<-sub.C
+	_, ok := <-sub.C
+	assert.False(t, ok)
+	assert.ErrorIs(t, sub.Err(), ErrSlowConsumer)
+}
@@ -0,0 +1,192 @@
+// Package supervisor runs long-lived goroutines with panic recovery and
+// restart-with-backoff, and reports their status.
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// Component states reported by Status.
+const (
+	StateRunning    = "running"
+	StateRestarting = "restarting"
+	StateStopped    = "stopped"
+)
+
+// PanicError wraps a recovered panic value together with its stack.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Recover converts a panic into a *PanicError stored in err. Use it as
+// `defer supervisor.Recover(&err)` in functions that must not crash the process.
+func Recover(err *error) {
+	if r := recover(); r != nil {
+		*err = &PanicError{Value: r, Stack: debug.Stack()}
+	}
+}
+
+// Status is a point-in-time view of a supervised component.
+type Status struct {
+	Name        string    `json:"name"`
+	State       string    `json:"state"`
+	Restarts    int       `json:"restarts"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastStart   time.Time `json:"last_start"`
+	LastFailure time.Time `json:"last_failure,omitempty"`
+}
+
+// Supervisor starts and restarts named components.
+type Supervisor struct {
+	log         *zap.Logger
+	backoffBase time.Duration
+	backoffMax  time.Duration
+
+	wg         sync.WaitGroup
+	mu         sync.RWMutex
+	components map[string]*Status
+}
+
+// New creates a Supervisor. Restarts back off exponentially from base up to max.
+func New(log *zap.Logger, base, max time.Duration) *Supervisor {
+	if base <= 0 {
+		base = 200 * time.Millisecond
+	}
+	if max < base {
+		max = 30 * time.Second
+	}
+	return &Supervisor{
+		log:         log.Named("supervisor"),
+		backoffBase: base,
+		backoffMax:  max,
+		components:  make(map[string]*Status),
+	}
+}
+
+// Go runs fn under supervision. If fn panics or returns an error it is
+// restarted after a backoff; if it returns nil or ctx is done it is
+// considered stopped. The backoff resets once a run outlives backoffMax.
+func (s *Supervisor) Go(ctx context.Context, name string, fn func(ctx context.Context) error) {
+	s.mu.Lock()
+	s.components[name] = &Status{Name: name, State: StateRunning}
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.setState(name, StateStopped)
+
+		clog := s.log.With(zap.String("component", name))
+		backoff := s.backoffBase
+		for {
+			s.started(name)
+			start := time.Now()
+			err := run(ctx, fn)
+			if err == nil || ctx.Err() != nil {
+				return
+			}
+
+			var pe *PanicError
+			if errors.As(err, &pe) {
+				sfmetrics.SupervisorPanicsTotal.WithLabelValues(name).Inc()
+				clog.Error("component panicked, restarting",
+					zap.Any("panic", pe.Value),
+					zap.ByteString("stack", pe.Stack),
+					zap.Duration("sleep", backoff),
+				)
+			} else {
+				clog.Warn("component failed, restarting", zap.Error(err), zap.Duration("sleep", backoff))
+			}
+			if time.Since(start) > s.backoffMax {
+				backoff = s.backoffBase
+			}
+			s.failed(name, err)
+
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return
+			}
+			backoff *= 2
+			if backoff > s.backoffMax {
+				backoff = s.backoffMax
+			}
+			sfmetrics.SupervisorRestartsTotal.WithLabelValues(name).Inc()
+		}
+	}()
+}
+
+// Wait blocks until every supervised component has stopped.
+func (s *Supervisor) Wait() {
+	s.wg.Wait()
+}
+
+// Snapshot returns the status of all components sorted by name.
+func (s *Supervisor) Snapshot() []Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Status, 0, len(s.components))
+	for _, st := range s.components {
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Handler serves the component snapshot as JSON.
+func (s *Supervisor) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(s.Snapshot())
+	})
+}
+
+func run(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	defer Recover(&err)
+	return fn(ctx)
+}
+
+func (s *Supervisor) started(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.components[name]
+	if st.State == StateRestarting {
+		st.Restarts++
+	}
+	st.State = StateRunning
+	st.LastStart = time.Now().UTC()
+	sfmetrics.SupervisorUp.WithLabelValues(name).Set(1)
+}
+
+func (s *Supervisor) failed(name string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.components[name]
+	st.State = StateRestarting
+	st.LastError = err.Error()
+	st.LastFailure = time.Now().UTC()
+	sfmetrics.SupervisorUp.WithLabelValues(name).Set(0)
+}
+
+func (s *Supervisor) setState(name, state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.components[name].State = state
+	sfmetrics.SupervisorUp.WithLabelValues(name).Set(0)
+}
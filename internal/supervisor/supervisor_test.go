@@ -0,0 +1,46 @@
+package supervisor
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRestartsAfterPanic(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := New(zap.NewNop(), time.Millisecond, 5*time.Millisecond)
+	var runs atomic.Int32
+	s.Go(ctx, "flaky", func(context.Context) error {
+		if runs.Add(1) < 3 {
+			panic("boom")
+		}
+		return nil
+	})
+	s.Wait()
+
+	assert.Equal(t, int32(3), runs.Load())
+	snap := s.Snapshot()
+	require.Len(t, snap, 1)
+	assert.Equal(t, StateStopped, snap[0].State)
+	assert.Equal(t, 2, snap[0].Restarts)
+	assert.Equal(t, "panic: boom", snap[0].LastError)
+}
+
+func TestStopsOnContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := New(zap.NewNop(), time.Millisecond, time.Millisecond)
+	s.Go(ctx, "blocking", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	cancel()
+	s.Wait()
+	assert.Equal(t, StateStopped, s.Snapshot()[0].State)
+}
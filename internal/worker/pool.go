@@ -2,41 +2,352 @@ package worker
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/jonandereg/streamforge/internal/deadletter"
 	"github.com/jonandereg/streamforge/internal/events"
+	"github.com/jonandereg/streamforge/internal/health"
+	sfmetrics "github.com/jonandereg/streamforge/internal/metrics"
+	"github.com/jonandereg/streamforge/internal/supervisor"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.uber.org/zap"
 )
 
+const tracerName = "github.com/jonandereg/streamforge/internal/worker"
+
 // Processor abstracts business processing for a TickMsg.
 type Processor interface {
 	Process(ctx context.Context, msg events.TickMsg) error
 }
 
-func StartWorkers(ctx context.Context, inputs []chan events.TickMsg, proc Processor, log *zap.Logger) {
+// ErrSkip is returned, possibly wrapped, by a Processor that deliberately
+// discarded a message (a filter). The message is acknowledged without
+// counting as a failure, and processors after it in a chain do not see it.
+var ErrSkip = errors.New("message skipped")
+
+// Starter is implemented by processors that need setup before the first
+// message, such as opening connections or restoring state. Start is called
+// once before the workers start; an error aborts StartWorkers.
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Flusher is implemented by processors that buffer output (sinks,
+// aggregators). Flush is called every flush interval while the workers run,
+// never concurrently with Process: the workers wait while it runs. It is
+// called once more on shutdown after all workers stopped and before the
+// final offsets are committed.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// Closer is implemented by processors holding resources. Close is called
+// once on shutdown, after the final Flush.
+type Closer interface {
+	Close(ctx context.Context) error
+}
+
+// ErrNotFlusher is returned by Pool.Flush when the processor does not buffer.
+var ErrNotFlusher = errors.New("processor does not buffer output")
+
+// Thresholds holds worker limits that can be changed while workers run.
+type Thresholds struct {
+	slow  atomic.Int64
+	stuck atomic.Int64
+	flush atomic.Int64
+}
+
+// SetSlow sets the processing time above which a message is logged as slow;
+// 0 disables the warning.
+func (t *Thresholds) SetSlow(d time.Duration) { t.slow.Store(int64(d)) }
+
+// Slow returns the current slow-processing threshold.
+func (t *Thresholds) Slow() time.Duration { return time.Duration(t.slow.Load()) }
+
+// SetStuck sets how long a worker may spend on one message before the
+// liveness check reports it stuck; 0 disables the check.
+func (t *Thresholds) SetStuck(d time.Duration) { t.stuck.Store(int64(d)) }
+
+// Stuck returns the current stuck threshold.
+func (t *Thresholds) Stuck() time.Duration { return time.Duration(t.stuck.Load()) }
+
+// SetFlushEvery sets how often a Flusher processor is flushed while the
+// workers run; 0 disables periodic flushes.
+func (t *Thresholds) SetFlushEvery(d time.Duration) { t.flush.Store(int64(d)) }
+
+// FlushEvery returns the current periodic flush interval.
+func (t *Thresholds) FlushEvery() time.Duration { return time.Duration(t.flush.Load()) }
+
+// StartWorkers calls proc's Start hook, then runs one supervised worker per
+// input channel and, for a Flusher, a supervised "flusher" that flushes it
+// every th.FlushEvery until the workers finish. A panic in proc.Process sends
+// the offending message to dlq and restarts the worker with backoff; the
+// input channel is kept, so queued messages are not lost. th and hr may be
+// nil; with hr each worker registers a watchdog named after its supervisor
+// name, and failing hooks are reported by the non-critical "processor" check.
+// Call Pool.Stop once the workers are done.
+func StartWorkers(ctx context.Context, inputs []chan events.TickMsg, proc Processor, sup *supervisor.Supervisor, dlq deadletter.Sink, th *Thresholds, hr *health.Registry, log *zap.Logger) (*Pool, error) {
+	if th == nil {
+		th = &Thresholds{}
+	}
+	p := &Pool{proc: proc, th: th, done: make(chan struct{}), failed: make(map[string]error), log: log.Named("worker")}
+	if s, ok := proc.(Starter); ok {
+		if err := p.hook(ctx, "start", s.Start); err != nil {
+			return nil, err
+		}
+	}
+	if hr != nil {
+		hr.Register("processor", health.NonCritical, p.Check)
+	}
+
+	var running sync.WaitGroup
+	running.Add(len(inputs))
 	for i := range inputs {
-		i := i
-		ch := inputs[i]
-		go func() {
-			wlog := log.Named("worker").With(zap.Int("id", i))
-			wlog.Info("started")
-			defer wlog.Info("stopped")
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case msg, ok := <-ch:
-					if !ok {
-						return
-					}
-					if err := proc.Process(ctx, msg); err != nil {
-						// retry / policies can be added here later
-						wlog.Warn("process failed",
-							zap.String("symbol", msg.Tick.Symbol),
-							zap.Error(err),
-						)
-					}
+		name := "worker-" + strconv.Itoa(i)
+		var wd *health.Watchdog
+		if hr != nil {
+			wd = hr.Watchdog(name, th.Stuck())
+		}
+		w := &worker{
+			id:     i,
+			in:     inputs[i],
+			proc:   proc,
+			procMu: &p.procMu,
+			dlq:    dlq,
+			th:     th,
+			wd:     wd,
+			log:    p.log.With(zap.Int("id", i)),
+		}
+		sup.Go(ctx, name, func(ctx context.Context) error {
+			err := w.run(ctx)
+			if err == nil {
+				running.Done() // not restarted
+			}
+			return err
+		})
+	}
+	go func() {
+		running.Wait()
+		close(p.done)
+	}()
+	if _, ok := proc.(Flusher); ok {
+		sup.Go(ctx, "flusher", p.flushLoop)
+	}
+	return p, nil
+}
+
+// Pool calls the lifecycle hooks of the processor shared by the workers and
+// remembers failed hooks for health checks.
+type Pool struct {
+	proc Processor
+	th   *Thresholds
+	done chan struct{} // closed once every worker has finished
+	log  *zap.Logger
+
+	procMu sync.RWMutex // shared by Process calls, exclusive for Flush
+
+	mu     sync.Mutex
+	failed map[string]error // by hook, until the hook next succeeds
+}
+
+// Flush flushes the processor now, e.g. on an admin request. It returns
+// ErrNotFlusher when the processor does not buffer.
+func (p *Pool) Flush(ctx context.Context) error {
+	f, ok := p.proc.(Flusher)
+	if !ok {
+		return ErrNotFlusher
+	}
+	p.procMu.Lock()
+	defer p.procMu.Unlock()
+	return p.hook(ctx, "flush", f.Flush)
+}
+
+// Stop runs the final Flush and then Close. Call it after the workers are
+// done and before the final offsets are committed.
+func (p *Pool) Stop(ctx context.Context) error {
+	var errs []error
+	if f, ok := p.proc.(Flusher); ok {
+		errs = append(errs, p.hook(ctx, "flush", f.Flush))
+	}
+	if c, ok := p.proc.(Closer); ok {
+		errs = append(errs, p.hook(ctx, "close", c.Close))
+	}
+	return errors.Join(errs...)
+}
+
+// Check reports failed hooks until a later call of the same hook succeeds.
+func (p *Pool) Check(context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var errs []error
+	for _, name := range []string{"start", "flush", "close"} {
+		errs = append(errs, p.failed[name])
+	}
+	return errors.Join(errs...)
+}
+
+// flushLoop flushes every th.FlushEvery until the workers finish. The
+// interval is re-read each round so reloads apply.
+func (p *Pool) flushLoop(ctx context.Context) error {
+	for {
+		d := p.th.FlushEvery()
+		wait := d
+		if wait <= 0 {
+			wait = time.Second // disabled; check again for a reload
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil
+		case <-p.done:
+			t.Stop()
+			return nil
+		case <-t.C:
+		}
+		if d > 0 {
+			if err := p.Flush(ctx); err != nil {
+				p.log.Warn("periodic flush failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// hook calls fn, converting a panic into an error, and records the outcome.
+func (p *Pool) hook(ctx context.Context, name string, fn func(context.Context) error) (err error) {
+	defer func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if err != nil {
+			sfmetrics.ProcessorHookErrorsTotal.WithLabelValues(name).Inc()
+			err = fmt.Errorf("processor %s: %w", name, err)
+		}
+		p.failed[name] = err
+	}()
+	defer supervisor.Recover(&err)
+	return fn(ctx)
+}
+
+type worker struct {
+	id     int
+	in     chan events.TickMsg
+	proc   Processor
+	procMu *sync.RWMutex
+	dlq    deadletter.Sink
+	th     *Thresholds
+	wd     *health.Watchdog
+	log    *zap.Logger
+}
+
+// run consumes the input channel until it is closed or ctx is done. It returns
+// the panic error after dead-lettering the message that caused it. Every
+// message is marked done once handled, successfully or not, so its offset can
+// be committed; only a message interrupted by ctx is not.
+func (w *worker) run(ctx context.Context) error {
+	label := strconv.Itoa(w.id)
+	depth := sfmetrics.ProcessorRouterQueueDepth.WithLabelValues(label)
+	latency := sfmetrics.ProcessorWorkerLatencySeconds.WithLabelValues(label)
+	w.log.Info("started")
+	defer w.log.Info("stopped")
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-w.in:
+			if !ok {
+				return nil
+			}
+			depth.Set(float64(len(w.in)))
+			start := time.Now()
+			err := w.process(ctx, msg)
+			done := time.Now()
+			latency.Observe(done.Sub(start).Seconds())
+			if slow := w.th.Slow(); slow > 0 && done.Sub(start) > slow {
+				w.log.Warn("slow process",
+					zap.String("symbol", msg.Tick.Symbol),
+					zap.Duration("took", done.Sub(start)),
+					zap.Duration("threshold", slow),
+				)
+			}
+
+			var pe *supervisor.PanicError
+			if errors.As(err, &pe) {
+				sfmetrics.ProcessorFailedTotal.WithLabelValues("process").Inc()
+				if dlqErr := w.dlq.Send(ctx, msg, "panic", err); dlqErr != nil {
+					w.log.Error("dead letter failed", zap.Error(dlqErr))
 				}
+				msg.Done()
+				return err
+			}
+			if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+				// Interrupted by shutdown: leave it unacknowledged so the
+				// committed offset stays behind it and it is redelivered.
+				w.log.Info("processing interrupted by shutdown", zap.String("symbol", msg.Tick.Symbol))
+				return nil
+			}
+			if errors.Is(err, ErrSkip) {
+				w.log.Debug("message skipped",
+					zap.String("symbol", msg.Tick.Symbol),
+					zap.Error(err),
+				)
+				msg.Done()
+				continue
 			}
-		}()
+			if err != nil {
+				sfmetrics.ProcessorFailedTotal.WithLabelValues("process").Inc()
+				// retry / policies can be added here later
+				w.log.Warn("process failed",
+					zap.String("symbol", msg.Tick.Symbol),
+					zap.Error(err),
+				)
+				msg.Done()
+				continue
+			}
+			msg.Done()
+			observeEndToEnd(msg, done)
+		}
+	}
+}
+
+// process runs proc.Process inside a span, converting a panic into a *supervisor.PanicError.
+// The watchdog is open for the duration of the call.
+func (w *worker) process(ctx context.Context, msg events.TickMsg) (err error) {
+	w.wd.SetLimit(w.th.Stuck())
+	w.wd.Begin()
+	defer w.wd.End()
+	pctx, span := otel.Tracer(tracerName).Start(msg.TraceContext(ctx), "worker.process")
+	span.SetAttributes(
+		attribute.Int("worker", w.id),
+		attribute.String("symbol", msg.Tick.Symbol),
+	)
+	msg.SpanContext = span.SpanContext()
+	defer func() {
+		if err != nil && !errors.Is(err, ErrSkip) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "process")
+		}
+		span.End()
+	}()
+	w.procMu.RLock()
+	defer w.procMu.RUnlock()
+	defer supervisor.Recover(&err)
+	return w.proc.Process(pctx, msg)
+}
+
+// observeEndToEnd records latency from the tick event time and the Kafka
+// append time to the moment processing completed.
+func observeEndToEnd(msg events.TickMsg, done time.Time) {
+	if !msg.Tick.Ts.IsZero() {
+		sfmetrics.ProcessorEndToEndLatencySeconds.WithLabelValues("event").Observe(done.Sub(msg.Tick.Ts).Seconds())
+	}
+	if !msg.Kafka.Time.IsZero() {
+		sfmetrics.ProcessorEndToEndLatencySeconds.WithLabelValues("kafka").Observe(done.Sub(msg.Kafka.Time).Seconds())
 	}
 }
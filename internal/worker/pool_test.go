@@ -0,0 +1,129 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jonandereg/streamforge/internal/deadletter"
+	"github.com/jonandereg/streamforge/internal/events"
+	"github.com/jonandereg/streamforge/internal/supervisor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// hooked records its lifecycle calls; flushErr fails every flush.
+type hooked struct {
+	mu       sync.Mutex
+	calls    []string
+	flushes  atomic.Int32
+	startErr error
+	flushErr error
+}
+
+func (h *hooked) record(c string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls = append(h.calls, c)
+}
+
+func (h *hooked) Start(context.Context) error { h.record("start"); return h.startErr }
+
+func (h *hooked) Process(context.Context, events.TickMsg) error { h.record("process"); return nil }
+
+func (h *hooked) Flush(context.Context) error {
+	h.flushes.Add(1)
+	return h.flushErr
+}
+
+func (h *hooked) Close(context.Context) error { h.record("close"); return nil }
+
+type plain struct{}
+
+func (plain) Process(context.Context, events.TickMsg) error { return nil }
+
+func start(t *testing.T, proc Processor, th *Thresholds) (*Pool, chan events.TickMsg, *supervisor.Supervisor) {
+	t.Helper()
+	in := make(chan events.TickMsg, 1)
+	sup := supervisor.New(zap.NewNop(), time.Millisecond, time.Millisecond)
+	p, err := StartWorkers(context.Background(), []chan events.TickMsg{in}, proc, sup, &deadletter.LogSink{Log: zap.NewNop()}, th, nil, zap.NewNop())
+	require.NoError(t, err)
+	return p, in, sup
+}
+
+func TestLifecycleHooks(t *testing.T) {
+	h := &hooked{}
+	th := &Thresholds{}
+	th.SetFlushEvery(time.Millisecond)
+	p, in, sup := start(t, h, th)
+
+	assert.Eventually(t, func() bool { return h.flushes.Load() >= 2 }, time.Second, time.Millisecond,
+		"flushed periodically without messages")
+	in <- events.TickMsg{}
+	close(in)
+	sup.Wait() // the flusher stops with the workers
+	require.NoError(t, p.Stop(context.Background()))
+	assert.Equal(t, []string{"start", "process", "close"}, h.calls)
+}
+
+func TestHookErrors(t *testing.T) {
+	_, err := StartWorkers(context.Background(), nil, &hooked{startErr: errors.New("no state")},
+		supervisor.New(zap.NewNop(), time.Millisecond, time.Millisecond), nil, nil, nil, zap.NewNop())
+	assert.ErrorContains(t, err, "processor start: no state")
+
+	h := &hooked{flushErr: errors.New("sink down")}
+	p, in, _ := start(t, h, nil)
+	defer close(in)
+	assert.Error(t, p.Flush(context.Background()))
+	assert.ErrorContains(t, p.Check(context.Background()), "sink down")
+	h.flushErr = nil
+	assert.NoError(t, p.Flush(context.Background()))
+	assert.NoError(t, p.Check(context.Background()))
+
+	p, in, _ = start(t, plain{}, nil)
+	defer close(in)
+	assert.ErrorIs(t, p.Flush(context.Background()), ErrNotFlusher)
+}
+
+// blocking holds Process until release is closed.
+type blocking struct {
+	entered, release chan struct{}
+	inProcess        atomic.Bool
+	overlapped       atomic.Bool
+}
+
+func (b *blocking) Process(context.Context, events.TickMsg) error {
+	b.inProcess.Store(true)
+	close(b.entered)
+	<-b.release
+	b.inProcess.Store(false)
+	return nil
+}
+
+func (b *blocking) Flush(context.Context) error {
+	b.overlapped.Store(b.inProcess.Load())
+	return nil
+}
+
+func TestFlushWaitsForProcess(t *testing.T) {
+	b := &blocking{entered: make(chan struct{}), release: make(chan struct{})}
+	p, in, _ := start(t, b, nil)
+	defer close(in)
+	in <- events.TickMsg{}
+	<-b.entered
+
+	flushed := make(chan error)
+	go func() { flushed <- p.Flush(context.Background()) }()
+	select {
+	case <-flushed:
+		t.Fatal("flush ran during Process")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(b.release)
+	require.NoError(t, <-flushed)
+	assert.False(t, b.overlapped.Load())
+}